@@ -33,6 +33,8 @@ The first configuration file present is used.  Others are ignored.
 	cmd.AddCommand(
 		NewUploadCmd(action),
 		NewDownloadCmd(action),
+		NewRemoveCmd(action),
+		NewLineageCmd(action),
 		NewClientConfigCmd(action),
 	)
 	return cmd
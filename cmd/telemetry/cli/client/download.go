@@ -30,7 +30,7 @@ func NewDownloadCmd(clientAction *actions.Client) *cobra.Command {
 
 	cmd.Flags().BoolVar(&action.All, "all", true, "all object types (specify the <path> as the test set directory)")
 
-	cmd.Flags().BoolVar(&action.FromLatest, "from-latest", true, `read the index.latest file for each type and only request the data since then.
+	cmd.Flags().BoolVar(&action.FromLatest, "from-latest", true, `read the index.latest file (the position of the last record downloaded) for each type and only request the data after it.
 		This is very useful when combined with --all to provide incremental backups/mirrors.`)
 
 	return cmd
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/actions"
+	"github.com/act3-ai/data-telemetry/v3/internal/lineage"
+)
+
+// NewLineageCmd creates a new "lineage" command.
+func NewLineageCmd(clientAction *actions.Client) *cobra.Command {
+	action := &actions.Lineage{
+		Client: clientAction,
+	}
+
+	formats := make([]string, len(lineage.Formats))
+	for i, f := range lineage.Formats {
+		formats[i] = string(f)
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lineage <digest> <url>",
+		Short: "Export the lineage of the bottle with <digest> from the server at <url>",
+		Long: `The lineage is the graph of the ancestors (the sources of the bottle, their sources, and so on) and the descendants of the bottle.
+Sources that are not bottles and bottles that are not known to the server are included as well.
+
+The formats are:
+  json       the lineage as returned by the REST API
+  prov-json  W3C PROV-JSON (bottles and other sources are entities, sources are derivations, and authors are agents)
+  prov-o     W3C PROV-O in Turtle (with the same mapping as prov-json)
+  dot        GraphViz DOT (e.g., render it with "dot -Tsvg")
+  graphml    GraphML`,
+		Example: `telemetry client lineage sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 https://telemetry.example.com --format dot --ancestors 3 | dot -Tsvg > lineage.svg`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Run(cmd.Context(), cmd.OutOrStdout(), args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVar(&action.Format, "format", string(lineage.FormatJSON), "output format ("+strings.Join(formats, ", ")+")")
+	cmd.Flags().UintVar(&action.Ancestors, "ancestors", 1, "number of generations of ancestors")
+	cmd.Flags().UintVar(&action.Descendants, "descendants", 1, "number of generations of descendants")
+
+	return cmd
+}
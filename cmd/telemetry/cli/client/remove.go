@@ -0,0 +1,34 @@
+package client
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/actions"
+)
+
+// NewRemoveCmd creates a new "remove" command.
+func NewRemoveCmd(clientAction *actions.Client) *cobra.Command {
+	action := &actions.Remove{
+		Client: clientAction,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "remove <digest> <url>",
+		Short: "Remove the bottle with <digest> from the server at <url>",
+		Long: `This permanently deletes the bottle along with its manifests, events, signatures, and the public artifacts that no other bottle uses.
+It is intended for bottles whose metadata leaked sensitive information.  It requires an admin (see "auth.admin" in the server configuration) so bottles cannot be removed from servers without authentication.
+
+A tombstone is left in place of the bottle.  The server rejects uploads of the removed objects and "telemetry client download"
+discards its copies of them so mirrors do not upload them again.`,
+		Example: `telemetry client remove sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 https://telemetry.example.com --reason "author email leaked"`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Run(cmd.Context(), cmd.OutOrStdout(), args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVar(&action.Reason, "reason", "", "why the bottle is removed (required, it is recorded in the tombstone)")
+	_ = cmd.MarkFlagRequired("reason")
+
+	return cmd
+}
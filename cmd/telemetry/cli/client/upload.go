@@ -24,6 +24,7 @@ func NewUploadCmd(clientAction *actions.Client) *cobra.Command {
 
 	cmd.Flags().BoolVar(&action.All, "all", true, "all object types (specify the <path> as the test set directory)")
 	cmd.Flags().BoolVar(&action.SkipInvalid, "continue", false, "continue uploading after encountering an invalid manifest error")
+	cmd.Flags().StringVar(&action.SigningKeyFile, "signing-key", "", "PEM encoded ed25519 private key used to sign the upload requests (overrides the location's signingKeyFile)")
 
 	return cmd
 }
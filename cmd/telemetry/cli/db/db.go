@@ -0,0 +1,30 @@
+// Package db contains the CLI for administering the Telemetry server's database
+package db
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/actions"
+)
+
+// NewDBCmd creates a new "db" command.
+func NewDBCmd(telemetryAction *actions.Telemetry) *cobra.Command {
+	action := &actions.DB{
+		Telemetry: telemetryAction,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Database administration commands",
+		Long: `These commands connect directly to the database from the server configuration.
+They are intended to be used by administrators during maintenance windows.`,
+	}
+
+	cmd.AddCommand(
+		NewGCCmd(action),
+		NewMigrateCmd(action),
+		NewReprocessCmd(action),
+		NewStatusCmd(action),
+	)
+	return cmd
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/actions"
+)
+
+// NewGCCmd creates a new "gc" command.
+func NewGCCmd(dbAction *actions.DB) *cobra.Command {
+	action := &actions.DBGC{
+		DB: dbAction,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete expired events and data that is no longer referenced",
+		Long: `Events older than allowed by the retention rules in the server configuration are deleted
+(with their counts saved if the rule has keepCounts).  Then data (e.g., manifests and artifacts)
+that is no longer referenced by any object is deleted, including from blob storage.
+Data created in the last hour is never deleted so uploads in progress are not affected.
+
+The server does this periodically when "retention.interval" is set in the server configuration.`,
+		Example: `Show what would be deleted:
+telemetry db gc --dry-run`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().BoolVar(&action.DryRun, "dry-run", false, "report what would be deleted without deleting anything")
+
+	return cmd
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/actions"
+)
+
+// NewMigrateCmd creates a new "migrate" command.
+func NewMigrateCmd(dbAction *actions.DB) *cobra.Command {
+	action := &actions.DBMigrate{
+		DB: dbAction,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the database schema",
+		Long: `This applies the database schema and, when blob storage is configured, moves raw data that is still in the database to the blob storage.
+Rows that are out of date are not reprocessed (see "telemetry db reprocess").`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Run(cmd.Context())
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/actions"
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+)
+
+// NewReprocessCmd creates a new "reprocess" command.
+func NewReprocessCmd(dbAction *actions.DB) *cobra.Command {
+	action := &actions.DBReprocess{
+		DB: dbAction,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "reprocess",
+		Short: "Reprocess rows that were processed by an older version of the server",
+		Long: `This re-runs the processors over the raw data stored in the database.
+The database schema must already be migrated (see "telemetry db migrate").`,
+		Example: `Reprocess all out of date bottles:
+telemetry db reprocess --type bottle
+
+Reprocess all bottles and manifests regardless of version:
+telemetry db reprocess --type bottle --type manifest --force`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&action.Types, "type", "t", nil,
+		"type of object to reprocess (blob, bottle, manifest, event, signature), all types if not provided")
+	cmd.Flags().BoolVar(&action.Force, "force", false, "reprocess rows even if they are up to date")
+	cmd.Flags().IntVar(&action.BatchSize, "batch-size", db.DefaultReprocessBatchSize, "number of rows to reprocess in each transaction")
+
+	return cmd
+}
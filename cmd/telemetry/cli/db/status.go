@@ -0,0 +1,25 @@
+package db
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/actions"
+)
+
+// NewStatusCmd creates a new "status" command.
+func NewStatusCmd(dbAction *actions.DB) *cobra.Command {
+	action := &actions.DBStatus{
+		DB: dbAction,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the number of rows processed by each processor version",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return action.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+
+	return cmd
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/act3-ai/go-common/pkg/version"
 
 	"github.com/act3-ai/data-telemetry/v3/cmd/telemetry/cli/client"
+	"github.com/act3-ai/data-telemetry/v3/cmd/telemetry/cli/db"
 	"github.com/act3-ai/data-telemetry/v3/internal/actions"
 	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
 )
@@ -44,6 +45,7 @@ The first configuration file present is used.  Others are ignored.
 		NewServeCmd(action),
 		NewTemplateCmd(),
 		client.NewClientCmd(action),
+		db.NewDBCmd(action),
 		NewConfigCmd(action),
 		NewFilterCmd(),
 	)
@@ -55,12 +55,17 @@ func (action *Client) NewHandler(ctx context.Context) (http.Handler, error) {
 		return nil, err
 	}
 
+	trustPolicy, err := db.NewTrustPolicy(serverConfig.Trust)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust configuration: %w", err)
+	}
+
 	serveMux := http.NewServeMux()
 
 	myAPI := api.API{}
 	myAPI.Initialize(serveMux, scheme)
 
-	return middleware.DatabaseMiddleware(myDB)(serveMux), nil
+	return middleware.DatabaseMiddleware(myDB)(middleware.TrustAnchorMiddleware(trustPolicy)(serveMux)), nil
 }
 
 // AddClientConfigOverride adds an override function that will be called in GetConfig to edit config after loading.
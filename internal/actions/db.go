@@ -0,0 +1,274 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+)
+
+// DB is the base action for database administration commands.
+type DB struct {
+	*Telemetry
+}
+
+// connect connects to the database from the server configuration without migrating it.
+func (action *DB) connect(ctx context.Context) (*gorm.DB, *runtime.Scheme, error) {
+	serverConfig, err := action.GetServerConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := bottle.AddToScheme(scheme); err != nil {
+		return nil, nil, fmt.Errorf("adding bottle to scheme: %w", err)
+	}
+
+	con, err := db.Connect(ctx, serverConfig.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+	return con, scheme, nil
+}
+
+// DBMigrate is the action for migrating the database schema.
+type DBMigrate struct {
+	*DB
+}
+
+// Run is the action method.
+func (action *DBMigrate) Run(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+
+	con, _, err := action.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	serverConfig, err := action.GetServerConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	isLeader, err := db.RunAsLeader(ctx, con, db.MigrationTask, func(con *gorm.DB) error {
+		if err := db.MigrateDB(ctx, con); err != nil {
+			return err
+		}
+		if db.BlobStore(con) == nil {
+			return nil
+		}
+		moved, err := db.MoveToBlobStore(ctx, con, serverConfig.DB.ReprocessBatchSize)
+		if err != nil {
+			return err
+		}
+		log.InfoContext(ctx, "Data moved to blob storage", "rows", moved)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !isLeader {
+		return errors.New("the database is being migrated by another process")
+	}
+
+	log.InfoContext(ctx, "Database schema migrated")
+	return nil
+}
+
+// DBReprocess is the action for reprocessing rows in the database.
+type DBReprocess struct {
+	*DB
+
+	// Types to reprocess (e.g., "bottle"), all types are reprocessed if empty
+	Types []string
+
+	// Force reprocessing of rows that are already up to date
+	Force bool
+
+	// BatchSize is the number of rows reprocessed in each transaction
+	BatchSize int
+}
+
+// Run is the action method.
+func (action *DBReprocess) Run(ctx context.Context) error {
+	con, scheme, err := action.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	processors, err := selectProcessors(db.Processors(scheme), action.Types)
+	if err != nil {
+		return err
+	}
+
+	opts := db.ReprocessOptions{
+		BatchSize: action.BatchSize,
+		Force:     action.Force,
+	}
+
+	isLeader, err := db.RunAsLeader(ctx, con, db.ReprocessTask, func(con *gorm.DB) error {
+		for _, processor := range processors {
+			if err := db.Reprocess(ctx, con, processor, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !isLeader {
+		return errors.New("the database is being reprocessed by another process")
+	}
+	return nil
+}
+
+// selectProcessors returns the processors (in processing order) for the given types.
+func selectProcessors(processors []db.Processor, types []string) ([]db.Processor, error) {
+	if len(types) == 0 {
+		return processors, nil
+	}
+
+	known := make([]string, 0, len(processors))
+	for _, processor := range processors {
+		known = append(known, processorType(processor))
+	}
+
+	for _, t := range types {
+		if !slices.Contains(known, t) {
+			return nil, fmt.Errorf("unknown type %q, must be one of %s", t, strings.Join(known, ", "))
+		}
+	}
+
+	selected := make([]db.Processor, 0, len(types))
+	for _, processor := range processors {
+		if slices.Contains(types, processorType(processor)) {
+			selected = append(selected, processor)
+		}
+	}
+	return selected, nil
+}
+
+// processorType returns the type name (e.g., "bottle") for the processor.
+func processorType(processor db.Processor) string {
+	return strings.TrimSuffix(processor.PrimaryTable(), "s")
+}
+
+// DBStatus is the action for reporting the processing status of the database.
+type DBStatus struct {
+	*DB
+}
+
+// Run is the action method.
+func (action *DBStatus) Run(ctx context.Context, out io.Writer) error {
+	con, scheme, err := action.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]any{}
+	for _, processor := range db.Processors(scheme) {
+		table := processor.PrimaryTable()
+		counts, err := db.ProcessorVersionCounts(con, table)
+		if err != nil {
+			return fmt.Errorf("counting rows in %s: %w", table, err)
+		}
+		if len(counts) == 0 {
+			rows = append(rows, []any{table, processor.Version(), 0, "empty"})
+		}
+		for _, c := range counts {
+			var status string
+			switch {
+			case c.ProcessorVersion < processor.Version():
+				status = "needs reprocessing"
+			case c.ProcessorVersion > processor.Version():
+				status = "processed by a newer version"
+			default:
+				status = "current"
+			}
+			rows = append(rows, []any{table, c.ProcessorVersion, c.Count, status})
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "TABLE\tPROCESSOR VERSION\tROWS\tSTATUS"); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", row...); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// DBGC is the action for garbage collecting expired events and unreferenced data.
+type DBGC struct {
+	*DB
+
+	// DryRun reports what would be deleted without deleting anything
+	DryRun bool
+}
+
+// Run is the action method.
+func (action *DBGC) Run(ctx context.Context, out io.Writer) error {
+	con, _, err := action.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	serverConfig, err := action.GetServerConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	var report db.GCReport
+	isLeader, err := db.RunAsLeader(ctx, con, db.GCTask, func(con *gorm.DB) error {
+		var err error
+		report, err = db.CollectGarbage(ctx, con, db.GCOptions{
+			Events: serverConfig.Retention.Events,
+			DryRun: action.DryRun,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !isLeader {
+		return errors.New("garbage collection is being done by another process")
+	}
+
+	verb := "Deleted"
+	if action.DryRun {
+		verb = "Would delete"
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintf(w, "%s\n", verb); err != nil {
+		return err
+	}
+	for _, row := range [][]any{
+		{"Expired events", report.Events},
+		{"Event counts saved", report.EventCounts},
+		{"Expired pending objects", report.Pending},
+		{"Unreferenced data", report.Data},
+		{"Digests", report.Digests},
+		{"Bytes", report.Bytes},
+		{"Orphaned blobs", report.Blobs},
+	} {
+		if _, err := fmt.Fprintf(w, "  %s\t%d\n", row...); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
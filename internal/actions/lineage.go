@@ -0,0 +1,54 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/lineage"
+	client "github.com/act3-ai/data-telemetry/v3/pkg/client"
+)
+
+// Lineage is the action for exporting the lineage of a bottle.
+type Lineage struct {
+	*Client
+
+	Format      string
+	Ancestors   uint
+	Descendants uint
+}
+
+// Run is the action method.
+func (action *Lineage) Run(ctx context.Context, out io.Writer, bottleDigest, telemetryServerURL string) error {
+	dgst, err := digest.Parse(bottleDigest)
+	if err != nil {
+		return fmt.Errorf("parsing bottle digest: %w", err)
+	}
+	format, err := lineage.ParseFormat(action.Format)
+	if err != nil {
+		return err
+	}
+
+	clientConfig, err := action.GetClientConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	newconfig, err := matchURLConfig(telemetryServerURL, clientConfig)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.NewSingleClient(authClientOrDefault(ctx, newconfig), telemetryServerURL, string(newconfig.Token))
+	if err != nil {
+		return err
+	}
+
+	graph, err := c.GetLineage(ctx, dgst, action.Ancestors, action.Descendants)
+	if err != nil {
+		return err
+	}
+	return lineage.Write(out, graph, format)
+}
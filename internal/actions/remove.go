@@ -0,0 +1,51 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+
+	client "github.com/act3-ai/data-telemetry/v3/pkg/client"
+)
+
+// Remove is the action for removing a bottle from the server.
+type Remove struct {
+	*Client
+
+	Reason string
+}
+
+// Run is the action method.
+func (action *Remove) Run(ctx context.Context, out io.Writer, bottleDigest, telemetryServerURL string) error {
+	dgst, err := digest.Parse(bottleDigest)
+	if err != nil {
+		return fmt.Errorf("parsing bottle digest: %w", err)
+	}
+	if action.Reason == "" {
+		return errors.New("a reason is required")
+	}
+
+	clientConfig, err := action.GetClientConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	newconfig, err := matchURLConfig(telemetryServerURL, clientConfig)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.NewSingleClient(authClientOrDefault(ctx, newconfig), telemetryServerURL, string(newconfig.Token))
+	if err != nil {
+		return err
+	}
+
+	if err := c.RemoveBottle(ctx, dgst, action.Reason); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "Removed bottle %s\n", dgst)
+	return err
+}
@@ -2,10 +2,12 @@ package actions
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"gorm.io/gorm"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
@@ -42,7 +44,7 @@ func (action *Serve) Run(ctx context.Context) error {
 		return err
 	}
 
-	myApp, err := app.NewApp(myDB, scheme, serverConfig.WebApp, log, action.GetVersionInfo().Version)
+	myApp, err := app.NewApp(myDB, scheme, serverConfig.ServerConfigurationSpec, log, action.GetVersionInfo().Version)
 	if err != nil {
 		return err
 	}
@@ -57,6 +59,44 @@ func (action *Serve) Run(ctx context.Context) error {
 		IdleTimeout:  time.Second * 60,
 		Handler:      myApp.HTTPHandler,
 	}
+
+	if serverConfig.DB.BackgroundReprocess {
+		go func() {
+			isLeader, err := db.RunAsLeader(ctx, myDB, db.ReprocessTask, func(con *gorm.DB) error {
+				return db.ReprocessAll(ctx, con, scheme, db.ReprocessOptions{BatchSize: serverConfig.DB.ReprocessBatchSize})
+			})
+			switch {
+			case errors.Is(err, context.Canceled):
+				log.InfoContext(ctx, "Background reprocessing stopped, it will resume on the next start")
+			case err != nil:
+				log.ErrorContext(ctx, "Background reprocessing failed", "error", err)
+			case !isLeader:
+				log.InfoContext(ctx, "Background reprocessing is being done by another replica")
+			default:
+				log.InfoContext(ctx, "Background reprocessing complete")
+			}
+		}()
+	}
+	go func() {
+		isLeader, err := db.RunAsLeader(ctx, myDB, db.CompressionTask, func(con *gorm.DB) error {
+			_, err := db.Recompress(ctx, con, serverConfig.DB.ReprocessBatchSize)
+			return err
+		})
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.InfoContext(ctx, "Background compression stopped, it will resume on the next start")
+		case err != nil:
+			log.ErrorContext(ctx, "Background compression failed", "error", err)
+		case !isLeader:
+			log.InfoContext(ctx, "Background compression is being done by another replica")
+		}
+	}()
+
+	go db.RunGarbageCollector(ctx, myDB, serverConfig.Retention)
+
+	// every replica delivers webhooks, deliveries are claimed so each is only sent by one replica at a time
+	go myApp.Webhooks.Run(ctx, myDB)
+
 	if err := httputil.Serve(ctx, srv, 10*time.Second); err != nil {
 		return fmt.Errorf("problem occurred in serve action: %w", err)
 	}
@@ -10,8 +10,9 @@ import (
 type Upload struct {
 	*Client
 
-	All         bool
-	SkipInvalid bool
+	All            bool
+	SkipInvalid    bool
+	SigningKeyFile string
 }
 
 // Run is the action method.
@@ -31,6 +32,18 @@ func (action *Upload) Run(ctx context.Context, path, telemetryServerURL string)
 		return err
 	}
 
+	signingKeyFile := action.SigningKeyFile
+	if signingKeyFile == "" {
+		signingKeyFile = newconfig.SigningKeyFile
+	}
+	if signingKeyFile != "" {
+		key, err := client.LoadSigningKey(signingKeyFile)
+		if err != nil {
+			return err
+		}
+		c.SetSigningKey(key)
+	}
+
 	if action.All {
 		// return client.UploadAll(ctx, c, path, u, handler)
 		return c.UploadAll(ctx, path, action.SkipInvalid)
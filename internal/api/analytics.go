@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/schema"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// decodeAnalyticsQuery decodes the query parameters into query (a pointer to a query struct).
+// Times may be RFC 3339 or milliseconds since the Unix epoch.
+func decodeAnalyticsQuery(r *http.Request, query any) error {
+	values := r.URL.Query()
+	for _, v := range values {
+		for i := range v {
+			v[i] = strings.TrimSpace(v[i])
+		}
+	}
+
+	decoder := schema.NewDecoder()
+	decoder.RegisterConverter(time.Time{}, parseSearchTime)
+	if err := decoder.Decode(query, values); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+	return nil
+}
+
+// handleEventAnalytics responds with the number of events in each time bucket for each group (see types.EventAnalyticsQuery).
+func handleEventAnalytics(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	con := middleware.DatabaseFromContext(ctx)
+
+	query := types.EventAnalyticsQuery{}
+	if err := decodeAnalyticsQuery(r, &query); err != nil {
+		return err
+	}
+	if err := db.ValidateEventAnalyticsQuery(query); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+	log.InfoContext(ctx, "Parameters", "query", query)
+
+	analytics, err := db.EventAnalytics(con, query)
+	if err != nil {
+		return err
+	}
+
+	if err := httputil.WriteJSON(w, analytics); err != nil {
+		return fmt.Errorf("could not write JSON results: %w", err)
+	}
+	return nil
+}
+
+// handleTransferStats responds with the bytes transferred for each bottle, repository, and user
+// and the throughput of each repository over time (see types.TransferQuery).
+func handleTransferStats(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	con := middleware.DatabaseFromContext(ctx)
+
+	query := types.TransferQuery{}
+	if err := decodeAnalyticsQuery(r, &query); err != nil {
+		return err
+	}
+	if err := db.ValidateTransferQuery(query); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+	log.InfoContext(ctx, "Parameters", "query", query)
+
+	stats, err := db.TransferStats(con, query)
+	if err != nil {
+		return err
+	}
+
+	if err := httputil.WriteJSON(w, stats); err != nil {
+		return fmt.Errorf("could not write JSON results: %w", err)
+	}
+	return nil
+}
+
+// handleStorageStats responds with the storage of the layers of the manifests of bottles (see types.StorageQuery).
+func handleStorageStats(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	con := middleware.DatabaseFromContext(ctx)
+
+	query := types.StorageQuery{}
+	if err := decodeAnalyticsQuery(r, &query); err != nil {
+		return err
+	}
+	if err := db.ValidateStorageQuery(query); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+	log.InfoContext(ctx, "Parameters", "query", query)
+
+	stats, err := db.StorageStats(con, query)
+	if err != nil {
+		return err
+	}
+
+	if err := httputil.WriteJSON(w, stats); err != nil {
+		return fmt.Errorf("could not write JSON results: %w", err)
+	}
+	return nil
+}
@@ -4,30 +4,95 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gorm.io/gorm"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/act3-ai/bottle-schema/pkg/mediatype"
 	"github.com/act3-ai/go-common/pkg/httputil"
 
 	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/internal/webhook"
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
 )
 
 // API implements the REST API.
-type API struct{}
+type API struct {
+	// Webhooks queues webhook deliveries for newly ingested objects (optional)
+	Webhooks *webhook.Dispatcher
+
+	// Pending is the configuration of the queue of objects waiting for the objects they depend on
+	Pending v1alpha2.PendingQueue
+
+	// processors are the processors of each type of object
+	processors map[string]db.Processor
+
+	// changes is notified when objects are ingested
+	changes *notifier
+}
+
+// ingestHooks are notified of newly ingested objects.
+type ingestHooks interface {
+	// ingested is called within the ingest transaction for each new object.
+	ingested(con *gorm.DB, processor db.Processor, dataID uint, dgst digest.Digest) error
+
+	// queue is called within the ingest transaction when the object could not be processed.
+	// It returns the pending object if the object was queued to wait for the objects it depends on, otherwise err.
+	queue(con *gorm.DB, itemType string, dataID uint, dgst digest.Digest, err error) (*types.PendingObject, error)
+
+	// committed is called after the ingest transaction is committed.
+	committed()
+}
+
+func (a *API) ingested(con *gorm.DB, processor db.Processor, dataID uint, dgst digest.Digest) error {
+	if a.Webhooks != nil {
+		if err := a.Webhooks.Enqueue(con, processor.PrimaryTable(), dataID, dgst); err != nil {
+			return err
+		}
+	}
+	return a.resolvePending(con, dataID)
+}
+
+func (a *API) committed() {
+	a.changes.Notify()
+	if a.Webhooks != nil {
+		a.Webhooks.Wake()
+	}
+}
 
 // Initialize setup the API handlers.
 func (a *API) Initialize(serveMux *http.ServeMux, scheme *runtime.Scheme) {
-	a.addBasicRoutes(serveMux, "blob", "application/octet-stream", &db.BlobProcessor{})
-	a.addBasicRoutes(serveMux, "bottle", mediatype.MediaTypeBottleConfig, db.NewBottleProcessor(scheme))
-	a.addBasicRoutes(serveMux, "manifest", ocispec.MediaTypeImageManifest, &db.ManifestProcessor{})
-	a.addBasicRoutes(serveMux, "event", "application/json", &db.EventProcessor{})
-	a.addBasicRoutes(serveMux, "signature", "application/json", &db.SignatureProcessor{})
-	// Handler(httputils.SignatureVerifyMiddleware(httputil.RootHandler(handlePutEvent)))
+	a.changes = newNotifier()
+
+	a.processors = map[string]db.Processor{
+		"blob":      &db.BlobProcessor{},
+		"bottle":    db.NewBottleProcessor(scheme),
+		"manifest":  &db.ManifestProcessor{},
+		"event":     &db.EventProcessor{},
+		"signature": &db.SignatureProcessor{},
+	}
+
+	a.addBasicRoutes(serveMux, "blob", "application/octet-stream", a.processors["blob"])
+	a.addBasicRoutes(serveMux, "bottle", mediatype.MediaTypeBottleConfig, a.processors["bottle"])
+	a.addBasicRoutes(serveMux, "manifest", ocispec.MediaTypeImageManifest, a.processors["manifest"])
+	a.addBasicRoutes(serveMux, "event", "application/json", a.processors["event"])
+	a.addBasicRoutes(serveMux, "signature", "application/json", a.processors["signature"])
+
+	// Mixed object types in one request
+	serveMux.Handle("POST /bulk", httputil.AllowContentTypeMiddleware(handleBulk(a.processors, a), types.MediaTypeNDJSON))
+
+	// Newly ingested objects as Server-Sent Events
+	serveMux.Handle("GET /stream", handleStream(a.changes))
 
 	// Bottle search
 	serveMux.Handle("GET /search", httputil.RootHandler(handleBottleSearch))
 
+	// Bottle search with the filters of the catalog (in the web app)
+	serveMux.Handle("GET /catalog", httputil.RootHandler(handleCatalogSearch))
+
 	// Content search
 	serveMux.Handle("GET /content", httputil.RootHandler(handleContentSearch))
 
@@ -36,9 +101,35 @@ func (a *API) Initialize(serveMux *http.ServeMux, scheme *runtime.Scheme) {
 
 	serveMux.Handle("GET /location", httputil.RootHandler(handleGetLocation))
 
+	// Tag history of a repository and what a tag points to
+	serveMux.Handle("GET /tags", httputil.RootHandler(handleGetTags))
+	serveMux.Handle("GET /resolve", httputil.RootHandler(handleResolve))
+
+	// Bottle lineage (ancestors and descendants)
+	serveMux.Handle("GET /lineage", httputil.RootHandler(handleGetLineage))
+
+	// Difference between two bottles
+	serveMux.Handle("GET /diff", httputil.RootHandler(handleGetDiff))
+
+	// Event counts over time
+	serveMux.Handle("GET /analytics/events", httputil.RootHandler(handleEventAnalytics))
+
+	// Bytes transferred and throughput
+	serveMux.Handle("GET /analytics/transfers", httputil.RootHandler(handleTransferStats))
+
+	// Layer storage (compression, archiving, and deduplication)
+	serveMux.Handle("GET /analytics/storage", httputil.RootHandler(handleStorageStats))
+
 	// Bottle Signatures
 	serveMux.Handle("GET /signatures", httputil.RootHandler(handleGetSignatures))
 	serveMux.Handle("GET /signature/validate", httputil.RootHandler(handleGetSigValid))
+
+	// Administrative removal of a bottle (only possible when authentication is configured) and the list of removed bottles
+	serveMux.Handle("DELETE /bottle", middleware.RequireAuthenticatedAdmin(httputil.RootHandler(handleRemoveBottle)))
+	serveMux.Handle("GET /tombstone", httputil.RootHandler(handleListTombstones))
+
+	// Objects waiting for the objects they depend on
+	serveMux.Handle("GET /pending", httputil.RootHandler(handleListPending))
 }
 
 func (a *API) addBasicRoutes(serveMux *http.ServeMux, itemType, contentType string, processor db.Processor) {
@@ -60,5 +151,5 @@ func (a *API) addBasicRoutes(serveMux *http.ServeMux, itemType, contentType stri
 		}
 	})
 
-	serveMux.Handle(fmt.Sprintf("PUT %s", path), httputil.AllowContentTypeMiddleware(genericPutData(processor), contentType))
+	serveMux.Handle(fmt.Sprintf("PUT %s", path), httputil.AllowContentTypeMiddleware(genericPutData(itemType, processor, a), contentType))
 }
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// handleBulk returns an HTTP handler that ingests a stream of newline delimited types.BulkRecord.
+// Records are processed in topological order (see types.TopologicalOrderingOfTypes) within one transaction so
+// a record may depend on any other record in the same request.
+// A rejected record does not prevent the other records from being accepted.
+// The response contains the status of each record in request order.
+func handleBulk(processors map[string]db.Processor, hooks ingestHooks) http.Handler {
+	return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		con := middleware.DatabaseFromContext(ctx)
+
+		records := []types.BulkRecord{}
+		dec := json.NewDecoder(r.Body)
+		for {
+			var record types.BulkRecord
+			if err := dec.Decode(&record); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return httputil.NewHTTPError(err, http.StatusBadRequest, fmt.Sprintf("Invalid record at index %d", len(records)))
+			}
+			records = append(records, record)
+		}
+
+		// process in topological order but report in request order
+		statuses := make([]types.BulkRecordStatus, len(records))
+		order := make([]int, len(records))
+		for i, record := range records {
+			order[i] = i
+			statuses[i] = types.BulkRecordStatus{
+				Index: i,
+				Type:  record.Type,
+			}
+		}
+		slices.SortStableFunc(order, func(a, b int) int {
+			return cmp.Compare(typeRank(records[a].Type), typeRank(records[b].Type))
+		})
+
+		err := con.Transaction(func(tx *gorm.DB) error {
+			for _, i := range order {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				record := records[i]
+				status := &statuses[i]
+
+				processor, ok := processors[record.Type]
+				if !ok {
+					status.StatusCode = http.StatusBadRequest
+					status.Error = fmt.Sprintf("unknown type %q", record.Type)
+					continue
+				}
+
+				if err := authorizePut(ctx, record.Type, record.Data); err != nil {
+					if err := setBulkRecordStatus(status, false, err); err != nil {
+						return err
+					}
+					continue
+				}
+
+				dgst, err := bulkRecordDigest(record)
+				if err != nil {
+					status.StatusCode = http.StatusBadRequest
+					status.Error = err.Error()
+					continue
+				}
+				status.Digest = dgst
+
+				// each record gets a savepoint so a rejected record does not abort the whole transaction
+				var existed bool
+				var pending *types.PendingObject
+				err = tx.Transaction(func(tx *gorm.DB) error {
+					dataID, exists, err := putData(tx, processor, record.Data, dgst)
+					existed = exists
+					if err != nil {
+						pending, err = hooks.queue(tx, record.Type, dataID, dgst, err)
+						return err
+					}
+					if existed {
+						return nil
+					}
+					return hooks.ingested(tx, processor, dataID, dgst)
+				})
+				if err := setBulkRecordStatus(status, existed, err); err != nil {
+					return err
+				}
+				if pending != nil {
+					status.StatusCode = http.StatusAccepted
+					status.MissingDigests = pending.MissingDigests
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		accepted := 0
+		for _, status := range statuses {
+			if status.OK() {
+				accepted++
+			}
+		}
+		if accepted > 0 {
+			hooks.committed()
+		}
+		log.InfoContext(ctx, "Bulk ingest", "records", len(records), "accepted", accepted, "rejected", len(records)-accepted,
+			"signingKey", middleware.SigningKeyFromContext(ctx))
+
+		if err := httputil.WriteJSON(w, map[string]any{"Results": statuses}); err != nil {
+			return fmt.Errorf("could not write JSON results: %w", err)
+		}
+		return nil
+	})
+}
+
+// typeRank returns the position of the type in the topological ordering of types.
+// Unknown types sort last.
+func typeRank(itemType string) int {
+	if i := slices.Index(types.TopologicalOrderingOfTypes, itemType); i >= 0 {
+		return i
+	}
+	return len(types.TopologicalOrderingOfTypes)
+}
+
+// bulkRecordDigest returns the verified digest of the record's data.
+func bulkRecordDigest(record types.BulkRecord) (digest.Digest, error) {
+	if record.Digest == "" {
+		return digest.FromBytes(record.Data), nil
+	}
+
+	if err := record.Digest.Validate(); err != nil {
+		return "", fmt.Errorf("invalid digest: %w", err)
+	}
+	if dgst := record.Digest.Algorithm().FromBytes(record.Data); dgst != record.Digest {
+		return "", fmt.Errorf("digest %s does not match the data, calculated digest is %s", record.Digest, dgst)
+	}
+	return record.Digest, nil
+}
+
+// setBulkRecordStatus records the outcome of processing a record.
+// Errors that are not client errors are returned since they abort the bulk request.
+func setBulkRecordStatus(status *types.BulkRecordStatus, existed bool, err error) error {
+	if err == nil {
+		status.StatusCode = http.StatusCreated
+		if existed {
+			status.StatusCode = http.StatusNoContent
+		}
+		return nil
+	}
+
+	var clientErr httputil.ClientError
+	if !errors.As(err, &clientErr) {
+		return err
+	}
+	status.StatusCode, _ = clientErr.ResponseHeaders()
+	status.Error = clientErr.Error()
+
+	var missing *types.MissingDigestsError
+	if errors.As(err, &missing) {
+		status.MissingDigests = missing.MissingDigests
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+)
+
+// handleGetDiff responds with the structural difference (see types.BottleDiff) between two bottles.
+// The "from" and "to" parameters are the digests of the older and newer bottles.
+func handleGetDiff(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	find := func(param string) (digest.Digest, *db.BottleRelative, error) {
+		dgst, err := digest.Parse(r.URL.Query().Get(param))
+		if err != nil {
+			return "", nil, httputil.NewHTTPError(err, http.StatusBadRequest, fmt.Sprintf("Invalid %q parameter", param))
+		}
+		bottle, err := db.FindBottleForDiff(con, dgst)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, httputil.NewHTTPError(err, http.StatusNotFound, fmt.Sprintf("Bottle %q not found", param))
+		}
+		return dgst, bottle, err
+	}
+
+	fromDigest, from, err := find("from")
+	if err != nil {
+		return err
+	}
+	toDigest, to, err := find("to")
+	if err != nil {
+		return err
+	}
+
+	if err := httputil.WriteJSON(w, db.DiffBottles(from, to, fromDigest, toDigest)); err != nil {
+		return fmt.Errorf("could not write JSON results: %w", err)
+	}
+	return nil
+}
@@ -1,12 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/schema"
@@ -18,6 +20,7 @@ import (
 
 	"github.com/act3-ai/data-telemetry/v3/internal/db"
 	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/internal/storage"
 	"github.com/act3-ai/data-telemetry/v3/pkg/types"
 )
 
@@ -75,38 +78,30 @@ func handleGetBottlesFromMetric(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// handleGetLocation is an HTTP handler function that responds with the locations of a bottle in JSON (see types.LocationResponse).
+// The locations are ranked by the most recent pull or push and selected with URL parameters (see types.LocationQuery):
+//   - "bottle_digest" -> the bottle (required).
+//   - "registry" -> only locations in the registry with this host.
+//   - "public" -> only locations that do not require authentication.
 func handleGetLocation(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	// log := logger.FromContext(ctx)
 	con := middleware.DatabaseFromContext(ctx)
 
-	bottleDigest, err := digest.Parse(r.URL.Query().Get("bottle_digest"))
-	if err != nil {
-		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"bottle_digest\" parameter")
+	query := types.LocationQuery{}
+	if err := schema.NewDecoder().Decode(&query, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+	if err := db.ValidateLocationQuery(query); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
 	}
 
-	tx := con.
-		Table("events").
-		// Preload("Manifest").
-
-		// Does not work in Postgres (but works in SQLite)
-		// Select("events.repository", "events.auth_required", "Manifest.digest").
-		// Joins("Bottle").
-		// Joins("Manifest").
-		// Where("Bottle.digest = ?", bottleDigest.String()).
-
-		Distinct("events.repository", "events.auth_required", "events.manifest_digest AS digest").
-		Joins("INNER JOIN bottles ON events.bottle_id = bottles.id").
-		Joins("INNER JOIN manifests ON events.bottle_id = manifests.bottle_id").
-		Scopes(db.FilterByDigest(bottleDigest, "bottles"))
-
-	entries := []types.LocationResponse{}
-	if err := tx.Find(&entries).Error; err != nil {
+	entries, err := db.FindLocations(con, query)
+	if err != nil {
 		return err
 	}
 
 	// TODO We could consider returning the bottle config (then the requester would know the content digests of each part)
-	// TODO we could also consider returning all the known manifests so they know the layer digests of parts (there can be many layer digests for the same content digest)
 	if err := httputil.WriteJSON(w, map[string]any{"Results": entries}); err != nil {
 		return fmt.Errorf("could not write JSON results: %w", err)
 	}
@@ -127,6 +122,7 @@ func handleGetSignatures(w http.ResponseWriter, r *http.Request) error {
 
 	tx := con.
 		Table("signatures").
+		Preload("Annotations").
 		Joins("INNER JOIN bottles ON signatures.bottle_id = bottles.id").
 		Scopes(db.FilterByDigest(bottleDigest, "bottles"))
 
@@ -135,6 +131,7 @@ func handleGetSignatures(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	trustAnchor := middleware.TrustAnchorFromContext(ctx)
 	dtoEntries := []types.SignatureValidationSummary{}
 	for _, e := range entries {
 		annos := map[string]string{}
@@ -145,10 +142,9 @@ func handleGetSignatures(w http.ResponseWriter, r *http.Request) error {
 			SubjectManifest: e.ManifestDigest,
 			SubjectBottleid: e.BottleDigest,
 			Validated:       true, // if it exists in the database, it has been validated
-			// TODO: use appropriate trust anchor
-			Trusted:     e.Trusted(&db.DefaultTrustAnchor{}),
-			Fingerprint: e.PublicKeyFingerPrint.String(),
-			Annotations: annos,
+			Trusted:         e.Trusted(trustAnchor),
+			Fingerprint:     e.PublicKeyFingerPrint.String(),
+			Annotations:     annos,
 		})
 	}
 
@@ -163,6 +159,8 @@ func handleGetSignatures(w http.ResponseWriter, r *http.Request) error {
 //   - "bottle_digest" -> get data for signatures associated with the given bottle.
 //   - "key_fingerprint" -> get data for signatures made with the given key fingerprint.
 //   - "trust_level" -> get data for signatures that match the given trust level ("trusted", or "validated" (default)).
+//
+// Trust is evaluated against the server's trust policy.
 func handleGetSigValid(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	con := middleware.DatabaseFromContext(ctx)
@@ -176,28 +174,39 @@ func handleGetSigValid(w http.ResponseWriter, r *http.Request) error {
 		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"key_fingerprint\" parameter")
 	}
 	trustlevel := r.URL.Query().Get("trust_level")
-	if trustlevel == "" {
+	switch trustlevel {
+	case "":
 		trustlevel = "validated"
+	case "validated", "trusted":
+	default:
+		return httputil.NewHTTPError(nil, http.StatusBadRequest, "Invalid \"trust_level\" parameter")
 	}
 
 	tx := con.
 		Table("signatures").
+		Preload("Annotations").
 		Joins("INNER JOIN bottles ON signatures.bottle_id = bottles.id").
 		Scopes(db.FilterByDigest(bottleDigest, "bottles")).
-		Scopes(db.FilterByPublicKeyFP(keyFP)).
-		Scopes(db.FilterByTrustLevel(trustlevel))
+		Scopes(db.FilterByPublicKeyFP(keyFP))
 
 	entries := []db.Signature{}
 	if err := tx.Find(&entries).Error; err != nil {
 		return err
 	}
 
+	// trust depends on the trust policy so it is not stored in the database
+	trustAnchor := middleware.TrustAnchorFromContext(ctx)
 	dtoEntries := []types.SignatureValid{}
 	for _, e := range entries {
+		trusted := e.Trusted(trustAnchor)
+		if trustlevel == "trusted" && !trusted {
+			continue
+		}
 		dtoEntries = append(dtoEntries, types.SignatureValid{
 			BottleID:  e.BottleDigest,
 			KeyFp:     e.PublicKeyFingerPrint.String(),
 			Validated: true, // if it exists in the database, it has been validated
+			Trusted:   trusted,
 		})
 	}
 	if err := httputil.WriteJSON(w, map[string]any{"Results": dtoEntries}); err != nil {
@@ -217,20 +226,18 @@ func (e *listResultEntry) MarshalJSON() ([]byte, error) {
 		Digests:   e.Digests,
 		CreatedAt: e.CreatedAt,
 		Data:      e.Data.RawData,
+		Cursor:    db.ListCursor{CreatedAt: e.CreatedAt, ID: e.ID}.String(),
 	})
 }
 
+// genericListData lists the data in the table in creation order.
+// The listing starts after the "cursor" parameter (from a previous listing) if provided, otherwise after the "since" time.
 func genericListData(table string) http.Handler {
 	return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
 		ctx := r.Context()
 		// log := logger.FromContext(ctx)
 		con := middleware.DatabaseFromContext(ctx)
 
-		since, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
-		if err != nil {
-			return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"since\" parameter")
-		}
-
 		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
 		if err != nil {
 			return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"limit\" parameter")
@@ -238,11 +245,26 @@ func genericListData(table string) http.Handler {
 
 		tx := con.Table(table).
 			Preload("Data").
-			Select(table+".created_at", table+".data_id").
-			Where(table+".created_at > ?", since).
-			Order(table + ".created_at ASC").Limit(limit).
+			Select(table+".id", table+".created_at", table+".data_id").
+			Limit(limit).
 			Scopes(db.IncludeDigests(table))
 
+		if token := r.URL.Query().Get("cursor"); token != "" {
+			cursor, err := db.ParseListCursor(token)
+			if err != nil {
+				return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"cursor\" parameter")
+			}
+			tx = tx.Scopes(db.AfterCursor(cursor, table))
+		} else {
+			since, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
+			if err != nil {
+				return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"since\" parameter")
+			}
+			tx = tx.Where(table+".created_at > ?", since).
+				Order(table + ".created_at ASC").
+				Order(table + ".id ASC")
+		}
+
 		// What digests reference a object?  We can pull all of them that point to the same piece of data
 		// but (since an artifact can be referenced as sha256 in one bottle and sha512 in another bottle,
 		// we need both references/aliases).  The safe thing it to return all digests.
@@ -269,7 +291,8 @@ func genericGetData(table, mediaType string) http.Handler {
 		}
 
 		base := db.Base{}
-		tx := con.Table(table).
+		// the data is only decompressed if the client does not accept it compressed
+		tx := db.SkipDecompression(con).Table(table).
 			Preload("Data").
 			// Select("created_at", "data").
 			Scopes(db.FilterByDigest(dgst, table))
@@ -284,9 +307,23 @@ func genericGetData(table, mediaType string) http.Handler {
 		w.Header().Set(httputil.HeaderCreationDate, base.CreatedAt.Format(time.RFC3339Nano))
 		httputil.AllowCaching(w.Header())
 		w.Header().Set("Content-Type", mediaType)
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// send the data as it is stored (compressed) if the client accepts the encoding
+		var body []byte
+		if base.Data.Codec != storage.CodecNone && base.Data.StoredData != nil && acceptsEncoding(r, string(base.Data.Codec)) {
+			w.Header().Set("Content-Encoding", string(base.Data.Codec))
+			body = base.Data.StoredData
+		} else if r.Method == http.MethodGet {
+			if err := base.Data.Decompress(); err != nil {
+				return err
+			}
+			body = base.Data.RawData
+		}
+
 		switch r.Method {
 		case http.MethodGet:
-			_, err := w.Write(base.Data.RawData)
+			_, err := w.Write(body)
 			if err != nil {
 				return fmt.Errorf("getting data: %w", err)
 			}
@@ -299,6 +336,27 @@ func genericGetData(table, mediaType string) http.Handler {
 	})
 }
 
+// acceptsEncoding returns true if the Accept-Encoding header of the request allows the content-coding.
+func acceptsEncoding(r *http.Request, coding string) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, accepted := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(accepted, ";")
+			name = strings.TrimSpace(name)
+			if name != coding && name != "*" {
+				continue
+			}
+			// "q=0" means not acceptable
+			if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
 func parseDataPutParams(data []byte, r *http.Request) (*digest.Digest, error) {
 	ctx := r.Context()
 	log := logger.FromContext(ctx)
@@ -339,10 +397,10 @@ func parseDataPutParams(data []byte, r *http.Request) (*digest.Digest, error) {
 	return &serverDigest, nil
 }
 
-func genericPutData(processor db.Processor) http.Handler {
+func genericPutData(itemType string, processor db.Processor, hooks ingestHooks) http.Handler {
 	return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
 		ctx := r.Context()
-		// log := logger.FromContext(ctx)
+		log := logger.FromContext(ctx)
 		con := middleware.DatabaseFromContext(ctx)
 
 		data, err := io.ReadAll(r.Body)
@@ -350,6 +408,10 @@ func genericPutData(processor db.Processor) http.Handler {
 			return httputil.NewHTTPError(err, http.StatusBadRequest, "Unable to read the body")
 		}
 
+		if err := authorizePut(ctx, itemType, data); err != nil {
+			return err
+		}
+
 		// TODO support empty (Content-Length: 0) bodies.  Just pull the data with the "digest" from the params.
 		// We would need a HEAD (and GET for completeness) for data (not just blobs)
 		dgst, err := parseDataPutParams(data, r)
@@ -358,77 +420,145 @@ func genericPutData(processor db.Processor) http.Handler {
 		}
 		w.Header().Add(types.HeaderContentDigest, dgst.String())
 
-		// compute the CanonicalDigest if we do not already have it
-		canonicalDigest := *dgst
-		if dgst.Algorithm() != db.CanonicalDigestAlgorithm {
-			canonicalDigest = db.CanonicalDigestAlgorithm.FromBytes(data)
-		}
-
-		// Step 1: Make sure the Data record exists
-		// Step 2: Make sure the Digest record exists
-		// Step 3: Make sure the Object (bottle, event, ..) record exists
-
-		// Step 1
-		tx := con.Where(db.Data{
-			CanonicalDigest: canonicalDigest,
-		}).Attrs(db.Data{
-			RawData: data,
+		var existed bool
+		var pending *types.PendingObject
+		err = con.Transaction(func(tx *gorm.DB) error {
+			dataID, exists, err := putData(tx, processor, data, *dgst)
+			existed = exists
+			if err != nil {
+				pending, err = hooks.queue(tx, itemType, dataID, *dgst, err)
+				return err
+			}
+			if existed {
+				return nil
+			}
+			return hooks.ingested(tx, processor, dataID, *dgst)
 		})
-		dataRecord := db.Data{}
-		if err := tx.FirstOrCreate(&dataRecord).Error; err != nil {
+		if err != nil {
 			return err
 		}
+		log.InfoContext(ctx, "Ingest", "type", itemType, "digest", dgst, "existed", existed, "pending", pending != nil,
+			"signingKey", middleware.SigningKeyFromContext(ctx))
 
-		// Step 2
-		tx = con.Where(db.Digest{
-			DataID: dataRecord.ID, // This is slightly redundant.  If a record exists with the digest then the data better be the same or we found a collision.
-			Digest: *dgst,
-		})
-		digestRecord := db.Digest{}
-		if err := tx.FirstOrCreate(&digestRecord).Error; err != nil {
-			return err
+		if pending != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			return json.NewEncoder(w).Encode(pending)
+		}
+		if !existed {
+			hooks.committed()
 		}
 
-		// Step 3
-		tx = con.Table(processor.PrimaryTable()).
-			Where(db.Base{DataID: dataRecord.ID})
-		base := db.Base{}
-		if err := tx.First(&base).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				// a real error occurred
-				return err
-			}
-		} else {
-			// we found one, it already exists
+		if existed {
 			w.WriteHeader(http.StatusNoContent)
-
-			if base.ProcessorVersion == processor.Version() {
-				// short circuit
-				return nil
-			}
-			// else we "reprocess" the object to make its processor version up to date (fallthrough)
+			return nil
 		}
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	})
+}
 
-		base.ProcessorVersion = processor.Version()
-		base.Data = dataRecord
-		base.DataID = dataRecord.ID
+// authorizePut returns an error if the request is not allowed to upload the object.
+// Users may only upload their own events (the event's username must match the authenticated username).
+func authorizePut(ctx context.Context, itemType string, data []byte) error {
+	if err := middleware.AuthorizeWrite(ctx, itemType); err != nil {
+		return err
+	}
+	if itemType != "event" {
+		return nil
+	}
+	var event types.Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		// the event processor rejects invalid events
+		return nil
+	}
+	return middleware.AuthorizeUsername(ctx, event.Username)
+}
 
-		if err := processor.Process(con, base); err != nil {
-			return err
-		}
+// putData stores the data (identified by dgst) and processes it with the processor.
+// It returns the ID of the data and true if the object already existed.  Existing objects are reprocessed if they are out of date.
+func putData(con *gorm.DB, processor db.Processor, data []byte, dgst digest.Digest) (uint, bool, error) {
+	// compute the CanonicalDigest if we do not already have it
+	canonicalDigest := dgst
+	if dgst.Algorithm() != db.CanonicalDigestAlgorithm {
+		canonicalDigest = db.CanonicalDigestAlgorithm.FromBytes(data)
+	}
 
-		/*
-			username := r.Header.Get(middleware.HeaderUsername)
-			// FIXME
-			if evt := event.(*db.Event); username != evt.Username {
-				log.Info("username mismatch", "header", username, "event", evt.Username)
-				// return httputil.NewHTTPError(nil, htt.StatusUnauthorized, "Username in the event does not match the header")
-			}
-		*/
+	// objects removed by an administrator are not accepted again
+	removed, err := db.IsRemoved(con, dgst, canonicalDigest)
+	if err != nil {
+		return 0, false, err
+	}
+	if removed {
+		return 0, false, httputil.NewHTTPError(nil, http.StatusGone, "Removed by an administrator", "digest", dgst)
+	}
 
-		w.WriteHeader(http.StatusCreated)
-		return nil
+	// Step 1: Make sure the Data record exists
+	// Step 2: Make sure the Digest record exists
+	// Step 3: Make sure the Object (bottle, event, ..) record exists
+
+	// Step 1
+	// RawData is not a column (it is stored compressed or in the blob store) so it is set on the record instead of with Attrs()
+	tx := con.Where(db.Data{
+		CanonicalDigest: canonicalDigest,
+	})
+	dataRecord := db.Data{RawData: data}
+	if err := tx.FirstOrCreate(&dataRecord).Error; err != nil {
+		return 0, false, err
+	}
+
+	// Step 2
+	tx = con.Where(db.Digest{
+		DataID: dataRecord.ID, // This is slightly redundant.  If a record exists with the digest then the data better be the same or we found a collision.
+		Digest: dgst,
 	})
+	digestRecord := db.Digest{}
+	if err := tx.FirstOrCreate(&digestRecord).Error; err != nil {
+		return 0, false, err
+	}
+
+	// Step 3
+	existed := false
+	tx = con.Table(processor.PrimaryTable()).
+		Where(db.Base{DataID: dataRecord.ID})
+	base := db.Base{}
+	if err := tx.First(&base).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			// a real error occurred
+			return 0, false, err
+		}
+	} else {
+		// we found one, it already exists
+		existed = true
+
+		if base.ProcessorVersion == processor.Version() {
+			// short circuit
+			return dataRecord.ID, existed, nil
+		}
+		// else we "reprocess" the object to make its processor version up to date (fallthrough)
+	}
+
+	base.ProcessorVersion = processor.Version()
+	base.Data = dataRecord
+	base.DataID = dataRecord.ID
+
+	// a savepoint so an object that can not be processed (e.g., it is queued to wait for its dependencies) does not leave anything behind
+	if err := con.Transaction(func(tx *gorm.DB) error { return processor.Process(tx, base) }); err != nil {
+		// depending on a removed object is the same as being removed
+		var missing *types.MissingDigestsError
+		if !errors.As(err, &missing) {
+			return dataRecord.ID, existed, err
+		}
+		removed, rerr := db.IsRemoved(con, missing.MissingDigests...)
+		if rerr != nil {
+			return dataRecord.ID, existed, rerr
+		}
+		if removed {
+			return dataRecord.ID, existed, httputil.NewHTTPError(err, http.StatusGone, "Depends on an object removed by an administrator", "digest", dgst)
+		}
+		return dataRecord.ID, existed, err
+	}
+	return dataRecord.ID, existed, nil
 }
 
 type searchResultEntry struct {
@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/ecdsa"
@@ -19,12 +20,15 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
@@ -38,6 +42,7 @@ import (
 	"github.com/act3-ai/data-telemetry/v3/internal/db"
 	"github.com/act3-ai/data-telemetry/v3/internal/dbtest"
 	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/internal/storage"
 	ttest "github.com/act3-ai/data-telemetry/v3/internal/testing"
 	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
 	client "github.com/act3-ai/data-telemetry/v3/pkg/client"
@@ -47,6 +52,8 @@ import (
 type HandlersTestSuite struct {
 	suite.Suite
 	server  *httptest.Server
+	handler http.Handler
+	api     *api.API
 	dataDir string
 	log     *slog.Logger
 	ctx     context.Context
@@ -83,16 +90,29 @@ func (s *HandlersTestSuite) SetupTest() {
 		s.T().Cleanup(cleanup)
 	}
 	myDB, err := db.Open(s.ctx, v1alpha2.Database{
-		DSN: redact.SecretURL(u.String()),
+		DSN:         redact.SecretURL(u.String()),
+		Compression: "zstd",
 	}, scheme)
 	s.NoError(err)
 
+	// trust the test signing key when the signature was verified by gitlab
+	keyFP, err := ttest.FileDigest(filepath.Join(s.dataDir, "signature", "pub.pem"), "sha256")
+	s.NoError(err)
+	trustPolicy, err := db.NewTrustPolicy(v1alpha2.Trust{
+		Fingerprints: []string{keyFP.String()},
+		Identities: []v1alpha2.IdentityConstraint{
+			{Annotation: "verify-api", Pattern: "git*"},
+		},
+	})
+	s.NoError(err)
+
 	serveMux := http.NewServeMux()
-	wrappedServeMux := httputil.LoggingMiddleware(s.log)(middleware.DatabaseMiddleware(myDB)(serveMux))
+	wrappedServeMux := httputil.LoggingMiddleware(s.log)(middleware.DatabaseMiddleware(myDB)(middleware.TrustAnchorMiddleware(trustPolicy)(serveMux)))
 
-	a := &api.API{}
-	a.Initialize(serveMux, scheme)
+	s.api = &api.API{}
+	s.api.Initialize(serveMux, scheme)
 
+	s.handler = wrappedServeMux
 	s.server = httptest.NewServer(wrappedServeMux)
 }
 
@@ -228,6 +248,180 @@ func (s *HandlersTestSuite) testPutSignature() {
 	s.NotEmpty(hdrs.Get(types.HeaderContentDigest))
 }
 
+func (s *HandlersTestSuite) TestAPI_handleBulk() {
+	readRecord := func(objType, file string) types.BulkRecord {
+		data, err := os.ReadFile(filepath.Join(s.dataDir, objType, file))
+		s.NoError(err)
+		return types.BulkRecord{Type: objType, Data: data}
+	}
+
+	// dependents come before their dependencies
+	records := []types.BulkRecord{
+		readRecord("event", "push1.json"),
+		readRecord("event", "push2.json"), // manifest2.json is not included
+		readRecord("manifest", "manifest1.json"),
+		readRecord("bottle", "bottle1.json"),
+	}
+	for _, blob := range []string{"sample.txt", "tabular1.csv", "flame_temperature.ipynb", "parent.html", "child.html", "image1.jpg"} {
+		records = append(records, readRecord("blob", blob))
+	}
+	docRecord := readRecord("blob", "doc.md")
+	docRecord.Digest = digest.SHA512.FromBytes(docRecord.Data)
+	records = append(records,
+		docRecord,
+		readRecord("blob", "sample.txt"),
+		types.BulkRecord{Type: "blob", Digest: digest.FromString("other"), Data: []byte("data")},
+		types.BulkRecord{Type: "unknown", Data: []byte("data")},
+	)
+
+	body := &bytes.Buffer{}
+	enc := json.NewEncoder(body)
+	for _, record := range records {
+		s.NoError(enc.Encode(record))
+	}
+
+	req := s.makeRequest("POST", "/bulk", body)
+	req.Header.Set("Content-Type", types.MediaTypeNDJSON)
+	status, _, resBody := s.performRequest(req)
+	s.Equal(http.StatusOK, status)
+
+	results := struct {
+		Results []types.BulkRecordStatus
+	}{}
+	s.NoError(json.Unmarshal(resBody, &results))
+	statuses := results.Results
+	s.Len(statuses, len(records))
+	for i, status := range statuses {
+		s.Equal(i, status.Index)
+		s.Equal(records[i].Type, status.Type)
+	}
+
+	s.Equal(http.StatusCreated, statuses[0].StatusCode)
+	s.Equal(http.StatusPreconditionFailed, statuses[1].StatusCode)
+	s.Len(statuses[1].MissingDigests, 1)
+	s.Equal(http.StatusCreated, statuses[2].StatusCode)
+	s.Equal(http.StatusCreated, statuses[3].StatusCode)
+	s.Equal(http.StatusNoContent, statuses[len(records)-3].StatusCode, "duplicate record")
+	s.Equal(http.StatusBadRequest, statuses[len(records)-2].StatusCode, "digest mismatch")
+	s.Equal(http.StatusBadRequest, statuses[len(records)-1].StatusCode, "unknown type")
+
+	// the accepted records are retrievable
+	req = s.makeRequest("GET", "/event?digest="+statuses[0].Digest.String(), nil)
+	status, _, _ = s.performRequest(req)
+	s.Equal(http.StatusOK, status)
+
+	// an invalid stream is rejected outright
+	req = s.makeRequest("POST", "/bulk", bytes.NewReader([]byte("{not json")))
+	req.Header.Set("Content-Type", types.MediaTypeNDJSON)
+	status, _, _ = s.performRequest(req)
+	s.Equal(http.StatusBadRequest, status)
+}
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// readEvents sends the Server-Sent Events in the body to the returned channel.
+func readEvents(body io.Reader) <-chan sseEvent {
+	events := make(chan sseEvent)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(nil, 10*1024*1024)
+		var evt sseEvent
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				evt.ID = value
+			case "event":
+				evt.Event = value
+			case "data":
+				evt.Data = value
+			case "":
+				if evt.Event != "" {
+					events <- evt
+				}
+				evt = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func (s *HandlersTestSuite) openStream(query url.Values, lastEventID string) (<-chan sseEvent, func()) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.server.URL+"/stream?"+query.Encode(), nil)
+	s.NoError(err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := s.server.Client().Do(req) //nolint:bodyclose
+	s.NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal(types.MediaTypeEventStream, res.Header.Get("Content-Type"))
+	return readEvents(res.Body), func() {
+		cancel()
+		s.NoError(res.Body.Close())
+	}
+}
+
+func (s *HandlersTestSuite) nextEvent(events <-chan sseEvent) sseEvent {
+	select {
+	case evt, ok := <-events:
+		s.True(ok, "stream closed")
+		return evt
+	case <-time.After(10 * time.Second):
+		s.FailNow("timed out waiting for a stream event")
+		return sseEvent{}
+	}
+}
+
+func (s *HandlersTestSuite) TestAPI_handleStream() {
+	query := url.Values{"types": []string{"bottle,event"}}
+	events, closeStream := s.openStream(query, "")
+	defer closeStream()
+
+	// blobs are never selected and only matching bottles are sent
+	selected, closeSelected := s.openStream(url.Values{
+		"types":    []string{"blob", "bottle"},
+		"selector": []string{"type=testing,myotherkey=myothervalue2"},
+	}, "")
+	defer closeSelected()
+
+	uploadURL, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, uploadURL, s.token, false))
+
+	// bottles are ingested before events
+	first := s.nextEvent(events)
+	s.Equal("bottle", first.Event)
+	var entry types.ListResultEntry
+	s.NoError(json.Unmarshal([]byte(first.Data), &entry))
+	s.NotEmpty(entry.Digests)
+	s.NotEmpty(entry.Data)
+	second := s.nextEvent(events)
+
+	// resuming replays what comes after the last event ID
+	resumed, closeResumed := s.openStream(query, first.ID)
+	defer closeResumed()
+	evt := s.nextEvent(resumed)
+	s.Equal(second.ID, evt.ID)
+	s.Equal(second.Event, evt.Event)
+
+	evt = s.nextEvent(selected)
+	s.Equal("bottle", evt.Event)
+	s.NoError(json.Unmarshal([]byte(evt.Data), &entry))
+	s.Contains(string(entry.Data), "myothervalue2")
+
+	// invalid requests are rejected before streaming
+	req := s.makeRequest("GET", "/stream?types=widget", nil)
+	status, _, _ := s.performRequest(req)
+	s.Equal(http.StatusBadRequest, status)
+}
+
 func (s *HandlersTestSuite) TestAPI_handleGetBottlesFromMetric() {
 	uploadURL, err := url.Parse(s.server.URL)
 	s.NoError(err)
@@ -275,6 +469,49 @@ func (s *HandlersTestSuite) TestAPI_handleGetLocation() {
 	s.Equal(http.StatusOK, status)
 	s.Contains(string(body), "reg2.example.com/bar/somewhere/else")
 	s.Contains(string(body), "reg.example.com/foo")
+
+	get := func(query url.Values) []types.LocationResponse {
+		query.Set("bottle_digest", bottleDigest.String())
+		status, _, body := s.performRequest(s.makeRequest("GET", "/location?"+query.Encode(), nil))
+		s.Require().Equal(http.StatusOK, status)
+		results := struct {
+			Results []types.LocationResponse
+		}{}
+		s.Require().NoError(json.Unmarshal(body, &results))
+		return results.Results
+	}
+
+	// the push is the most recent
+	locations := get(url.Values{})
+	s.Require().Len(locations, 3)
+	s.Equal("reg.example.com/foo", locations[0].Repository)
+	s.Equal(time.Date(2021, time.April, 23, 18, 25, 43, 0, time.UTC), locations[0].LastSeen.UTC())
+	s.Equal([]string{"v1.0.1"}, locations[0].Tags)
+	s.Require().Len(locations[0].Layers, 2)
+	s.Equal(digest.Digest("sha256:625b0528ec90bd34498563b8380db33f2f374256181a62a23a6cdcaf41b19304"), locations[0].Layers[0].Digest)
+	s.Equal("application/vnd.act3-ace.bottle.layer.v1.tar+gzip", locations[0].Layers[0].MediaType)
+	s.Equal(int64(32654), locations[0].Layers[0].Size)
+	s.Equal("reg2.example.com/bar/somewhere/else", locations[1].Repository)
+	s.True(locations[1].AuthRequired)
+	s.Equal("reg45.example.com/foo", locations[2].Repository)
+	s.Equal(digest.Digest("sha256:0159c5a580bc5d8efc4b70324b9a50e3c9fa4fcd37785e5ec4040fe22797030a"), locations[2].Digest)
+
+	locations = get(url.Values{"public": {"true"}})
+	s.Len(locations, 2)
+	for _, l := range locations {
+		s.False(l.AuthRequired)
+	}
+
+	locations = get(url.Values{"registry": {"reg45.example.com"}})
+	s.Require().Len(locations, 1)
+	s.Equal("reg45.example.com/foo", locations[0].Repository)
+
+	s.Empty(get(url.Values{"registry": {"reg2.example.com"}, "public": {"true"}}))
+
+	for _, query := range []string{"", "bottle_digest=sha256:abc", "bottle_digest=" + bottleDigest.String() + "&registry=reg.example.com/foo"} {
+		status, _, _ := s.performRequest(s.makeRequest("GET", "/location?"+query, nil))
+		s.Equal(http.StatusBadRequest, status, query)
+	}
 }
 
 func (s *HandlersTestSuite) TestAPI_handleBottleSearch() {
@@ -298,6 +535,162 @@ func (s *HandlersTestSuite) TestAPI_handleBottleSearch() {
 	s.Contains(string(body), "sha512")
 }
 
+func (s *HandlersTestSuite) TestAPI_handleCatalogSearch() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	search := func(query types.SearchQuery) *types.SearchResponse {
+		response, err := client.Search(s.ctx, s.server.Client(), u, query)
+		s.Require().NoError(err)
+		return response
+	}
+
+	response := search(types.SearchQuery{Author: "jane.smith@example.com", SortByMetric: "accuracy"})
+	s.Equal(int64(2), response.Total)
+	s.Require().Len(response.Results, 2)
+	s.Contains(string(response.Results[0].Bottle), "jane.smith@example.com")
+	s.NotEmpty(response.Results[0].Digests)
+
+	all := search(types.SearchQuery{LabelSelectors: []string{"type=testing"}, ShowDeprecated: true})
+	s.Equal(int64(7), all.Total)
+	s.False(all.More)
+
+	// pages
+	page := search(types.SearchQuery{LabelSelectors: []string{"type=testing"}, ShowDeprecated: true, Limit: 3, Page: 1})
+	s.Equal(all.Total, page.Total)
+	s.True(page.More)
+	s.Require().Len(page.Results, 3)
+	s.Equal(all.Results[3].Digests, page.Results[0].Digests)
+
+	// past the last page
+	page = search(types.SearchQuery{LabelSelectors: []string{"type=testing"}, ShowDeprecated: true, Limit: 3, Page: 5})
+	s.Equal(all.Total, page.Total)
+	s.False(page.More)
+	s.Empty(page.Results)
+
+	s.Equal(100, search(types.SearchQuery{Limit: 1000}).Limit)
+
+	s.Empty(search(types.SearchQuery{CreatedBefore: time.Now().Add(-time.Hour)}).Results)
+	s.Len(search(types.SearchQuery{Metrics: []string{"accuracy>0"}, Repository: "nothing"}).Results, 0)
+
+	// the query parameters of the catalog are accepted as is
+	status, _, body := s.performRequest(s.makeRequest("GET", "/catalog?label-selector=refname%3Dbottle1&created-before="+
+		strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10), nil))
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), "MNIST")
+
+	_, err = client.Search(s.ctx, s.server.Client(), u, types.SearchQuery{ParentsOf: "sha256:bad"})
+	s.Error(err)
+}
+
+func (s *HandlersTestSuite) TestAPI_handleGetLineage() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	bottle1 := "sha256:1c62b7c436992270b5ac7fc683debef0bbf461fe296d85d823ffc18f68dae33d"
+	bottle2 := digest.Digest("sha256:eb7e9899b608573061edbf85340300ff5548734a0028ba607be98367f221647e")
+	bottle3 := "sha256:a4223ee334dfea67d0509cbc8f89758b352e314867957e1c76b55026a063e29b"
+	unknown := "sha256:42a8efd3483c60a4364d3f6f328ee1897facdbffb043b51941424a34121bbbe9"
+
+	lineage, err := client.GetLineage(s.ctx, s.server.Client(), u, bottle2, 2, 1)
+	s.Require().NoError(err)
+	s.Equal(bottle2, lineage.Digest)
+
+	nodes := make(map[string]types.LineageNode, len(lineage.Nodes))
+	for _, n := range lineage.Nodes {
+		nodes[n.ID] = n
+	}
+	s.Len(nodes, 6)
+	s.Equal(types.LineageNodeBottle, nodes[bottle2.String()].Kind)
+	s.Equal(0, nodes[bottle2.String()].Generation)
+	s.Equal(types.LineageNodeBottle, nodes[bottle1].Kind)
+	s.Equal(-1, nodes[bottle1].Generation)
+	s.Equal(types.LineageNodeURI, nodes["http://data.example.com/for-bottle-2"].Kind)
+	s.Equal(types.LineageNodeURI, nodes["http://data.example.com"].Kind)
+	s.Equal(-2, nodes["http://data.example.com"].Generation)
+	s.Equal(types.LineageNodeUnknownBottle, nodes[unknown].Kind)
+	s.Equal(-2, nodes[unknown].Generation)
+	s.Equal(types.LineageNodeBottle, nodes[bottle3].Kind)
+	s.Equal(1, nodes[bottle3].Generation)
+
+	// bottle3 refers to bottle2 by its sha512 digest and is also a child of bottle1
+	s.Contains(lineage.Edges, types.LineageEdge{From: bottle2.String(), To: bottle3, Name: "Training set"})
+	s.Contains(lineage.Edges, types.LineageEdge{From: bottle1, To: bottle3, Name: "Test set"})
+	s.Contains(lineage.Edges, types.LineageEdge{
+		From: bottle1, To: bottle2.String(), Name: "Training dataset",
+		PartSelectors: []string{"label2=otherlabel", "also=notthere,other=doesnotexist"},
+	})
+	s.Len(lineage.Edges, 6)
+
+	// only the bottle
+	lineage, err = client.GetLineage(s.ctx, s.server.Client(), u, bottle2, 0, 0)
+	s.Require().NoError(err)
+	s.Len(lineage.Nodes, 1)
+	s.Empty(lineage.Edges)
+
+	_, err = client.GetLineage(s.ctx, s.server.Client(), u, digest.FromString("unknown"), 1, 1)
+	s.ErrorContains(err, "404")
+
+	status, _, _ := s.performRequest(s.makeRequest("GET", "/lineage?digest="+bottle2.String()+"&ancestors=100", nil))
+	s.Equal(http.StatusBadRequest, status)
+
+	// export formats
+	status, headers, body := s.performRequest(s.makeRequest("GET", "/lineage?digest="+bottle2.String()+"&format=dot", nil))
+	s.Equal(http.StatusOK, status)
+	s.Equal("text/vnd.graphviz", headers.Get("Content-Type"))
+	s.Contains(string(body), `"`+bottle1+`" -> "`+bottle2.String()+`"`)
+
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/lineage?digest="+bottle2.String()+"&format=svg", nil))
+	s.Equal(http.StatusBadRequest, status)
+}
+
+func (s *HandlersTestSuite) TestAPI_handleGetDiff() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	// bottle1 deprecates bottle00
+	bottle00 := "sha256:2e9e86ac5509a9870d4109c1d0d26d160cc7ce21d8350ac74d37371894d300f6"
+	bottle1 := "sha256:1c62b7c436992270b5ac7fc683debef0bbf461fe296d85d823ffc18f68dae33d"
+
+	status, _, body := s.performRequest(s.makeRequest("GET", "/diff?from="+bottle00+"&to="+bottle1, nil))
+	s.Require().Equal(http.StatusOK, status)
+
+	diff := types.BottleDiff{}
+	s.Require().NoError(json.Unmarshal(body, &diff))
+	s.Equal(digest.Digest(bottle00), diff.From)
+	s.Equal(digest.Digest(bottle1), diff.To)
+	s.True(diff.Deprecates)
+	s.False(diff.Empty())
+	changes := make(map[string]types.DiffChange, len(diff.Parts))
+	for _, p := range diff.Parts {
+		changes[p.Name] = p.Change
+	}
+	s.Equal(types.DiffRemoved, changes["someusage"])
+
+	// the reverse direction is not a deprecation
+	status, _, body = s.performRequest(s.makeRequest("GET", "/diff?from="+bottle1+"&to="+bottle00, nil))
+	s.Require().Equal(http.StatusOK, status)
+	diff = types.BottleDiff{}
+	s.Require().NoError(json.Unmarshal(body, &diff))
+	s.False(diff.Deprecates)
+
+	// a bottle does not differ from itself
+	status, _, body = s.performRequest(s.makeRequest("GET", "/diff?from="+bottle1+"&to="+bottle1, nil))
+	s.Require().Equal(http.StatusOK, status)
+	diff = types.BottleDiff{}
+	s.Require().NoError(json.Unmarshal(body, &diff))
+	s.True(diff.Empty())
+
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/diff?from=bogus&to="+bottle1, nil))
+	s.Equal(http.StatusBadRequest, status)
+
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/diff?from="+bottle1+"&to="+digest.FromString("unknown").String(), nil))
+	s.Equal(http.StatusNotFound, status)
+}
+
 func (s *HandlersTestSuite) TestAPI_handleContentSearch() {
 	uploadURL, err := url.Parse(s.server.URL)
 	s.NoError(err)
@@ -357,6 +750,41 @@ func (s *HandlersTestSuite) TestAPI_handleGetData() {
 	}
 }
 
+func (s *HandlersTestSuite) TestAPI_handleGetDataEncoding() {
+	uploadURL, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, uploadURL, s.token, false))
+
+	expected, err := os.ReadFile(filepath.Join(s.dataDir, "bottle", "bottle1.json"))
+	s.NoError(err)
+	u := url.URL{
+		Path: "/bottle",
+		RawQuery: url.Values{
+			"digest": []string{digest.FromBytes(expected).String()},
+		}.Encode(),
+	}
+
+	// the data is sent as stored (compressed) when the client accepts it
+	req := s.makeRequest("GET", u.String(), nil)
+	req.Header.Set("Accept-Encoding", "gzip, zstd")
+	status, header, body := s.performRequest(req)
+	s.Equal(http.StatusOK, status)
+	s.Equal("zstd", header.Get("Content-Encoding"))
+	decoded, err := storage.CodecZstd.Decode(body)
+	s.NoError(err)
+	s.Equal(expected, decoded)
+
+	// otherwise it is decompressed
+	for _, acceptEncoding := range []string{"gzip", "zstd;q=0"} {
+		req = s.makeRequest("GET", u.String(), nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		status, header, body = s.performRequest(req)
+		s.Equal(http.StatusOK, status)
+		s.Empty(header.Get("Content-Encoding"))
+		s.Equal(expected, body)
+	}
+}
+
 func (s *HandlersTestSuite) TestAPI_handleListData() {
 	uploadURL, err := url.Parse(s.server.URL)
 	s.NoError(err)
@@ -380,6 +808,143 @@ func (s *HandlersTestSuite) TestAPI_handleListData() {
 	s.NotContains(string(body), "null")
 }
 
+func (s *HandlersTestSuite) TestAPI_handleRemoveBottle() {
+	uploadURL, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, uploadURL, s.token, false))
+
+	bottleDigest, err := ttest.FileDigest(filepath.Join(s.dataDir, "bottle", "bottle1.json"), "sha256")
+	s.NoError(err)
+	manifestDigest, err := ttest.FileDigest(filepath.Join(s.dataDir, "manifest", "manifest1.json"), "sha256")
+	s.NoError(err)
+
+	remove := func(reason, authorization string) (int, []byte) {
+		query := url.Values{"digest": []string{bottleDigest.String()}}
+		if reason != "" {
+			query.Set("reason", reason)
+		}
+		req := s.makeRequest("DELETE", "/bottle?"+query.Encode(), nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		status, _, body := s.performRequest(req)
+		return status, body
+	}
+
+	// nobody may remove bottles without authentication
+	status, _ := remove("author email leaked", "")
+	s.Equal(http.StatusForbidden, status)
+
+	issuer, err := ttest.NewIssuer()
+	s.Require().NoError(err)
+	defer issuer.Close()
+	s.server = httptest.NewServer(middleware.AuthMiddleware(middleware.NewAuthenticator(v1alpha2.Auth{
+		Issuer: issuer.URL,
+		Admin:  v1alpha2.AccessRule{Users: []string{"root"}},
+	}))(s.handler))
+	defer s.server.Close()
+
+	token, err := issuer.Token(nil)
+	s.Require().NoError(err)
+	status, _ = remove("author email leaked", "Bearer "+token)
+	s.Equal(http.StatusForbidden, status)
+
+	token, err = issuer.Token(map[string]any{"sub": "root"})
+	s.Require().NoError(err)
+	admin := "Bearer " + token
+
+	status, _ = remove("", admin)
+	s.Equal(http.StatusBadRequest, status)
+
+	status, body := remove("author email leaked", admin)
+	s.Equal(http.StatusOK, status)
+	tombstone := types.Tombstone{}
+	s.NoError(json.Unmarshal(body, &tombstone))
+	s.Equal(bottleDigest, tombstone.BottleDigest)
+	s.Contains(tombstone.Digests, manifestDigest)
+	s.Equal("author email leaked", tombstone.Reason)
+	s.Equal("root", tombstone.Username)
+
+	status, _ = remove("again", admin)
+	s.Equal(http.StatusNotFound, status)
+
+	// the bottle and the objects that reference it are gone
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/bottle?digest="+bottleDigest.String(), nil))
+	s.Equal(http.StatusNotFound, status)
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/manifest?digest="+manifestDigest.String(), nil))
+	s.Equal(http.StatusNotFound, status)
+
+	// and they are not accepted again
+	f, err := os.Open(filepath.Join(s.dataDir, "bottle", "bottle1.json"))
+	s.NoError(err)
+	req := s.makeRequest("PUT", "/bottle", f)
+	req.Header.Set("Content-Type", mediatype.MediaTypeBottleConfig)
+	req.Header.Set("Authorization", admin)
+	status, _, _ = s.performRequest(req)
+	s.Equal(http.StatusGone, status)
+
+	f, err = os.Open(filepath.Join(s.dataDir, "event", "push1.json"))
+	s.NoError(err)
+	req = s.makeRequest("PUT", "/event", f)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", admin)
+	status, _, _ = s.performRequest(req)
+	s.Equal(http.StatusGone, status)
+
+	// who removed the bottle and why are not listed
+	status, _, body = s.performRequest(s.makeRequest("GET", "/tombstone", nil))
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), bottleDigest.String())
+	s.NotContains(string(body), "leaked")
+}
+
+func (s *HandlersTestSuite) TestAPI_handlePending() {
+	s.api.Pending = v1alpha2.PendingQueue{Enabled: true, MaxAge: metav1.Duration{Duration: time.Hour}}
+
+	uploadURL, err := url.Parse(s.server.URL)
+	s.NoError(err)
+
+	manifestDigest, err := ttest.FileDigest(filepath.Join(s.dataDir, "manifest", "manifest1.json"), "sha256")
+	s.NoError(err)
+
+	// an event before its manifest is queued
+	f, err := os.Open(filepath.Join(s.dataDir, "event", "push1.json"))
+	s.NoError(err)
+	req := s.makeRequest("PUT", "/event", f)
+	req.Header.Set("Content-Type", "application/json")
+	status, _, body := s.performRequest(req)
+	s.Equal(http.StatusAccepted, status)
+	pending := types.PendingObject{}
+	s.NoError(json.Unmarshal(body, &pending))
+	s.Equal("event", pending.Type)
+	s.Equal([]digest.Digest{manifestDigest}, pending.MissingDigests)
+
+	// upload everything else in reverse order
+	for _, objType := range []string{"event", "manifest", "bottle"} {
+		s.NoError(client.Upload(s.ctx, s.server.Client(), filepath.Join(s.dataDir, objType, "index.csv"), uploadURL, s.token, false))
+	}
+	listPending := func() []types.PendingObject {
+		status, _, body := s.performRequest(s.makeRequest("GET", "/pending", nil))
+		s.Equal(http.StatusOK, status)
+		results := struct{ Results []types.PendingObject }{}
+		s.NoError(json.Unmarshal(body, &results))
+		return results.Results
+	}
+	s.NotEmpty(listPending())
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/manifest?digest="+manifestDigest.String(), nil))
+	s.Equal(http.StatusNotFound, status)
+
+	// the blobs resolve everything
+	s.NoError(client.Upload(s.ctx, s.server.Client(), filepath.Join(s.dataDir, "blob", "index.csv"), uploadURL, s.token, false))
+	s.Empty(listPending())
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/manifest?digest="+manifestDigest.String(), nil))
+	s.Equal(http.StatusOK, status)
+	eventDigest, err := ttest.FileDigest(filepath.Join(s.dataDir, "event", "push1.json"), "sha256")
+	s.NoError(err)
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/event?digest="+eventDigest.String(), nil))
+	s.Equal(http.StatusOK, status)
+}
+
 func (s *HandlersTestSuite) TestAPI_handleGetSignatures() {
 	uploadURL, err := url.Parse(s.server.URL)
 	s.NoError(err)
@@ -403,6 +968,7 @@ func (s *HandlersTestSuite) TestAPI_handleGetSignatures() {
 
 	s.Equal(http.StatusOK, status)
 	s.Contains(string(body), manifestDigest.String())
+	s.Contains(string(body), `"sigTrusted":true`)
 	s.NotContains(string(body), "null")
 }
 
@@ -435,6 +1001,44 @@ func (s *HandlersTestSuite) TestAPI_handleGetSigValid() {
 	s.NotContains(string(body), "null")
 }
 
+func (s *HandlersTestSuite) TestAPI_handleGetSigValidTrusted() {
+	uploadURL, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, uploadURL, s.token, false))
+
+	bottleDigest, err := ttest.FileDigest(filepath.Join(s.dataDir, "bottle", "bottle1.json"), "sha256")
+	s.NoError(err)
+
+	keyFP, err := ttest.FileDigest(filepath.Join(s.dataDir, "signature", "pub.pem"), "sha256")
+	s.NoError(err)
+
+	u := url.URL{
+		Path: "/signature/validate",
+		RawQuery: url.Values{
+			"bottle_digest":   []string{bottleDigest.String()},
+			"key_fingerprint": []string{keyFP.String()},
+			"trust_level":     []string{"trusted"},
+		}.Encode(),
+	}
+	req := s.makeRequest("GET", u.String(), nil)
+
+	status, _, body := s.performRequest(req)
+
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), keyFP.String())
+	s.Contains(string(body), `"trusted":true`)
+
+	u.RawQuery = url.Values{
+		"bottle_digest":   []string{bottleDigest.String()},
+		"key_fingerprint": []string{keyFP.String()},
+		"trust_level":     []string{"bogus"},
+	}.Encode()
+	req = s.makeRequest("GET", u.String(), nil)
+
+	status, _, _ = s.performRequest(req)
+	s.Equal(http.StatusBadRequest, status)
+}
+
 // func (s *HandlersTestSuite) TestAPI_handleGetSigIdent() {
 // 	uploadURL, err := url.Parse(s.server.URL)
 // 	s.NoError(err)
@@ -462,6 +1066,239 @@ func (s *HandlersTestSuite) TestAPI_handleGetSigValid() {
 // 	s.NotContains(string(body), "null")
 // }
 
+func (s *HandlersTestSuite) TestAPI_handleEventAnalytics() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	get := func(query url.Values) types.EventAnalytics {
+		status, _, body := s.performRequest(s.makeRequest("GET", "/analytics/events?"+query.Encode(), nil))
+		s.Require().Equal(http.StatusOK, status)
+		analytics := types.EventAnalytics{}
+		s.Require().NoError(json.Unmarshal(body, &analytics))
+		return analytics
+	}
+
+	day := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	// all events by day
+	analytics := get(url.Values{})
+	s.Equal(types.IntervalDay, analytics.Interval)
+	s.Equal([]types.EventSeries{{Total: 5, Buckets: []types.EventBucket{
+		{Start: day(2012, time.April, 23, 0), Count: 3},
+		{Start: day(2020, time.April, 23, 0), Count: 1},
+		{Start: day(2021, time.April, 23, 0), Count: 1},
+	}}}, analytics.Series)
+
+	// by action and month
+	analytics = get(url.Values{"group-by": {"action"}, "interval": {"month"}})
+	s.Equal([]types.EventSeries{
+		{Group: "pull", Total: 3, Buckets: []types.EventBucket{{Start: day(2012, time.April, 1, 0), Count: 3}}},
+		{Group: "push", Total: 2, Buckets: []types.EventBucket{
+			{Start: day(2020, time.April, 1, 0), Count: 1},
+			{Start: day(2021, time.April, 1, 0), Count: 1},
+		}},
+	}, analytics.Series)
+
+	// weeks start on Monday
+	analytics = get(url.Values{"group-by": {"username"}, "interval": {"week"}, "action": {"push"}})
+	s.Equal([]types.EventSeries{
+		{Group: "joe.shmo@example.com", Total: 1, Buckets: []types.EventBucket{{Start: day(2021, time.April, 19, 0), Count: 1}}},
+		{Group: "john.smith@example.com", Total: 1, Buckets: []types.EventBucket{{Start: day(2020, time.April, 20, 0), Count: 1}}},
+	}, analytics.Series)
+
+	// time range and label selectors
+	analytics = get(url.Values{"interval": {"hour"}, "start": {"2015-01-01T00:00:00Z"}, "end": {"2021-01-01T00:00:00Z"}})
+	s.Equal([]types.EventSeries{{Total: 1, Buckets: []types.EventBucket{{Start: day(2020, time.April, 23, 18), Count: 1}}}}, analytics.Series)
+
+	analytics = get(url.Values{"label-selector": {"refname=bottle1"}})
+	s.Require().Len(analytics.Series, 1)
+	s.Equal(int64(4), analytics.Series[0].Total)
+
+	analytics = get(url.Values{"label-selector": {"refname=nothing"}})
+	s.Empty(analytics.Series)
+
+	for _, query := range []url.Values{
+		{"interval": {"year"}},
+		{"group-by": {"tag"}},
+		{"label-selector": {"foo in (a, b"}},
+		{"start": {"2021-01-01T00:00:00Z"}, "end": {"2015-01-01T00:00:00Z"}},
+		{"start": {"yesterday"}},
+	} {
+		status, _, _ := s.performRequest(s.makeRequest("GET", "/analytics/events?"+query.Encode(), nil))
+		s.Equal(http.StatusBadRequest, status, query)
+	}
+}
+
+func (s *HandlersTestSuite) TestAPI_handleTransferStats() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	get := func(query url.Values) types.TransferStats {
+		status, _, body := s.performRequest(s.makeRequest("GET", "/analytics/transfers?"+query.Encode(), nil))
+		s.Require().Equal(http.StatusOK, status)
+		stats := types.TransferStats{}
+		s.Require().NoError(json.Unmarshal(body, &stats))
+		return stats
+	}
+
+	// bottle1 (the bottle of four events) and bottle2 are each 495 bytes
+	stats := get(url.Values{})
+	s.Equal(types.IntervalDay, stats.Interval)
+	s.Equal([]types.TransferTotal{
+		{Name: "sha256:1c62b7c436992270b5ac7fc683debef0bbf461fe296d85d823ffc18f68dae33d", Events: 4, Bytes: 1980},
+		{Name: "sha512:3a5a8236af293032053f36e15d612ccbfc9a92d5c6fc2a5f198727a600af47ba2042437d551de372d595b6c7a3367c8a4ed15f1d6aad637d26e35a33a45c9dea", Events: 1, Bytes: 495},
+	}, stats.Bottles)
+	s.Equal(types.TransferTotal{Name: "reg.example.com/foo", Events: 2, Bytes: 990}, stats.Repositories[0])
+	s.Len(stats.Repositories, 4)
+	s.Equal([]types.TransferTotal{
+		{Name: "joe.shmo@example.com", Events: 4, Bytes: 1980},
+		{Name: "john.smith@example.com", Events: 1, Bytes: 495},
+	}, stats.Users)
+
+	// events without a bandwidth are not part of the throughput
+	s.Len(stats.Throughput, 3)
+	s.Equal(types.ThroughputSeries{Repository: "reg.example.com/foo", Buckets: []types.ThroughputBucket{
+		{Start: time.Date(2012, time.April, 23, 0, 0, 0, 0, time.UTC), Events: 1, Bandwidth: 10000000},
+		{Start: time.Date(2021, time.April, 23, 0, 0, 0, 0, time.UTC), Events: 1, Bandwidth: 101010101099},
+	}}, stats.Throughput[0])
+
+	// filtered and limited
+	stats = get(url.Values{"action": {"pull"}, "limit": {"1"}, "interval": {"month"}})
+	s.Len(stats.Bottles, 1)
+	s.Equal(int64(3), stats.Bottles[0].Events)
+	s.Len(stats.Repositories, 1)
+	s.Len(stats.Users, 1)
+	for _, series := range stats.Throughput {
+		s.Equal(time.Date(2012, time.April, 1, 0, 0, 0, 0, time.UTC), series.Buckets[0].Start)
+	}
+
+	stats = get(url.Values{"start": {"2030-01-01T00:00:00Z"}})
+	s.Empty(stats.Bottles)
+	s.Empty(stats.Throughput)
+
+	for _, query := range []string{"interval=year", "limit=-1", "label-selector=foo+bar"} {
+		status, _, _ := s.performRequest(s.makeRequest("GET", "/analytics/transfers?"+query, nil))
+		s.Equal(http.StatusBadRequest, status, query)
+	}
+}
+
+func (s *HandlersTestSuite) TestAPI_handleGetTags() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	status, _, body := s.performRequest(s.makeRequest("GET", "/tags?repository=reg.example.com/foo", nil))
+	s.Require().Equal(http.StatusOK, status)
+	tags := types.RepositoryTags{}
+	s.Require().NoError(json.Unmarshal(body, &tags))
+	s.Equal("reg.example.com/foo", tags.Repository)
+	s.Require().Len(tags.Tags, 1)
+	s.Equal("v1.0.1", tags.Tags[0].Tag)
+	s.Require().Len(tags.Tags[0].Pushes, 1)
+	push := tags.Tags[0].Pushes[0]
+	s.Equal(digest.Digest("sha256:74968ed318f252397002f7cc02c563554156cc1f0eeec91d643fc12de61314c9"), push.ManifestDigest)
+	s.Equal(digest.Digest("sha256:1c62b7c436992270b5ac7fc683debef0bbf461fe296d85d823ffc18f68dae33d"), push.BottleDigest)
+	s.Equal(time.Date(2021, time.April, 23, 18, 25, 43, 0, time.UTC), push.Timestamp.UTC())
+
+	// pulls do not move tags
+	status, _, body = s.performRequest(s.makeRequest("GET", "/tags?repository=reg2.example.com/bar/somewhere/else", nil))
+	s.Require().Equal(http.StatusOK, status)
+	tags = types.RepositoryTags{}
+	s.Require().NoError(json.Unmarshal(body, &tags))
+	s.Empty(tags.Tags)
+
+	status, _, body = s.performRequest(s.makeRequest("GET", "/tags?repository=reg.example.com/foo&tag=v2", nil))
+	s.Require().Equal(http.StatusOK, status)
+	tags = types.RepositoryTags{}
+	s.Require().NoError(json.Unmarshal(body, &tags))
+	s.Empty(tags.Tags)
+
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/tags", nil))
+	s.Equal(http.StatusBadRequest, status)
+}
+
+func (s *HandlersTestSuite) TestAPI_handleResolve() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	status, _, body := s.performRequest(s.makeRequest("GET", "/resolve?ref=reg.example.com/other:v2.0.1", nil))
+	s.Require().Equal(http.StatusOK, status)
+	push := types.TagPush{}
+	s.Require().NoError(json.Unmarshal(body, &push))
+	s.Equal("reg.example.com/other", push.Repository)
+	s.Equal("v2.0.1", push.Tag)
+	s.Equal(digest.Digest("sha256:fb1098afb06bdffc12bd48cf5febf1bd9ebff257a9ae3af3da4044fc3fcb79ad"), push.ManifestDigest)
+	s.Equal("john.smith@example.com", push.Username)
+
+	// resolved at a time after the push
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/resolve?ref=reg.example.com/other:v2.0.1&at=2020-05-01T00:00:00Z", nil))
+	s.Equal(http.StatusOK, status)
+
+	for _, query := range []string{
+		"ref=reg.example.com/other:v2.0.1&at=2020-01-01T00:00:00Z", // before the push
+		"ref=reg.example.com/other",                                // latest
+		"ref=reg.example.com/unknown:v1",
+	} {
+		status, _, _ := s.performRequest(s.makeRequest("GET", "/resolve?"+query, nil))
+		s.Equal(http.StatusNotFound, status, query)
+	}
+
+	for _, query := range []string{
+		"",
+		"ref=other",
+		"ref=reg.example.com/other@sha256:fb1098afb06bdffc12bd48cf5febf1bd9ebff257a9ae3af3da4044fc3fcb79ad",
+		"ref=reg.example.com/other:v2.0.1&at=yesterday",
+	} {
+		status, _, _ := s.performRequest(s.makeRequest("GET", "/resolve?"+query, nil))
+		s.Equal(http.StatusBadRequest, status, query)
+	}
+}
+
+func (s *HandlersTestSuite) TestAPI_handleStorageStats() {
+	u, err := url.Parse(s.server.URL)
+	s.NoError(err)
+	s.NoError(client.UploadAll(s.ctx, s.server.Client(), s.dataDir, u, s.token, false))
+
+	get := func(query url.Values) types.StorageStats {
+		status, _, body := s.performRequest(s.makeRequest("GET", "/analytics/storage?"+query.Encode(), nil))
+		s.Require().Equal(http.StatusOK, status)
+		stats := types.StorageStats{}
+		s.Require().NoError(json.Unmarshal(body, &stats))
+		return stats
+	}
+
+	// three manifests (two of bottle1) of two layers each, manifest1 and manifest2 share their layers
+	stats := get(url.Values{})
+	s.Equal(int64(2), stats.Bottles)
+	s.Equal(int64(3), stats.Manifests)
+	s.Equal(int64(6), stats.Layers)
+	s.Equal(int64(3*495), stats.UncompressedBytes)
+	s.Equal(int64(3*(32654+16724)), stats.CompressedBytes)
+	s.Equal(int64(2*(32654+16724)), stats.DeduplicatedBytes)
+	s.Equal(int64(6), stats.ArchivedLayers)
+	s.Equal([]types.MediaTypeStorage{{
+		MediaType:         "application/vnd.act3-ace.bottle.layer.v1.tar+gzip",
+		Archived:          true,
+		Compressed:        true,
+		Layers:            6,
+		UncompressedBytes: 3 * 495,
+		CompressedBytes:   3 * (32654 + 16724),
+	}}, stats.MediaTypes)
+
+	stats = get(url.Values{"label-selector": {"nonexistent=true"}})
+	s.Zero(stats.Layers)
+	s.Zero(stats.DeduplicatedBytes)
+	s.Empty(stats.MediaTypes)
+
+	status, _, _ := s.performRequest(s.makeRequest("GET", "/analytics/storage?label-selector=foo+bar", nil))
+	s.Equal(http.StatusBadRequest, status)
+}
+
 func TestHandlersTestSuite(t *testing.T) {
 	suite.Run(t, new(HandlersTestSuite))
 }
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/schema"
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/lineage"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+)
+
+// maxLineageGenerations limits the number of generations of ancestors (and of descendants) in a lineage.
+const maxLineageGenerations = 10
+
+// handleGetLineage responds with the lineage graph (see types.Lineage) of a bottle.
+// The parameters are:
+//   - "digest" -> the digest of the bottle.
+//   - "ancestors" -> the number of generations of ancestors (default 1).
+//   - "descendants" -> the number of generations of descendants (default 1).
+//   - "format" -> the format of the response (see lineage.Formats, default "json").
+func handleGetLineage(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	params := struct {
+		Digest      digest.Digest `schema:"digest"`
+		Ancestors   uint          `schema:"ancestors"`
+		Descendants uint          `schema:"descendants"`
+		Format      string        `schema:"format"`
+	}{
+		Ancestors:   1,
+		Descendants: 1,
+		Format:      string(lineage.FormatJSON),
+	}
+	if err := schema.NewDecoder().Decode(&params, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+	if err := params.Digest.Validate(); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"digest\" parameter")
+	}
+	if params.Ancestors > maxLineageGenerations || params.Descendants > maxLineageGenerations {
+		return httputil.NewHTTPError(nil, http.StatusBadRequest,
+			fmt.Sprintf("The \"ancestors\" and \"descendants\" parameters must be at most %d", maxLineageGenerations))
+	}
+	format, err := lineage.ParseFormat(params.Format)
+	if err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"format\" parameter")
+	}
+
+	graph, err := db.GetLineage(con, params.Digest, params.Ancestors, params.Descendants)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return httputil.NewHTTPError(err, http.StatusNotFound, "Bottle not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	if format == lineage.FormatJSON {
+		if err := httputil.WriteJSON(w, graph); err != nil {
+			return fmt.Errorf("could not write JSON results: %w", err)
+		}
+		return nil
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	return lineage.Write(w, graph, format)
+}
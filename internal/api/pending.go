@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// pendingObject converts the pending object to the API type.
+func pendingObject(pending db.PendingObject) types.PendingObject {
+	missing := make([]digest.Digest, len(pending.Dependencies))
+	for i, d := range pending.Dependencies {
+		missing[i] = d.Digest
+	}
+	return types.PendingObject{
+		Type:           pending.Type,
+		Digest:         pending.Digest,
+		MissingDigests: missing,
+		CreatedAt:      pending.CreatedAt,
+		ExpiresAt:      pending.ExpiresAt,
+	}
+}
+
+// queue adds the object to the pending queue (when it is enabled) if err is only that the objects it depends on are missing.
+// It returns the pending object if the object was queued, otherwise err is returned.
+func (a *API) queue(con *gorm.DB, itemType string, dataID uint, dgst digest.Digest, err error) (*types.PendingObject, error) {
+	var missing *types.MissingDigestsError
+	if !a.Pending.Enabled || dataID == 0 || !errors.As(err, &missing) {
+		return nil, err
+	}
+
+	pending, err := db.AddPending(con, itemType, dataID, dgst, missing.MissingDigests, time.Now().Add(a.Pending.MaxAge.Duration))
+	if err != nil {
+		return nil, err
+	}
+	ctx := con.Statement.Context
+	logger.FromContext(ctx).InfoContext(ctx, "Queued object until its dependencies are uploaded",
+		"type", itemType, "digest", dgst, "missing", missing.MissingDigests)
+	result := pendingObject(*pending)
+	return &result, nil
+}
+
+// resolvePending processes the pending objects that were waiting for the new object (with the data).
+// Processed objects are ingested in turn (so they may resolve other pending objects).
+// Objects that are still missing some of their dependencies stay in the queue.
+func (a *API) resolvePending(con *gorm.DB, dataID uint) error {
+	if !a.Pending.Enabled {
+		return nil
+	}
+	ctx := con.Statement.Context
+	log := logger.FromContext(ctx)
+
+	dependents, err := db.PendingDependents(con, dataID)
+	if err != nil {
+		return err
+	}
+	for _, pending := range dependents {
+		log := log.With("type", pending.Type, "digest", pending.Digest)
+		processor, ok := a.processors[pending.Type]
+		if !ok {
+			return fmt.Errorf("unknown type %q of pending object", pending.Type)
+		}
+
+		// a savepoint so an object that still can not be processed does not leave anything behind
+		err := con.Transaction(func(tx *gorm.DB) error {
+			return processor.Process(tx, db.Base{
+				ProcessorVersion: processor.Version(),
+				Data:             pending.Data,
+				DataID:           pending.DataID,
+			})
+		})
+		var missing *types.MissingDigestsError
+		var clientErr httputil.ClientError
+		switch {
+		case err == nil:
+			log.InfoContext(ctx, "Processed pending object")
+			if err := db.RemovePending(con, &pending); err != nil {
+				return err
+			}
+			if err := a.ingested(con, processor, pending.DataID, pending.Digest); err != nil {
+				return err
+			}
+		case errors.As(err, &missing):
+			log.InfoContext(ctx, "Pending object is still missing dependencies", "missing", missing.MissingDigests)
+			if _, err := db.AddPending(con, pending.Type, pending.DataID, pending.Digest, missing.MissingDigests, pending.ExpiresAt); err != nil {
+				return err
+			}
+		case errors.As(err, &clientErr):
+			// the object is invalid for another reason so waiting longer will not help
+			log.InfoContext(ctx, "Discarding invalid pending object", "error", err)
+			if err := db.RemovePending(con, &pending); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// handleListPending lists the objects in the pending queue (oldest first) with the digests they are waiting for.
+// The "type" parameter limits the list to one type of object.
+func handleListPending(w http.ResponseWriter, r *http.Request) error {
+	con := middleware.DatabaseFromContext(r.Context())
+
+	tx := con.Preload("Dependencies").Where("expires_at > ?", time.Now()).Order("id")
+	if itemType := r.URL.Query().Get("type"); itemType != "" {
+		tx = tx.Where("type = ?", itemType)
+	}
+	pending := []db.PendingObject{}
+	if err := tx.Find(&pending).Error; err != nil {
+		return err
+	}
+
+	results := make([]types.PendingObject, len(pending))
+	for i, p := range pending {
+		results[i] = pendingObject(p)
+	}
+	return httputil.WriteJSON(w, map[string]any{"Results": results})
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// tombstoneDigests returns the digests of the tombstone.
+func tombstoneDigests(tombstone db.Tombstone) []digest.Digest {
+	dgsts := make([]digest.Digest, len(tombstone.Digests))
+	for i, d := range tombstone.Digests {
+		dgsts[i] = d.Digest
+	}
+	return dgsts
+}
+
+// handleRemoveBottle removes the bottle with the "digest" parameter and everything that references it.
+// The "reason" parameter is required so there is a record of why the bottle was removed.
+func handleRemoveBottle(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	dgst, err := digest.Parse(r.URL.Query().Get("digest"))
+	if err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"digest\" parameter")
+	}
+	reason := r.URL.Query().Get("reason")
+	if reason == "" {
+		return httputil.NewHTTPError(nil, http.StatusBadRequest, "The \"reason\" parameter is required")
+	}
+
+	var username string
+	if p := middleware.PrincipalFromContext(ctx); p != nil {
+		username = p.Username
+	}
+
+	tombstone, err := db.RemoveBottle(ctx, con, dgst, username, reason)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return httputil.NewHTTPError(err, http.StatusNotFound, "Bottle not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	return httputil.WriteJSON(w, types.Tombstone{
+		BottleDigest: tombstone.BottleDigest,
+		Digests:      tombstoneDigests(*tombstone),
+		RemovedAt:    tombstone.CreatedAt,
+		Username:     tombstone.Username,
+		Reason:       tombstone.Reason,
+	})
+}
+
+// handleListTombstones lists the removed bottles (without who removed them or why) so mirrors can discard their copies.
+func handleListTombstones(w http.ResponseWriter, r *http.Request) error {
+	con := middleware.DatabaseFromContext(r.Context())
+
+	tombstones := []db.Tombstone{}
+	if err := con.Preload("Digests").Order("id").Find(&tombstones).Error; err != nil {
+		return err
+	}
+
+	results := make([]types.Tombstone, len(tombstones))
+	for i, tombstone := range tombstones {
+		results[i] = types.Tombstone{
+			BottleDigest: tombstone.BottleDigest,
+			Digests:      tombstoneDigests(tombstone),
+			RemovedAt:    tombstone.CreatedAt,
+		}
+	}
+	return httputil.WriteJSON(w, map[string]any{"Results": results})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/schema"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// defaultSearchLimit is the number of bottles in a page of search results when the limit is not given.
+const defaultSearchLimit = 20
+
+// maxSearchLimit is the largest number of bottles in a page of search results.
+const maxSearchLimit = 100
+
+// parseSearchTime parses a time as RFC 3339 or as milliseconds since the Unix epoch (as used by the catalog of the web app).
+// It returns an invalid value (so decoding fails) if the time can not be parsed.
+func parseSearchTime(value string) reflect.Value {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return reflect.ValueOf(t)
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return reflect.ValueOf(time.UnixMilli(ms))
+	}
+	return reflect.Value{}
+}
+
+type catalogResultEntry struct {
+	db.Base
+	db.Digested
+	IsDeprecated bool
+	NumPulls     int
+	TotalCount   int64
+}
+
+// handleCatalogSearch searches for bottles with the filters of the catalog of the web app (see types.SearchQuery).
+// The response is a page of results (see types.SearchResponse).
+func handleCatalogSearch(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	con := middleware.DatabaseFromContext(ctx)
+
+	values := r.URL.Query()
+	for _, v := range values {
+		for i := range v {
+			v[i] = strings.TrimSpace(v[i])
+		}
+	}
+
+	query := types.SearchQuery{Limit: defaultSearchLimit}
+	decoder := schema.NewDecoder()
+	decoder.RegisterConverter(time.Time{}, parseSearchTime)
+	if err := decoder.Decode(&query, values); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+	if err := db.ValidateSearchQuery(query); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+	if query.Limit <= 0 || query.Page < 0 {
+		return httputil.NewHTTPError(nil, http.StatusBadRequest, "The \"limit\" parameter must be positive and the \"page\" parameter must not be negative")
+	}
+	query.Limit = min(query.Limit, maxSearchLimit)
+	log.InfoContext(ctx, "Parameters", "query", query)
+
+	search := func() *gorm.DB {
+		tx := con.Table("bottles").Scopes(db.SearchBottles(query))
+		if query.SortByMetric != "" {
+			tx = tx.Scopes(db.SortByMetric(query.SortByMetric, query.MetricSortAscending))
+		}
+		// the bottle ID makes the order (and therefore the pages) stable
+		tx = tx.Order("bottles.id").
+			Preload("Data").
+			Select("bottles.id", "bottles.created_at", "bottles.data_id").
+			Scopes(db.IncludeDigests("bottles"), db.IncludeIsDeprecated(), db.IncludeNumPulls())
+		tx.Statement.Selects = append(tx.Statement.Selects, "COUNT(*) OVER () AS total_count")
+		return tx
+	}
+
+	var entries []catalogResultEntry
+	if err := search().Limit(query.Limit).Offset(query.Page * query.Limit).Find(&entries).Error; err != nil {
+		return err
+	}
+
+	var total int64
+	if len(entries) > 0 {
+		total = entries[0].TotalCount
+	} else if query.Page > 0 {
+		// the page is past the end so the total comes from the first result
+		var first []catalogResultEntry
+		if err := search().Limit(1).Find(&first).Error; err != nil {
+			return err
+		}
+		if len(first) > 0 {
+			total = first[0].TotalCount
+		}
+	}
+
+	response := types.SearchResponse{
+		Results: make([]types.SearchResponseEntry, len(entries)),
+		Page:    query.Page,
+		Limit:   query.Limit,
+		Total:   total,
+	}
+	for i, entry := range entries {
+		response.Results[i] = types.SearchResponseEntry{
+			Digests:    entry.Digests,
+			CreatedAt:  entry.CreatedAt,
+			Deprecated: entry.IsDeprecated,
+			Pulls:      entry.NumPulls,
+			Bottle:     entry.Data.RawData,
+		}
+	}
+	response.More = int64((query.Page+1)*query.Limit) < response.Total
+
+	if err := httputil.WriteJSON(w, response); err != nil {
+		return fmt.Errorf("could not write JSON results: %w", err)
+	}
+	return nil
+}
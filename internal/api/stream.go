@@ -0,0 +1,320 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/schema"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+const (
+	// streamBatchSize is the maximum number of rows of a type read from the database at a time.
+	streamBatchSize = 100
+
+	// streamPollInterval is how often the database is checked for objects ingested by other replicas.
+	// A keep-alive comment is sent at the same interval.
+	streamPollInterval = 15 * time.Second
+
+	// streamRetry is the reconnection delay (in milliseconds) sent to the client.
+	streamRetry = 1000
+)
+
+// notifier wakes up waiters when new objects are ingested.
+type notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newNotifier() *notifier {
+	return &notifier{ch: make(chan struct{})}
+}
+
+// Notify wakes up all the current waiters.
+func (n *notifier) Notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.ch)
+	n.ch = make(chan struct{})
+}
+
+// Wait returns a channel that is closed on the next call to Notify.
+func (n *notifier) Wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ch
+}
+
+// streamPosition is the cursor of the last object sent for each type.
+// It is sent to the client as the SSE event ID so the client can resume with the Last-Event-ID header.
+type streamPosition map[string]db.ListCursor
+
+func (p streamPosition) String() string {
+	tokens := make(map[string]string, len(p))
+	for itemType, cursor := range p {
+		tokens[itemType] = cursor.String()
+	}
+	b, _ := json.Marshal(tokens) // a map of strings always marshals
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func parseStreamPosition(id string) (streamPosition, error) {
+	b, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return nil, fmt.Errorf("decoding stream position: %w", err)
+	}
+	tokens := map[string]string{}
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, fmt.Errorf("parsing stream position: %w", err)
+	}
+	p := make(streamPosition, len(tokens))
+	for itemType, token := range tokens {
+		cursor, err := db.ParseListCursor(token)
+		if err != nil {
+			return nil, err
+		}
+		p[itemType] = cursor
+	}
+	return p, nil
+}
+
+// handleStream returns an HTTP handler that streams newly ingested objects as Server-Sent Events.
+// Each SSE event is named after the object type and the data is a types.ListResultEntry.
+// Without a Last-Event-ID header (or lastEventID parameter) only objects ingested after the request are sent.
+func handleStream(changes *notifier) http.Handler {
+	return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		con := middleware.DatabaseFromContext(ctx)
+
+		itemTypes, selectors, pos, err := parseStreamParams(con, r)
+		if err != nil {
+			return err
+		}
+
+		// end the stream before any request timeout so the client can cleanly reconnect
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second))
+			defer cancel()
+		}
+		con = con.WithContext(ctx)
+
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return fmt.Errorf("clearing write deadline: %w", err)
+		}
+
+		w.Header().Set("Content-Type", types.MediaTypeEventStream)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry); err != nil {
+			return fmt.Errorf("writing stream: %w", err)
+		}
+		if err := rc.Flush(); err != nil {
+			return fmt.Errorf("flushing stream: %w", err)
+		}
+
+		log.InfoContext(ctx, "Streaming", "types", itemTypes)
+		err = streamLoop(ctx, con, w, changes, itemTypes, selectors, pos)
+		if ctx.Err() != nil {
+			// the client went away or the request timed out
+			return nil
+		}
+		return err
+	})
+}
+
+// streamLoop writes objects as they are ingested until the context is done.
+func streamLoop(ctx context.Context, con *gorm.DB, w http.ResponseWriter, changes *notifier, itemTypes, selectors []string, pos streamPosition) error {
+	rc := http.NewResponseController(w)
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+	for {
+		// get the channel before reading so we do not miss objects ingested while reading
+		wait := changes.Wait()
+
+		sent, err := streamAllObjects(con, w, itemTypes, selectors, pos)
+		if err != nil {
+			return err
+		}
+		if sent > 0 {
+			if err := rc.Flush(); err != nil {
+				return fmt.Errorf("flushing stream: %w", err)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wait:
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return fmt.Errorf("writing stream: %w", err)
+			}
+			if err := rc.Flush(); err != nil {
+				return fmt.Errorf("flushing stream: %w", err)
+			}
+		}
+	}
+}
+
+// parseStreamParams returns the types, selectors and starting position for the stream request.
+func parseStreamParams(con *gorm.DB, r *http.Request) ([]string, []string, streamPosition, error) {
+	type Params struct {
+		Types       []string `schema:"types"`
+		Selectors   []string `schema:"selector"`
+		LastEventID string   `schema:"lastEventID"`
+	}
+	var params Params
+	if err := schema.NewDecoder().Decode(&params, r.URL.Query()); err != nil {
+		return nil, nil, nil, httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+
+	itemTypes, err := streamTypes(params.Types)
+	if err != nil {
+		return nil, nil, nil, httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"types\" parameter")
+	}
+
+	// validate the selectors before we start streaming
+	if err := con.Table("bottles").Scopes(db.FilterBySelectors(params.Selectors)).Limit(0).Find(&[]db.Bottle{}).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = params.LastEventID
+	}
+	pos := streamPosition{}
+	if lastEventID != "" {
+		pos, err = parseStreamPosition(lastEventID)
+		if err != nil {
+			return nil, nil, nil, httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid Last-Event-ID")
+		}
+	}
+	for _, itemType := range itemTypes {
+		if _, ok := pos[itemType]; ok {
+			continue
+		}
+		// start after the newest object (unless resuming)
+		pos[itemType], err = latestCursor(con, itemType+"s")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return itemTypes, params.Selectors, pos, nil
+}
+
+// streamTypes returns the requested types (comma separated or repeated) in topological order.
+func streamTypes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return types.TopologicalOrderingOfTypes, nil
+	}
+	itemTypes := []string{}
+	for _, value := range requested {
+		for _, itemType := range strings.Split(value, ",") {
+			itemType = strings.TrimSpace(itemType)
+			if !slices.Contains(types.TopologicalOrderingOfTypes, itemType) {
+				return nil, fmt.Errorf("unknown type %q", itemType)
+			}
+			if !slices.Contains(itemTypes, itemType) {
+				itemTypes = append(itemTypes, itemType)
+			}
+		}
+	}
+	slices.SortFunc(itemTypes, func(a, b string) int {
+		return cmp.Compare(typeRank(a), typeRank(b))
+	})
+	return itemTypes, nil
+}
+
+// latestCursor returns the cursor of the newest row in the table.
+func latestCursor(con *gorm.DB, table string) (db.ListCursor, error) {
+	var latest db.Model
+	err := con.Table(table).
+		Select("id", "created_at").
+		Order("created_at DESC").
+		Order("id DESC").
+		Take(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.ListCursor{}, nil
+	}
+	if err != nil {
+		return db.ListCursor{}, err
+	}
+	return db.ListCursor{CreatedAt: latest.CreatedAt, ID: latest.ID}, nil
+}
+
+// streamAllObjects writes the objects of all the types after the position as SSE events.
+func streamAllObjects(con *gorm.DB, w http.ResponseWriter, itemTypes, selectors []string, pos streamPosition) (int, error) {
+	sent := 0
+	for _, itemType := range itemTypes {
+		n, err := streamObjects(con, w, itemType, selectors, pos)
+		if err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+// streamObjects writes the objects of the type after the position as SSE events and advances the position.
+// Blobs do not belong to a bottle so they never match a selector.
+func streamObjects(con *gorm.DB, w http.ResponseWriter, itemType string, selectors []string, pos streamPosition) (int, error) {
+	table := itemType + "s"
+	tx := con.Table(table).
+		Preload("Data").
+		Select(table+".id", table+".created_at", table+".data_id").
+		Limit(streamBatchSize).
+		Scopes(db.IncludeDigests(table), db.AfterCursor(pos[itemType], table))
+
+	if len(selectors) != 0 {
+		switch itemType {
+		case "blob":
+			return 0, nil
+		case "bottle":
+			tx = tx.Scopes(db.FilterBySelectors(selectors))
+		default:
+			bottles := con.Session(&gorm.Session{NewDB: true}).
+				Table("bottles").
+				Select("bottles.id").
+				Scopes(db.FilterBySelectors(selectors))
+			tx = tx.Where(table+".bottle_id IN (?)", bottles)
+		}
+	}
+
+	var entries []listResultEntry
+	if err := tx.Find(&entries).Error; err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		pos[itemType] = db.ListCursor{CreatedAt: entry.CreatedAt, ID: entry.ID}
+		data, err := json.Marshal(&entry)
+		if err != nil {
+			return 0, fmt.Errorf("encoding %s: %w", itemType, err)
+		}
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", pos, itemType, data); err != nil {
+			return 0, fmt.Errorf("writing stream: %w", err)
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/schema"
+	"gorm.io/gorm"
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+)
+
+// handleGetTags responds with the history of the tags of a repository (see types.RepositoryTags).
+// The "repository" parameter is required and the "tag" parameter limits the response to one tag.
+func handleGetTags(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	type Params struct {
+		Repository string `schema:"repository"`
+		Tag        string `schema:"tag"`
+	}
+
+	params := Params{}
+	if err := schema.NewDecoder().Decode(&params, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+	if params.Repository == "" {
+		return httputil.NewHTTPError(nil, http.StatusBadRequest, "The \"repository\" parameter is required")
+	}
+
+	tags, err := db.FindRepositoryTags(con, params.Repository, params.Tag)
+	if err != nil {
+		return err
+	}
+
+	if err := httputil.WriteJSON(w, tags); err != nil {
+		return fmt.Errorf("could not write JSON results: %w", err)
+	}
+	return nil
+}
+
+// handleResolve responds with the push of a tag (see types.TagPush) that the tag points to.
+// The "ref" parameter is the reference ("registry/repository:tag") to resolve (the tag defaults to "latest").
+// The "at" parameter (RFC 3339 or milliseconds since the Unix epoch) resolves the tag as it was at that time.
+func handleResolve(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	type Params struct {
+		Ref string    `schema:"ref"`
+		At  time.Time `schema:"at"`
+	}
+
+	params := Params{}
+	decoder := schema.NewDecoder()
+	decoder.RegisterConverter(time.Time{}, parseSearchTime)
+	if err := decoder.Decode(&params, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters")
+	}
+
+	ref, err := registry.ParseReference(params.Ref)
+	if err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \"ref\" parameter: "+err.Error())
+	}
+	if strings.Contains(ref.Reference, ":") {
+		return httputil.NewHTTPError(nil, http.StatusBadRequest, "The \"ref\" parameter must be a tag (not a digest)")
+	}
+
+	repository := ref.Registry + "/" + ref.Repository
+	push, err := db.ResolveTag(con, repository, ref.ReferenceOrDefault(), params.At)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return httputil.NewHTTPError(err, http.StatusNotFound, "Tag not found: "+repository+":"+ref.ReferenceOrDefault())
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := httputil.WriteJSON(w, push); err != nil {
+		return fmt.Errorf("could not write JSON results: %w", err)
+	}
+	return nil
+}
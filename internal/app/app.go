@@ -17,8 +17,10 @@ import (
 	"github.com/act3-ai/go-common/pkg/httputil/promhttputil"
 
 	"github.com/act3-ai/data-telemetry/v3/internal/api"
+	telemdb "github.com/act3-ai/data-telemetry/v3/internal/db"
 	mware "github.com/act3-ai/data-telemetry/v3/internal/middleware"
 	"github.com/act3-ai/data-telemetry/v3/internal/webapp"
+	"github.com/act3-ai/data-telemetry/v3/internal/webhook"
 	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
 )
 
@@ -35,14 +37,30 @@ const (
 type App struct {
 	HTTPHandler http.Handler
 	DB          *gorm.DB
+	Webhooks    *webhook.Dispatcher
 }
 
 // NewApp create a new Telemetry application.
-func NewApp(db *gorm.DB, scheme *runtime.Scheme, webConf v1alpha2.WebApp, log *slog.Logger, version string) (*App, error) {
+func NewApp(db *gorm.DB, scheme *runtime.Scheme, conf v1alpha2.ServerConfigurationSpec, log *slog.Logger, version string) (*App, error) {
 	if db == nil {
 		return nil, errors.New("DB is required")
 	}
 
+	trustPolicy, err := telemdb.NewTrustPolicy(conf.Trust)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust configuration: %w", err)
+	}
+
+	webhooks, err := webhook.NewDispatcher(conf.Webhooks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook configuration: %w", err)
+	}
+
+	verifier, err := mware.NewSignatureVerifier(conf.RequestSigning)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request signing configuration: %w", err)
+	}
+
 	mainMux := http.NewServeMux()
 
 	// add some middleware
@@ -52,16 +70,18 @@ func NewApp(db *gorm.DB, scheme *runtime.Scheme, webConf v1alpha2.WebApp, log *s
 		httputil.TracingMiddleware(
 			httputil.LoggingMiddleware(log)(
 				mware.DatabaseMiddleware(db)(
-					promhttputil.PrometheusMiddleware(
-						// Set a timeout value on the request context (ctx), that will signal
-						// through ctx.Done() that the request has timed out and further
-						// processing should be stopped.
-						httputil.TimeoutMiddleware(mainMux, 60*time.Second))))))
+					mware.TrustAnchorMiddleware(trustPolicy)(
+						promhttputil.PrometheusMiddleware(
+							// Set a timeout value on the request context (ctx), that will signal
+							// through ctx.Done() that the request has timed out and further
+							// processing should be stopped.
+							httputil.TimeoutMiddleware(mainMux, 60*time.Second)))))))
 	// mware.RecovererMiddleware,
 
-	a := &App{wrappedMainMuxHandler, db}
+	a := &App{wrappedMainMuxHandler, db, webhooks}
 
 	prometheus.DefaultRegisterer.MustRegister(promhttputil.HTTPDuration)
+	prometheus.DefaultRegisterer.MustRegister(telemdb.GCCollectors()...)
 
 	// TODO this should be exposed on its own port
 	mainMux.Handle("GET /metrics", promhttp.Handler())
@@ -71,18 +91,22 @@ func NewApp(db *gorm.DB, scheme *runtime.Scheme, webConf v1alpha2.WebApp, log *s
 	mainMux.Handle("GET /version", versionHandler(version))
 
 	// Setup the REST API
-	myAPI := api.API{}
+	myAPI := api.API{Webhooks: webhooks, Pending: conf.Pending}
+	// the REST API and the web app share the authentication and access rules
+	authMiddleware := mware.AuthMiddleware(mware.NewAuthenticator(conf.Auth))
 	apiMux := http.NewServeMux()
-	mainMux.Handle("/api/", http.StripPrefix("/api", apiMux))
+	mainMux.Handle("/api/", http.StripPrefix("/api",
+		authMiddleware(
+			mware.SignatureVerifyMiddleware(verifier)(apiMux))))
 	myAPI.Initialize(apiMux, scheme)
 
 	// Setup the Web App (leaderboard, catalog, ...)
-	webApp, err := webapp.NewWebApp(webConf, log, version)
+	webApp, err := webapp.NewWebApp(conf.WebApp, log, version)
 	if err != nil {
 		return nil, err
 	}
 	webMux := http.NewServeMux()
-	mainMux.Handle("/www/", http.StripPrefix("/www", webMux))
+	mainMux.Handle("/www/", http.StripPrefix("/www", authMiddleware(webMux)))
 	webApp.Initialize(webMux)
 
 	mainMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
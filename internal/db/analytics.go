@@ -0,0 +1,198 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/selector"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// bucketLayout is the layout of the start of a time bucket as selected by truncateTimestamp.
+const bucketLayout = "2006-01-02 15:04:05"
+
+// truncateTimestamp returns the SQL expression for the start (in UTC) of the time bucket of an event.
+// The expression is text in bucketLayout so both databases scan the same way.
+func truncateTimestamp(con *gorm.DB, interval types.EventInterval) (string, error) {
+	if con.Name() == "postgres" {
+		switch interval {
+		case types.IntervalHour, types.IntervalDay, types.IntervalWeek, types.IntervalMonth:
+			// date_trunc weeks start on Monday (ISO 8601)
+			return fmt.Sprintf("to_char(date_trunc('%s', events.timestamp AT TIME ZONE 'UTC'), 'YYYY-MM-DD HH24:MI:SS')", interval), nil
+		}
+		return "", fmt.Errorf("unknown interval %q", interval)
+	}
+
+	// SQLite converts times with a time zone to UTC
+	switch interval {
+	case types.IntervalHour:
+		return "strftime('%Y-%m-%d %H:00:00', events.timestamp)", nil
+	case types.IntervalDay:
+		return "strftime('%Y-%m-%d 00:00:00', events.timestamp)", nil
+	case types.IntervalWeek:
+		// advance to Sunday (unless it is Sunday) then back to Monday
+		return "strftime('%Y-%m-%d 00:00:00', events.timestamp, 'weekday 0', '-6 days')", nil
+	case types.IntervalMonth:
+		return "strftime('%Y-%m-01 00:00:00', events.timestamp)", nil
+	}
+	return "", fmt.Errorf("unknown interval %q", interval)
+}
+
+// eventGroupColumn returns the column of events to group by.
+func eventGroupColumn(groupBy types.EventGroupBy) (string, error) {
+	switch groupBy {
+	case types.GroupByBottle:
+		return "events.bottle_digest", nil
+	case types.GroupByRepository:
+		return "events.repository", nil
+	case types.GroupByUsername:
+		return "events.username", nil
+	case types.GroupByAction:
+		return "events.action", nil
+	}
+	return "", fmt.Errorf("unknown grouping %q", groupBy)
+}
+
+// validateEventFilter validates the label selectors and time range of the filter.
+func validateEventFilter(f types.EventFilter) error {
+	var multiError error
+
+	for _, labelSelector := range f.LabelSelectors {
+		if _, err := selector.Parse(labelSelector); err != nil {
+			multiError = errors.Join(multiError, fmt.Errorf("invalid param \"label-selector\" (%s): %w", labelSelector, err))
+		}
+	}
+
+	if !f.Start.IsZero() && !f.End.IsZero() && !f.End.After(f.Start) {
+		multiError = errors.Join(multiError, errors.New("invalid params \"start\" and \"end\": the end must be after the start"))
+	}
+
+	return multiError
+}
+
+// validateInterval validates an interval (the empty interval is the default).
+func validateInterval(interval types.EventInterval) error {
+	switch interval {
+	case "", types.IntervalHour, types.IntervalDay, types.IntervalWeek, types.IntervalMonth:
+		return nil
+	}
+	return fmt.Errorf("invalid param \"interval\" (%s): must be hour, day, week, or month", interval)
+}
+
+// filterEvents is a scope that selects the events that match the filter.
+// The label selectors must already be validated (see selector.Parse).
+func filterEvents(f types.EventFilter) func(db *gorm.DB) *gorm.DB {
+	return func(con *gorm.DB) *gorm.DB {
+		if f.Action != "" {
+			con = con.Where("events.action = ?", f.Action)
+		}
+		if !f.Start.IsZero() {
+			con = con.Where("events.timestamp >= ?", f.Start.UTC())
+		}
+		if !f.End.IsZero() {
+			con = con.Where("events.timestamp < ?", f.End.UTC())
+		}
+		if len(f.LabelSelectors) > 0 {
+			con = con.Where("events.bottle_id IN (?)", selectedBottleIDs(con, f.LabelSelectors))
+		}
+		return con
+	}
+}
+
+// filterEventCounts is a scope that selects the counts of expired events (see EventCount) that match the action and
+// label selectors of the filter.  The time range does not apply since the times of expired events are not retained.
+// The label selectors must already be validated (see selector.Parse).
+func filterEventCounts(f types.EventFilter) func(db *gorm.DB) *gorm.DB {
+	return func(con *gorm.DB) *gorm.DB {
+		if f.Action != "" {
+			con = con.Where("event_counts.action = ?", f.Action)
+		}
+		if len(f.LabelSelectors) > 0 {
+			con = con.Where("event_counts.bottle_id IN (?)", selectedBottleIDs(con, f.LabelSelectors))
+		}
+		return con
+	}
+}
+
+// selectedBottleIDs returns a subquery of the IDs of the bottles that match the label selectors.
+// It is a subquery so the joins on labels do not repeat rows.
+func selectedBottleIDs(con *gorm.DB, labelSelectors []string) *gorm.DB {
+	return con.Session(&gorm.Session{NewDB: true}).
+		Table("bottles").
+		Select("bottles.id").
+		Scopes(FilterBySelectors(labelSelectors))
+}
+
+// ValidateEventAnalyticsQuery validates the interval, grouping, label selectors, and time range of the query.
+func ValidateEventAnalyticsQuery(q types.EventAnalyticsQuery) error {
+	multiError := errors.Join(validateInterval(q.Interval), validateEventFilter(q.EventFilter))
+
+	if q.GroupBy != "" {
+		if _, err := eventGroupColumn(q.GroupBy); err != nil {
+			multiError = errors.Join(multiError, fmt.Errorf("invalid param \"group-by\" (%s): must be bottle, repository, username, or action", q.GroupBy))
+		}
+	}
+
+	return multiError
+}
+
+// EventAnalytics counts the events that match the query in time buckets for each group.
+// The query must be valid (see ValidateEventAnalyticsQuery).
+func EventAnalytics(con *gorm.DB, query types.EventAnalyticsQuery) (*types.EventAnalytics, error) {
+	if query.Interval == "" {
+		query.Interval = types.IntervalDay
+	}
+	bucket, err := truncateTimestamp(con, query.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := con.Model(&Event{}).Scopes(filterEvents(query.EventFilter))
+	if query.GroupBy != "" {
+		group, err := eventGroupColumn(query.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Select(bucket + " AS bucket, " + group + " AS grp, COUNT(*) AS count").
+			Group("bucket, grp").
+			Order("grp, bucket")
+	} else {
+		tx = tx.Select(bucket + " AS bucket, COUNT(*) AS count").
+			Group("bucket").
+			Order("bucket")
+	}
+
+	type result struct {
+		Bucket string
+		Grp    string
+		Count  int64
+	}
+
+	var results []result
+	if err := tx.Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	analytics := &types.EventAnalytics{
+		Interval: query.Interval,
+		GroupBy:  query.GroupBy,
+		Series:   []types.EventSeries{},
+	}
+	for i, r := range results {
+		start, err := time.Parse(bucketLayout, r.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time bucket %q: %w", r.Bucket, err)
+		}
+		// results are ordered by group so a new group starts a new series
+		if i == 0 || r.Grp != results[i-1].Grp {
+			analytics.Series = append(analytics.Series, types.EventSeries{Group: r.Grp})
+		}
+		series := &analytics.Series[len(analytics.Series)-1]
+		series.Total += r.Count
+		series.Buckets = append(series.Buckets, types.EventBucket{Start: start, Count: r.Count})
+	}
+	return analytics, nil
+}
@@ -18,7 +18,7 @@ import (
 )
 
 // BottleProcessorVersion is the current version of the processor code.  This is incremented after each measurable change to the BottleProcessor().
-const BottleProcessorVersion = 11
+const BottleProcessorVersion = 12
 
 // BottleProcessor handles bottle processing.
 type BottleProcessor struct {
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListCursor is a position in a listing of a table ordered by (created_at, id).
+// Rows often share a created_at timestamp so the ID is needed to break ties.
+type ListCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// String encodes the cursor as an opaque continuation token.
+func (c ListCursor) String() string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "," + strconv.FormatUint(uint64(c.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseListCursor decodes a continuation token produced by ListCursor.String.
+func ParseListCursor(token string) (ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ListCursor{}, fmt.Errorf("decoding cursor: %w", err)
+	}
+
+	ts, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return ListCursor{}, errors.New("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return ListCursor{}, fmt.Errorf("parsing cursor timestamp: %w", err)
+	}
+
+	n, err := strconv.ParseUint(id, 10, 0)
+	if err != nil {
+		return ListCursor{}, fmt.Errorf("parsing cursor ID: %w", err)
+	}
+
+	return ListCursor{CreatedAt: createdAt, ID: uint(n)}, nil
+}
+
+// AfterCursor filters the query to rows that come after the cursor and orders the rows by (created_at, id).
+func AfterCursor(cursor ListCursor, tableName string) func(db *gorm.DB) *gorm.DB {
+	return func(con *gorm.DB) *gorm.DB {
+		createdAt := tableName + ".created_at"
+		id := tableName + ".id"
+		return con.
+			Where(fmt.Sprintf("%s > ? OR (%s = ? AND %s > ?)", createdAt, createdAt, id), cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+			Order(createdAt + " ASC").
+			Order(id + " ASC")
+	}
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
+	"github.com/act3-ai/go-common/pkg/redact"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+type CursorTestSuite struct {
+	suite.Suite
+	ctx context.Context
+	con *gorm.DB
+}
+
+func (s *CursorTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	scheme := runtime.NewScheme()
+	s.NoError(bottle.AddToScheme(scheme))
+	myDB, err := Open(s.ctx, v1alpha2.Database{
+		DSN: redact.SecretURL("file::memory:"),
+	}, scheme)
+	s.NoError(err)
+	s.con = myDB
+}
+
+func (s *CursorTestSuite) TestRoundTrip() {
+	cursor := ListCursor{
+		CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("test", -5*60*60)),
+		ID:        42,
+	}
+	parsed, err := ParseListCursor(cursor.String())
+	s.NoError(err)
+	s.True(cursor.CreatedAt.Equal(parsed.CreatedAt))
+	s.Equal(cursor.ID, parsed.ID)
+
+	_, err = ParseListCursor("not a cursor")
+	s.Error(err)
+}
+
+func (s *CursorTestSuite) TestAfterCursor() {
+	// all the blobs share a timestamp so paging by time alone would skip some of them
+	createdAt := time.Now()
+	for i := range 5 {
+		raw := []byte(fmt.Sprintf("blob %d", i))
+		blob := &Blob{
+			Base: Base{
+				Model: Model{gorm.Model{CreatedAt: createdAt}},
+				Data: Data{
+					RawData:         raw,
+					CanonicalDigest: digest.FromBytes(raw),
+				},
+			},
+		}
+		s.NoError(s.con.Create(blob).Error)
+	}
+
+	var cursor ListCursor
+	seen := []uint{}
+	for {
+		var blobs []Blob
+		s.NoError(s.con.Scopes(AfterCursor(cursor, "blobs")).Limit(2).Find(&blobs).Error)
+		if len(blobs) == 0 {
+			break
+		}
+		for _, blob := range blobs {
+			seen = append(seen, blob.ID)
+		}
+		last := blobs[len(blobs)-1]
+		cursor = ListCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	s.Equal([]uint{1, 2, 3, 4, 5}, seen)
+}
+
+func TestCursorTestSuite(t *testing.T) {
+	suite.Run(t, new(CursorTestSuite))
+}
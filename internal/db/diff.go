@@ -0,0 +1,259 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// FindBottleForDiff finds the bottle with all the members compared by DiffBottles.
+// gorm.ErrRecordNotFound is returned if the bottle is not known.
+func FindBottleForDiff(con *gorm.DB, dgst digest.Digest) (*BottleRelative, error) {
+	bottle := &BottleRelative{}
+	if err := con.Table("bottles").
+		Select("bottles.*").
+		Preload("Parts").
+		Preload("Labels").
+		Preload("Annotations").
+		Preload("Metrics").
+		Preload("Sources").
+		Preload("Authors").
+		Preload("PublicArtifacts").
+		Preload("Deprecates").
+		Scopes(IncludeDigests("bottles"), FilterByDigest(dgst, "bottles")).
+		First(bottle).Error; err != nil {
+		return nil, err
+	}
+	return bottle, nil
+}
+
+// diffValues compares the values (by name) of two bottles.  The result is sorted by name.
+func diffValues(from, to map[string]string) []types.ValueDiff {
+	var diffs []types.ValueDiff
+	for name, f := range from {
+		t, ok := to[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, types.ValueDiff{Name: name, Change: types.DiffRemoved, From: f})
+		case f != t:
+			diffs = append(diffs, types.ValueDiff{Name: name, Change: types.DiffChanged, From: f, To: t})
+		}
+	}
+	for name, t := range to {
+		if _, ok := from[name]; !ok {
+			diffs = append(diffs, types.ValueDiff{Name: name, Change: types.DiffAdded, To: t})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+func labelValues(labels []Label) map[string]string {
+	values := make(map[string]string, len(labels))
+	for _, l := range labels {
+		values[l.Key] = l.Value
+	}
+	return values
+}
+
+func annotationValues(annotations []Annotation) map[string]string {
+	values := make(map[string]string, len(annotations))
+	for _, a := range annotations {
+		values[a.Key] = a.Value
+	}
+	return values
+}
+
+func sourceValues(sources []Source) map[string]string {
+	values := make(map[string]string, len(sources))
+	for _, s := range sources {
+		values[s.Name] = s.URI
+	}
+	return values
+}
+
+// diffParts compares the parts by name.  Removed and added parts with the same digest are reported as renamed.
+func diffParts(from, to []Part) []types.PartDiff {
+	toParts := make(map[string]Part, len(to))
+	for _, p := range to {
+		toParts[p.Name] = p
+	}
+	fromParts := make(map[string]Part, len(from))
+	for _, p := range from {
+		fromParts[p.Name] = p
+	}
+
+	var diffs []types.PartDiff
+	// removed parts by digest (there may be several parts with the same content)
+	removed := map[digest.Digest][]Part{}
+	for _, f := range from {
+		t, ok := toParts[f.Name]
+		if !ok {
+			removed[f.Digest] = append(removed[f.Digest], f)
+			continue
+		}
+		labels := diffValues(f.Labels, t.Labels)
+		if f.Digest != t.Digest || f.Size != t.Size || len(labels) > 0 {
+			diffs = append(diffs, types.PartDiff{
+				Name: f.Name, Change: types.DiffChanged,
+				FromDigest: f.Digest, ToDigest: t.Digest, FromSize: f.Size, ToSize: t.Size,
+				Labels: labels,
+			})
+		}
+	}
+
+	for _, t := range to {
+		if _, ok := fromParts[t.Name]; ok {
+			continue
+		}
+		if parts := removed[t.Digest]; len(parts) > 0 {
+			f := parts[0]
+			removed[t.Digest] = parts[1:]
+			diffs = append(diffs, types.PartDiff{
+				Name: t.Name, Change: types.DiffRenamed, FromName: f.Name,
+				FromDigest: f.Digest, ToDigest: t.Digest, FromSize: f.Size, ToSize: t.Size,
+				Labels: diffValues(f.Labels, t.Labels),
+			})
+			continue
+		}
+		diffs = append(diffs, types.PartDiff{Name: t.Name, Change: types.DiffAdded, ToDigest: t.Digest, ToSize: t.Size})
+	}
+
+	for _, parts := range removed {
+		for _, f := range parts {
+			diffs = append(diffs, types.PartDiff{Name: f.Name, Change: types.DiffRemoved, FromDigest: f.Digest, FromSize: f.Size})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+func diffMetrics(from, to []Metric) []types.MetricDiff {
+	toMetrics := make(map[string]float64, len(to))
+	for _, m := range to {
+		toMetrics[m.Name] = m.Value
+	}
+	fromMetrics := make(map[string]float64, len(from))
+	for _, m := range from {
+		fromMetrics[m.Name] = m.Value
+	}
+
+	var diffs []types.MetricDiff
+	for name, f := range fromMetrics {
+		t, ok := toMetrics[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, types.MetricDiff{Name: name, Change: types.DiffRemoved, From: &f})
+		case f != t:
+			diffs = append(diffs, types.MetricDiff{Name: name, Change: types.DiffChanged, From: &f, To: &t, Delta: t - f})
+		}
+	}
+	for name, t := range toMetrics {
+		if _, ok := fromMetrics[name]; !ok {
+			diffs = append(diffs, types.MetricDiff{Name: name, Change: types.DiffAdded, To: &t})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+func diffAuthors(from, to []Author) []types.AuthorDiff {
+	convert := func(authors []Author) map[string]types.Author {
+		result := make(map[string]types.Author, len(authors))
+		for _, a := range authors {
+			result[a.Name] = types.Author{Name: a.Name, Email: a.Email, URL: a.URL}
+		}
+		return result
+	}
+	fromAuthors, toAuthors := convert(from), convert(to)
+
+	var diffs []types.AuthorDiff
+	for name, f := range fromAuthors {
+		t, ok := toAuthors[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, types.AuthorDiff{Name: name, Change: types.DiffRemoved, From: &f})
+		case f != t:
+			diffs = append(diffs, types.AuthorDiff{Name: name, Change: types.DiffChanged, From: &f, To: &t})
+		}
+	}
+	for name, t := range toAuthors {
+		if _, ok := fromAuthors[name]; !ok {
+			diffs = append(diffs, types.AuthorDiff{Name: name, Change: types.DiffAdded, To: &t})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+func diffArtifacts(from, to []PublicArtifact) []types.ArtifactDiff {
+	toArtifacts := make(map[string]PublicArtifact, len(to))
+	for _, a := range to {
+		toArtifacts[a.Path] = a
+	}
+	fromArtifacts := make(map[string]PublicArtifact, len(from))
+	for _, a := range from {
+		fromArtifacts[a.Path] = a
+	}
+
+	var diffs []types.ArtifactDiff
+	for path, f := range fromArtifacts {
+		t, ok := toArtifacts[path]
+		switch {
+		case !ok:
+			diffs = append(diffs, types.ArtifactDiff{
+				Path: path, Change: types.DiffRemoved, Name: f.Name, MediaType: f.MediaType, FromDigest: f.Digest,
+			})
+		case f.Digest != t.Digest || f.Name != t.Name || f.MediaType != t.MediaType:
+			diffs = append(diffs, types.ArtifactDiff{
+				Path: path, Change: types.DiffChanged, Name: t.Name, MediaType: t.MediaType, FromDigest: f.Digest, ToDigest: t.Digest,
+			})
+		}
+	}
+	for path, t := range toArtifacts {
+		if _, ok := fromArtifacts[path]; !ok {
+			diffs = append(diffs, types.ArtifactDiff{
+				Path: path, Change: types.DiffAdded, Name: t.Name, MediaType: t.MediaType, ToDigest: t.Digest,
+			})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// deprecates returns true if the bottle deprecates the other bottle.
+func deprecates(bottle, other *BottleRelative) bool {
+	for _, d := range bottle.Deprecates {
+		for _, dgst := range other.Digests {
+			if d.DeprecatedBottleDigest == dgst {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DiffBottles compares two bottles (found with FindBottleForDiff) from the older bottle to the newer bottle.
+// Parts are matched by name (or by digest if they are renamed), metrics, authors, and sources by name, and public artifacts by path.
+func DiffBottles(from, to *BottleRelative, fromDigest, toDigest digest.Digest) types.BottleDiff {
+	diff := types.BottleDiff{
+		From:            fromDigest,
+		To:              toDigest,
+		Deprecates:      deprecates(to, from),
+		Parts:           diffParts(from.Parts, to.Parts),
+		Labels:          diffValues(labelValues(from.Labels), labelValues(to.Labels)),
+		Annotations:     diffValues(annotationValues(from.Annotations), annotationValues(to.Annotations)),
+		Metrics:         diffMetrics(from.Metrics, to.Metrics),
+		Sources:         diffValues(sourceValues(from.Sources), sourceValues(to.Sources)),
+		Authors:         diffAuthors(from.Authors, to.Authors),
+		PublicArtifacts: diffArtifacts(from.PublicArtifacts, to.PublicArtifacts),
+	}
+	if from.Description != to.Description {
+		diff.Description = &types.ValueDiff{Change: types.DiffChanged, From: from.Description, To: to.Description}
+	}
+	return diff
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+func TestDiffBottles(t *testing.T) {
+	fromDigest := digest.FromString("from")
+	toDigest := digest.FromString("to")
+	partDigest := digest.FromString("part")
+
+	from := &BottleRelative{
+		Bottle: Bottle{
+			Description: "first",
+			Parts: []Part{
+				{Name: "train", Digest: partDigest, Size: 10, Labels: map[string]string{"split": "train"}},
+				{Name: "old", Digest: digest.FromString("old"), Size: 1},
+				{Name: "same", Digest: digest.FromString("same"), Size: 1},
+			},
+			Labels:  []Label{{Key: "stage", Value: "dev"}, {Key: "team", Value: "a"}},
+			Metrics: []Metric{{Name: "accuracy", Value: 0.5}, {Name: "loss", Value: 2}},
+			Sources: []Source{{Name: "raw", URI: "https://example.com/v1"}},
+			Authors: []Author{{Name: "Jane", Email: "jane@example.com"}},
+			PublicArtifacts: []PublicArtifact{
+				{Path: "README.md", Name: "readme", MediaType: "text/markdown", Digest: digest.FromString("readme")},
+			},
+		},
+		Digested: Digested{Digests: []digest.Digest{fromDigest}},
+	}
+	to := &BottleRelative{
+		Bottle: Bottle{
+			Description: "second",
+			Parts: []Part{
+				{Name: "training", Digest: partDigest, Size: 10, Labels: map[string]string{"split": "train"}},
+				{Name: "same", Digest: digest.FromString("same"), Size: 1},
+				{Name: "new", Digest: digest.FromString("new"), Size: 2},
+			},
+			Labels:  []Label{{Key: "stage", Value: "prod"}, {Key: "team", Value: "a"}, {Key: "owner", Value: "b"}},
+			Metrics: []Metric{{Name: "accuracy", Value: 0.75}},
+			Sources: []Source{{Name: "raw", URI: "https://example.com/v2"}},
+			Authors: []Author{{Name: "Jane", Email: "jane@example.com"}, {Name: "John"}},
+			PublicArtifacts: []PublicArtifact{
+				{Path: "README.md", Name: "readme", MediaType: "text/markdown", Digest: digest.FromString("readme2")},
+			},
+			Deprecates: []Deprecates{{DeprecatedBottleDigest: fromDigest}},
+		},
+		Digested: Digested{Digests: []digest.Digest{toDigest}},
+	}
+
+	diff := DiffBottles(from, to, fromDigest, toDigest)
+	assert.True(t, diff.Deprecates)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, &types.ValueDiff{Change: types.DiffChanged, From: "first", To: "second"}, diff.Description)
+
+	assert.Equal(t, []types.PartDiff{
+		{Name: "new", Change: types.DiffAdded, ToDigest: digest.FromString("new"), ToSize: 2},
+		{Name: "old", Change: types.DiffRemoved, FromDigest: digest.FromString("old"), FromSize: 1},
+		{Name: "training", Change: types.DiffRenamed, FromName: "train", FromDigest: partDigest, ToDigest: partDigest, FromSize: 10, ToSize: 10},
+	}, diff.Parts)
+
+	assert.Equal(t, []types.ValueDiff{
+		{Name: "owner", Change: types.DiffAdded, To: "b"},
+		{Name: "stage", Change: types.DiffChanged, From: "dev", To: "prod"},
+	}, diff.Labels)
+	assert.Empty(t, diff.Annotations)
+
+	if assert.Len(t, diff.Metrics, 2) {
+		assert.Equal(t, "accuracy", diff.Metrics[0].Name)
+		assert.InDelta(t, 0.25, diff.Metrics[0].Delta, 1e-9)
+		assert.Equal(t, types.DiffRemoved, diff.Metrics[1].Change)
+		assert.Nil(t, diff.Metrics[1].To)
+	}
+
+	assert.Equal(t, []types.ValueDiff{
+		{Name: "raw", Change: types.DiffChanged, From: "https://example.com/v1", To: "https://example.com/v2"},
+	}, diff.Sources)
+	assert.Equal(t, []types.AuthorDiff{{Name: "John", Change: types.DiffAdded, To: &types.Author{Name: "John"}}}, diff.Authors)
+	assert.Equal(t, []types.ArtifactDiff{{
+		Path: "README.md", Change: types.DiffChanged, Name: "readme", MediaType: "text/markdown",
+		FromDigest: digest.FromString("readme"), ToDigest: digest.FromString("readme2"),
+	}}, diff.PublicArtifacts)
+
+	// a bottle does not differ from itself
+	assert.True(t, DiffBottles(to, to, toDigest, toDigest).Empty())
+	assert.False(t, DiffBottles(to, from, toDigest, fromDigest).Deprecates)
+}
+
+func TestDiffPartsSameDigest(t *testing.T) {
+	empty := digest.FromString("")
+	from := []Part{
+		{Name: "a/.keep", Digest: empty},
+		{Name: "b/.keep", Digest: empty},
+		{Name: "c/.keep", Digest: empty},
+	}
+	to := []Part{
+		{Name: "d/.keep", Digest: empty},
+	}
+
+	// one removed part is renamed and the others are still removed
+	assert.Equal(t, []types.PartDiff{
+		{Name: "b/.keep", Change: types.DiffRemoved, FromDigest: empty},
+		{Name: "c/.keep", Change: types.DiffRemoved, FromDigest: empty},
+		{Name: "d/.keep", Change: types.DiffRenamed, FromName: "a/.keep", FromDigest: empty, ToDigest: empty},
+	}, diffParts(from, to))
+}
@@ -14,7 +14,7 @@ import (
 )
 
 // EventProcessorVersion is the current version of the processor code.  This is incremented after each measurable change to the EventProcessor().
-const EventProcessorVersion = 3
+const EventProcessorVersion = 4
 
 // EventProcessor handles bottle processing.
 type EventProcessor struct{}
@@ -61,6 +61,7 @@ func (p *EventProcessor) Process(con *gorm.DB, base Base) error {
 		return err
 	}
 
+	// SQLite stores times as text (with their offset) so they are only ordered correctly when they are all in UTC
 	dbEvent := Event{
 		Base: base,
 
@@ -75,9 +76,25 @@ func (p *EventProcessor) Process(con *gorm.DB, base Base) error {
 		Tag:          eventDto.Tag,
 		AuthRequired: eventDto.AuthRequired,
 		Bandwidth:    eventDto.Bandwidth,
-		Timestamp:    eventDto.Timestamp,
+		Timestamp:    eventDto.Timestamp.UTC(),
 		Username:     eventDto.Username,
 	}
 
-	return con.Save(&dbEvent).Error
+	if err := con.Save(&dbEvent).Error; err != nil {
+		return err
+	}
+
+	// a push by tag moves the tag (a push by digest has no tag)
+	if dbEvent.Action != string(types.EventPush) || dbEvent.Tag == "" {
+		return nil
+	}
+	tagPush := TagPush{
+		Repository:     dbEvent.Repository,
+		Tag:            dbEvent.Tag,
+		ManifestDigest: dbEvent.ManifestDigest,
+		Timestamp:      dbEvent.Timestamp,
+	}
+	return con.Where(tagPush).
+		Attrs(TagPush{BottleID: dbEvent.Bottle.ID, BottleDigest: dbEvent.BottleDigest, Username: dbEvent.Username}).
+		FirstOrCreate(&tagPush).Error
 }
@@ -0,0 +1,357 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+// GCGracePeriod is how old unreferenced data must be before it is deleted.
+// Data is saved before the object that references it (in a separate transaction for single uploads) so new data is not garbage yet.
+const GCGracePeriod = time.Hour
+
+// gcBatchSize is the number of data rows deleted in each statement.
+const gcBatchSize = 500
+
+var (
+	gcRuns = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "telemetry_gc_runs_total",
+		Help: "Number of garbage collection runs that completed.",
+	})
+	gcEventsDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "telemetry_gc_events_deleted_total",
+		Help: "Number of expired events deleted by garbage collection.",
+	})
+	gcDataDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "telemetry_gc_data_deleted_total",
+		Help: "Number of unreferenced data rows deleted by garbage collection.",
+	})
+	gcBytesReclaimed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "telemetry_gc_reclaimed_bytes_total",
+		Help: "Size (uncompressed) of the data deleted by garbage collection.",
+	})
+	gcLastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "telemetry_gc_last_success_timestamp_seconds",
+		Help: "Time the last garbage collection run completed.",
+	})
+)
+
+// GCCollectors returns the Prometheus metrics of garbage collection.
+func GCCollectors() []prometheus.Collector {
+	return []prometheus.Collector{gcRuns, gcEventsDeleted, gcDataDeleted, gcBytesReclaimed, gcLastSuccess}
+}
+
+// GCOptions control garbage collection.
+type GCOptions struct {
+	// Events are the event retention rules
+	Events []v1alpha2.EventRetention
+
+	// DryRun reports what would be deleted without deleting anything
+	DryRun bool
+}
+
+// GCReport is what garbage collection deleted (or would delete for a dry run).
+type GCReport struct {
+	// Events is the number of expired events deleted
+	Events int64
+
+	// EventCounts is the number of event counts saved for expired events
+	EventCounts int64
+
+	// Pending is the number of expired pending objects deleted
+	Pending int64
+
+	// Data is the number of unreferenced data rows deleted
+	Data int64
+
+	// Digests is the number of digests (aliases) of the data deleted
+	Digests int64
+
+	// Bytes is the size (uncompressed) of the data deleted
+	Bytes int64
+
+	// Blobs is the number of blobs deleted that no data referenced (e.g., stored by uploads that were rolled back)
+	Blobs int64
+}
+
+// errDryRun rolls back the garbage collection transaction.
+var errDryRun = errors.New("dry run")
+
+// CollectGarbage deletes the expired events (per the retention rules), the expired pending objects, and the data that is no longer referenced.
+// Everything is done in one transaction (that is rolled back for a dry run).  Data in blob storage is deleted after the transaction commits.
+func CollectGarbage(ctx context.Context, con *gorm.DB, opts GCOptions) (GCReport, error) {
+	log := logger.FromContext(ctx).With("dryRun", opts.DryRun)
+	now := time.Now()
+
+	var report GCReport
+	var refs []string
+	err := con.Transaction(func(tx *gorm.DB) error {
+		for _, rule := range opts.Events {
+			if err := expireEvents(tx, rule, now, &report); err != nil {
+				return err
+			}
+		}
+
+		if err := expirePending(tx, now, &report); err != nil {
+			return err
+		}
+
+		var err error
+		refs, err = deleteUnreferencedData(tx, unreferencedData(tx).Where("created_at < ?", now.Add(-GCGracePeriod)), &report)
+		if err != nil {
+			return err
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return GCReport{}, fmt.Errorf("garbage collection: %w", err)
+	}
+
+	// the blobs can only be deleted once the rows referencing them are gone
+	if !opts.DryRun {
+		deleteFromBlobStore(ctx, con, refs)
+	}
+	if err := sweepBlobStore(ctx, con, now.Add(-GCGracePeriod), opts.DryRun, &report); err != nil {
+		// the orphaned blobs are unreachable so this only wastes space (until the next run)
+		log.ErrorContext(ctx, "Sweeping the blob store", "error", err)
+	}
+
+	log.InfoContext(ctx, "Garbage collection", "events", report.Events, "eventCounts", report.EventCounts,
+		"pending", report.Pending, "data", report.Data, "digests", report.Digests, "bytes", report.Bytes, "blobs", report.Blobs)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	gcRuns.Inc()
+	gcEventsDeleted.Add(float64(report.Events))
+	gcDataDeleted.Add(float64(report.Data))
+	gcBytesReclaimed.Add(float64(report.Bytes))
+	gcLastSuccess.SetToCurrentTime()
+	return report, nil
+}
+
+// expireEvents deletes the events that are older than allowed by the rule, saving their counts if requested.
+func expireEvents(tx *gorm.DB, rule v1alpha2.EventRetention, now time.Time, report *GCReport) error {
+	if rule.MaxAge.Duration <= 0 {
+		return fmt.Errorf("event retention rule for action %q must have a positive maxAge", rule.Action)
+	}
+
+	expired := func() *gorm.DB {
+		q := tx.Model(&Event{}).Where("timestamp < ?", now.Add(-rule.MaxAge.Duration))
+		if rule.Action != "" {
+			q = q.Where("action = ?", rule.Action)
+		}
+		return q
+	}
+
+	if rule.KeepCounts {
+		counts := []EventCount{}
+		if err := expired().
+			Select("bottle_id, bottle_digest, action, username, COUNT(*) AS count, SUM(bandwidth) AS bandwidth").
+			Group("bottle_id, bottle_digest, action, username").
+			Scan(&counts).Error; err != nil {
+			return fmt.Errorf("counting expired events: %w", err)
+		}
+		if len(counts) > 0 {
+			if err := tx.CreateInBatches(counts, gcBatchSize).Error; err != nil {
+				return fmt.Errorf("saving counts of expired events: %w", err)
+			}
+		}
+		report.EventCounts += int64(len(counts))
+	}
+
+	result := expired().Unscoped().Delete(&Event{})
+	if result.Error != nil {
+		return fmt.Errorf("deleting expired events: %w", result.Error)
+	}
+	report.Events += result.RowsAffected
+	return nil
+}
+
+// unreferencedData returns a query for the data that is not referenced by any object.
+func unreferencedData(tx *gorm.DB) *gorm.DB {
+	q := tx.Model(&Data{})
+	for _, table := range []string{"blobs", "bottles", "manifests", "events", "signatures", "public_artifacts", "pending_objects"} {
+		// soft deleted rows (e.g., public artifacts replaced by reprocessing) do not count as references
+		q = q.Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %[1]s WHERE %[1]s.data_id = data.id AND %[1]s.deleted_at IS NULL)", table))
+	}
+	return q
+}
+
+// deleteUnreferencedData deletes the data (and digests) selected by q (see unreferencedData).
+// It returns the blob storage references of the deleted data.
+func deleteUnreferencedData(tx, q *gorm.DB, report *GCReport) ([]string, error) {
+	type unreferenced struct {
+		ID         uint
+		StorageRef string
+		Size       int64
+	}
+	rows := []unreferenced{}
+	if err := q.Select("id", "storage_ref", "size").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("finding unreferenced data: %w", err)
+	}
+
+	refs := []string{}
+	for start := 0; start < len(rows); start += gcBatchSize {
+		batch := rows[start:min(start+gcBatchSize, len(rows))]
+		ids := make([]uint, len(batch))
+		for i, row := range batch {
+			ids[i] = row.ID
+			report.Bytes += row.Size
+			if row.StorageRef != "" {
+				refs = append(refs, row.StorageRef)
+			}
+		}
+
+		result := tx.Unscoped().Where("data_id IN ?", ids).Delete(&Digest{})
+		if result.Error != nil {
+			return nil, fmt.Errorf("deleting digests of unreferenced data: %w", result.Error)
+		}
+		report.Digests += result.RowsAffected
+
+		result = tx.Unscoped().Where("id IN ?", ids).Delete(&Data{})
+		if result.Error != nil {
+			return nil, fmt.Errorf("deleting unreferenced data: %w", result.Error)
+		}
+		report.Data += result.RowsAffected
+	}
+	return refs, nil
+}
+
+// deleteFromBlobStore deletes the blobs of data that was deleted from the database.
+// Blobs are content-addressable so the same content uploaded again (since the data was deleted) has the same reference.
+// Those blobs are referenced again so they are kept.
+func deleteFromBlobStore(ctx context.Context, con *gorm.DB, refs []string) {
+	store := BlobStore(con)
+	if store == nil {
+		return
+	}
+	log := logger.FromContext(ctx)
+	for start := 0; start < len(refs); start += gcBatchSize {
+		batch := refs[start:min(start+gcBatchSize, len(refs))]
+		referenced, err := referencedRefs(con, batch)
+		if err != nil {
+			// keeping the blobs only wastes space (they are swept by a later run)
+			log.ErrorContext(ctx, "Checking for re-uploaded blobs", "error", err)
+			continue
+		}
+		for _, ref := range batch {
+			if referenced[ref] {
+				log.InfoContext(ctx, "Keeping re-uploaded blob", "ref", ref)
+				continue
+			}
+			if err := store.Delete(ctx, ref); err != nil {
+				// the blob is unreachable so this only wastes space
+				log.ErrorContext(ctx, "Deleting unreferenced blob", "ref", ref, "error", err)
+			}
+		}
+	}
+}
+
+// sweepBlobStore deletes the blobs modified before the cutoff that no data references.
+// Blobs are put in the store before the data referencing them is committed so a rolled back upload leaves its blob behind.
+func sweepBlobStore(ctx context.Context, con *gorm.DB, cutoff time.Time, dryRun bool, report *GCReport) error {
+	store := BlobStore(con)
+	if store == nil {
+		return nil
+	}
+
+	sweep := func(refs []string) error {
+		referenced, err := referencedRefs(con, refs)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if referenced[ref] {
+				continue
+			}
+			report.Blobs++
+			if dryRun {
+				continue
+			}
+			if err := store.Delete(ctx, ref); err != nil {
+				logger.FromContext(ctx).ErrorContext(ctx, "Deleting orphaned blob", "ref", ref, "error", err)
+			}
+		}
+		return nil
+	}
+
+	// the refs are checked in batches so there is one query per batch instead of per blob
+	batch := []string{}
+	if err := store.Walk(ctx, func(ref string, modified time.Time) error {
+		if !modified.Before(cutoff) {
+			return nil
+		}
+		batch = append(batch, ref)
+		if len(batch) < gcBatchSize {
+			return nil
+		}
+		err := sweep(batch)
+		batch = []string{}
+		return err
+	}); err != nil {
+		return fmt.Errorf("walking the blob store: %w", err)
+	}
+	return sweep(batch)
+}
+
+// referencedRefs returns the blob storage references in refs that data (even soft deleted) still references.
+func referencedRefs(con *gorm.DB, refs []string) (map[string]bool, error) {
+	referenced := map[string]bool{}
+	if len(refs) == 0 {
+		return referenced, nil
+	}
+	found := []string{}
+	if err := con.Unscoped().Model(&Data{}).Where("storage_ref IN ?", refs).Pluck("storage_ref", &found).Error; err != nil {
+		return nil, fmt.Errorf("finding referenced blobs: %w", err)
+	}
+	for _, ref := range found {
+		referenced[ref] = true
+	}
+	return referenced, nil
+}
+
+// RunGarbageCollector runs garbage collection at the interval until the context is canceled.
+// Only one replica (the leader) collects garbage at a time.
+func RunGarbageCollector(ctx context.Context, con *gorm.DB, conf v1alpha2.Retention) {
+	if conf.Interval.Duration <= 0 {
+		return
+	}
+	log := logger.FromContext(ctx)
+	con = con.WithContext(ctx)
+
+	ticker := time.NewTicker(conf.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		isLeader, err := RunAsLeader(ctx, con, GCTask, func(con *gorm.DB) error {
+			_, err := CollectGarbage(ctx, con, GCOptions{Events: conf.Events})
+			return err
+		})
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			log.ErrorContext(ctx, "Garbage collection failed", "error", err)
+		case !isLeader:
+			log.InfoContext(ctx, "Garbage collection is being done by another replica")
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
+	"github.com/act3-ai/go-common/pkg/redact"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+type GCTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	con    *gorm.DB
+	blobs  string
+	bottle digest.Digest
+}
+
+func (s *GCTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	scheme := runtime.NewScheme()
+	s.NoError(bottle.AddToScheme(scheme))
+
+	dir := s.T().TempDir()
+	con, err := Open(s.ctx, v1alpha2.Database{
+		DSN:     redact.SecretURL("file:" + filepath.Join(dir, "test.db")),
+		Storage: v1alpha2.BlobStorage{Type: "filesystem", Path: filepath.Join(dir, "blobs")},
+	}, scheme)
+	s.Require().NoError(err)
+	s.T().Cleanup(func() {
+		sqlDB, err := con.DB()
+		s.NoError(err)
+		s.NoError(sqlDB.Close())
+	})
+	s.con = con
+	s.blobs = filepath.Join(dir, "blobs")
+	s.bottle = digest.FromString("bottle")
+}
+
+// createEvent adds an event that happened age ago.
+func (s *GCTestSuite) createEvent(action, username string, age time.Duration) {
+	raw := []byte(fmt.Sprintf(`{"action":%q,"username":%q,"age":%q}`, action, username, age))
+	s.Require().NoError(s.con.Create(&Event{
+		Base: Base{
+			ProcessorVersion: EventProcessorVersion,
+			Data:             Data{RawData: raw, CanonicalDigest: CanonicalDigestAlgorithm.FromBytes(raw)},
+		},
+		BottleDigest: s.bottle,
+		Action:       action,
+		Username:     username,
+		Bandwidth:    100,
+		Timestamp:    time.Now().Add(-age),
+	}).Error)
+}
+
+// createData adds data that is not referenced by anything and returns its storage reference.
+func (s *GCTestSuite) createData(raw string) string {
+	data := Data{RawData: []byte(raw), CanonicalDigest: CanonicalDigestAlgorithm.FromString(raw)}
+	s.Require().NoError(s.con.Create(&data).Error)
+	s.Require().NoError(s.con.Create(&Digest{DataID: data.ID, Digest: digest.SHA512.FromString(raw)}).Error)
+	return data.StorageRef
+}
+
+// age makes all the data older than the grace period.
+func (s *GCTestSuite) age() {
+	s.Require().NoError(s.con.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(&Data{}).
+		UpdateColumn("created_at", time.Now().Add(-2*GCGracePeriod)).Error)
+}
+
+func (s *GCTestSuite) count(model any) int64 {
+	var n int64
+	s.Require().NoError(s.con.Model(model).Count(&n).Error)
+	return n
+}
+
+func (s *GCTestSuite) TestExpireEvents() {
+	day := 24 * time.Hour
+	s.createEvent("pull", "alice", 500*day)
+	s.createEvent("pull", "alice", 450*day)
+	s.createEvent("pull", "bob", 10*day)
+	s.createEvent("push", "alice", 500*day)
+	s.createEvent("push", "bob", 100*day)
+	s.Equal(int64(3), BottlePulls(s.con, s.bottle))
+	s.age()
+
+	report, err := CollectGarbage(s.ctx, s.con, GCOptions{Events: []v1alpha2.EventRetention{
+		{Action: "pull", MaxAge: metav1.Duration{Duration: 400 * day}, KeepCounts: true},
+		{MaxAge: metav1.Duration{Duration: 30 * day}},
+	}})
+	s.Require().NoError(err)
+	s.Equal(GCReport{Events: 4, EventCounts: 1, Data: 4}, GCReport{Events: report.Events, EventCounts: report.EventCounts, Data: report.Data})
+
+	// the expired pulls are still counted
+	s.Equal(int64(1), s.count(&Event{}))
+	s.Equal(int64(3), BottlePulls(s.con, s.bottle))
+	pulls, err := UserPulls(s.con, s.bottle, 10)
+	s.NoError(err)
+	s.Equal(map[string]int{"alice": 2, "bob": 1}, pulls)
+
+	// and transferred (the bottle has no parts so no bytes)
+	stats, err := TransferStats(s.con, types.TransferQuery{EventFilter: types.EventFilter{Action: "pull"}})
+	s.Require().NoError(err)
+	s.Equal([]types.TransferTotal{{Name: string(s.bottle), Events: 3}}, stats.Bottles)
+	s.Equal([]types.TransferTotal{{Name: "alice", Events: 2}, {Name: "bob", Events: 1}}, stats.Users)
+	s.Equal([]types.TransferTotal{{Name: "", Events: 1}}, stats.Repositories)
+
+	// but not when there is a time range (their times are unknown)
+	stats, err = TransferStats(s.con, types.TransferQuery{EventFilter: types.EventFilter{Action: "pull", Start: time.Now().Add(-1000 * day)}})
+	s.Require().NoError(err)
+	s.Equal([]types.TransferTotal{{Name: "bob", Events: 1}}, stats.Users)
+
+	var counts []EventCount
+	s.NoError(s.con.Find(&counts).Error)
+	s.Require().Len(counts, 1)
+	s.Equal(uint64(200), counts[0].Bandwidth)
+}
+
+func (s *GCTestSuite) TestUnreferencedData() {
+	s.createEvent("pull", "alice", time.Hour)
+	ref := s.createData("orphan")
+	s.age()
+	recent := s.createData("recent")
+
+	report, err := CollectGarbage(s.ctx, s.con, GCOptions{})
+	s.Require().NoError(err)
+	s.Equal(GCReport{Data: 1, Digests: 1, Bytes: int64(len("orphan"))}, report)
+
+	// the event data and the recent data (in the grace period) are kept
+	s.Equal(int64(2), s.count(&Data{}))
+	_, err = BlobStore(s.con).Get(s.ctx, ref)
+	s.Error(err)
+	_, err = BlobStore(s.con).Get(s.ctx, recent)
+	s.NoError(err)
+}
+
+func (s *GCTestSuite) TestDryRun() {
+	s.createEvent("pull", "alice", 100*time.Hour)
+	ref := s.createData("orphan")
+	s.age()
+
+	opts := GCOptions{Events: []v1alpha2.EventRetention{{MaxAge: metav1.Duration{Duration: time.Hour}, KeepCounts: true}}, DryRun: true}
+	report, err := CollectGarbage(s.ctx, s.con, opts)
+	s.Require().NoError(err)
+	s.Equal(int64(1), report.Events)
+	s.Equal(int64(2), report.Data)
+
+	// nothing changed
+	s.Equal(int64(1), s.count(&Event{}))
+	s.Equal(int64(0), s.count(&EventCount{}))
+	s.Equal(int64(2), s.count(&Data{}))
+	_, err = BlobStore(s.con).Get(s.ctx, ref)
+	s.NoError(err)
+
+	opts.DryRun = false
+	again, err := CollectGarbage(s.ctx, s.con, opts)
+	s.Require().NoError(err)
+	s.Equal(report, again)
+	s.Equal(int64(0), s.count(&Data{}))
+}
+
+func (s *GCTestSuite) TestReuploadedBlob() {
+	// the data was uploaded again after garbage collection deleted it from the database (but before the blob was deleted)
+	reuploaded := s.createData("reuploaded")
+	deleted, err := BlobStore(s.con).Put(s.ctx, digest.FromString("deleted"), []byte("deleted"))
+	s.Require().NoError(err)
+
+	deleteFromBlobStore(s.ctx, s.con, []string{reuploaded, deleted})
+	_, err = BlobStore(s.con).Get(s.ctx, reuploaded)
+	s.NoError(err)
+	_, err = BlobStore(s.con).Get(s.ctx, deleted)
+	s.Error(err)
+}
+
+func (s *GCTestSuite) TestOrphanedBlobs() {
+	store := BlobStore(s.con)
+	makeOld := func(raw string) {
+		dgst := digest.FromString(raw)
+		modified := time.Now().Add(-2 * GCGracePeriod)
+		s.Require().NoError(os.Chtimes(filepath.Join(s.blobs, "sha256", dgst.Encoded()[:2], dgst.Encoded()), modified, modified))
+	}
+	// blobs of rolled back uploads
+	orphan, err := store.Put(s.ctx, digest.FromString("orphan"), []byte("orphan"))
+	s.Require().NoError(err)
+	makeOld("orphan")
+	recent, err := store.Put(s.ctx, digest.FromString("recent"), []byte("recent"))
+	s.Require().NoError(err)
+	// an old blob that data references
+	referenced := s.createData("referenced")
+	makeOld("referenced")
+
+	report, err := CollectGarbage(s.ctx, s.con, GCOptions{DryRun: true})
+	s.Require().NoError(err)
+	s.Equal(GCReport{Blobs: 1}, report)
+	_, err = store.Get(s.ctx, orphan)
+	s.NoError(err)
+
+	report, err = CollectGarbage(s.ctx, s.con, GCOptions{})
+	s.Require().NoError(err)
+	s.Equal(GCReport{Blobs: 1}, report)
+	_, err = store.Get(s.ctx, orphan)
+	s.Error(err)
+	_, err = store.Get(s.ctx, recent)
+	s.NoError(err)
+	_, err = store.Get(s.ctx, referenced)
+	s.NoError(err)
+}
+
+func (s *GCTestSuite) TestExpirePending() {
+	s.createData("waiting")
+	s.createData("expired")
+	var data []Data
+	s.Require().NoError(s.con.Order("id").Find(&data).Error)
+	_, err := AddPending(s.con, "event", data[0].ID, digest.FromString("waiting"), []digest.Digest{digest.FromString("manifest")}, time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+	_, err = AddPending(s.con, "event", data[1].ID, digest.FromString("expired"), []digest.Digest{digest.FromString("manifest")}, time.Now().Add(-time.Hour))
+	s.Require().NoError(err)
+	s.age()
+
+	report, err := CollectGarbage(s.ctx, s.con, GCOptions{})
+	s.Require().NoError(err)
+	s.Equal(GCReport{Pending: 1, Data: 1, Digests: 1, Bytes: int64(len("expired"))}, report)
+
+	// the data of the waiting object is kept
+	s.Equal(int64(1), s.count(&PendingObject{}))
+	s.Equal(int64(1), s.count(&PendingDependency{}))
+	s.Equal(int64(1), s.count(&Data{}))
+}
+
+func (s *GCTestSuite) TestInvalidRule() {
+	_, err := CollectGarbage(s.ctx, s.con, GCOptions{Events: []v1alpha2.EventRetention{{Action: "pull"}}})
+	s.Error(err)
+}
+
+func TestGCTestSuite(t *testing.T) {
+	suite.Run(t, new(GCTestSuite))
+}
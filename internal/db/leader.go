@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/logger"
+)
+
+// LeaderTask identifies work that only one replica (of the many that may share a database) is allowed to do at a time.
+// The value is used as the Postgres advisory lock key.
+type LeaderTask int64
+
+// Tasks that require leadership.
+const (
+	// MigrationTask is the schema migration (and reprocessing when not done in the background) done when opening the database.
+	MigrationTask LeaderTask = 0x74656c656d0001
+
+	// ReprocessTask is reprocessing of out of date rows.
+	ReprocessTask LeaderTask = 0x74656c656d0002
+
+	// CompressionTask is recompression of data saved in the database with the configured codec.
+	CompressionTask LeaderTask = 0x74656c656d0003
+
+	// GCTask is garbage collection of expired events and unreferenced data.
+	GCTask LeaderTask = 0x74656c656d0004
+)
+
+// String implements fmt.Stringer.
+func (t LeaderTask) String() string {
+	switch t {
+	case MigrationTask:
+		return "migration"
+	case ReprocessTask:
+		return "reprocess"
+	case CompressionTask:
+		return "compression"
+	case GCTask:
+		return "gc"
+	default:
+		return fmt.Sprintf("task-%x", int64(t))
+	}
+}
+
+// RunAsLeader runs fn if this replica becomes the leader for the task.
+// Leadership is held (with a Postgres session level advisory lock) until fn returns.
+// It returns false without running fn when another replica is the leader for the task.
+// SQLite databases are not shared so we are always the leader.
+func RunAsLeader(ctx context.Context, con *gorm.DB, task LeaderTask, fn func(con *gorm.DB) error) (bool, error) {
+	log := logger.FromContext(ctx).With("task", task)
+
+	if con.Name() != "postgres" {
+		return true, fn(con)
+	}
+
+	sqlDB, err := con.DB()
+	if err != nil {
+		return false, fmt.Errorf("getting database for leader election: %w", err)
+	}
+
+	// advisory locks belong to the session so we need to hold onto a single connection
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("getting connection for leader election: %w", err)
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", int64(task)).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("acquiring %s leadership: %w", task, err)
+	}
+	if !acquired {
+		log.InfoContext(ctx, "Another replica is the leader")
+		return false, nil
+	}
+	log.InfoContext(ctx, "Elected leader")
+
+	defer func() {
+		// use a fresh context so we release the lock even when ctx is canceled
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", int64(task)); err != nil {
+			log.ErrorContext(ctx, "Failed to release leadership", "error", err)
+		}
+	}()
+
+	return true, fn(con)
+}
+
+// WaitForLeader blocks until the current leader (if any) for the task is done.
+func WaitForLeader(ctx context.Context, con *gorm.DB, task LeaderTask) error {
+	if con.Name() != "postgres" {
+		return nil
+	}
+
+	log := logger.FromContext(ctx).With("task", task)
+	log.InfoContext(ctx, "Waiting for the leader")
+
+	sqlDB, err := con.DB()
+	if err != nil {
+		return fmt.Errorf("getting database to wait for leader: %w", err)
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("getting connection to wait for leader: %w", err)
+	}
+	defer conn.Close()
+
+	// a shared lock can only be acquired once the leader releases the exclusive lock
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock_shared($1)", int64(task)); err != nil {
+		return fmt.Errorf("waiting for %s leader: %w", task, err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock_shared($1)", int64(task)); err != nil {
+		return fmt.Errorf("releasing shared %s lock: %w", task, err)
+	}
+
+	log.InfoContext(ctx, "Leader is done")
+	return nil
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// lineageGraph accumulates the nodes and edges of a lineage.
+type lineageGraph struct {
+	types.Lineage
+
+	// ids are the node IDs of the digests of the bottles in the graph
+	ids map[digest.Digest]string
+
+	// nodes are the IDs of all the nodes in the graph
+	nodes map[string]bool
+
+	// edges are the edges in the graph (by from, to, and source name)
+	edges map[[3]string]bool
+}
+
+func (g *lineageGraph) addNode(node types.LineageNode) {
+	g.nodes[node.ID] = true
+	g.Nodes = append(g.Nodes, node)
+}
+
+// addBottle adds a node for the bottle (known to the telemetry server) if it is not already in the graph.
+// It returns the ID of the node.
+func (g *lineageGraph) addBottle(b BottleRelative, generation int) string {
+	for _, d := range b.Digests {
+		if id, ok := g.ids[d]; ok {
+			return id
+		}
+	}
+
+	id := b.Digests[0].String()
+	labels := make(map[string]string, len(b.Labels))
+	for _, l := range b.Labels {
+		labels[l.Key] = l.Value
+	}
+	var authors []types.Author
+	for _, a := range b.Authors {
+		authors = append(authors, types.Author{Name: a.Name, Email: a.Email, URL: a.URL})
+	}
+	g.addNode(types.LineageNode{
+		ID:          id,
+		Kind:        types.LineageNodeBottle,
+		Generation:  generation,
+		Digests:     b.Digests,
+		Description: b.Description,
+		Labels:      labels,
+		Authors:     authors,
+	})
+	for _, d := range b.Digests {
+		g.ids[d] = id
+	}
+	return id
+}
+
+// addSource adds an edge from the source to the bottle with the node ID "to".
+// If the source is not in the graph then a node is added for it when addMissing is true, otherwise the edge is dropped.
+func (g *lineageGraph) addSource(to string, source Source, generation int, addMissing bool) {
+	from, kind := source.URI, types.LineageNodeURI
+	if len(source.BottleDigest) != 0 {
+		from, kind = source.BottleDigest.String(), types.LineageNodeUnknownBottle
+		if id, ok := g.ids[source.BottleDigest]; ok {
+			from = id
+		}
+	}
+
+	if !g.nodes[from] {
+		if !addMissing {
+			return
+		}
+		g.addNode(types.LineageNode{ID: from, Kind: kind, Generation: generation})
+	}
+
+	key := [3]string{from, to, source.Name}
+	if g.edges[key] {
+		return
+	}
+	g.edges[key] = true
+
+	var partSelectors []string
+	for _, s := range source.PartSelectors {
+		partSelectors = append(partSelectors, s.String())
+	}
+	g.Edges = append(g.Edges, types.LineageEdge{
+		From:          from,
+		To:            to,
+		Name:          source.Name,
+		PartSelectors: partSelectors,
+	})
+}
+
+// GetLineage returns the graph of the ancestors (up to numGenAncestors generations) and descendants (up to numGenDescendants generations) of the bottle.
+// The sources of the bottle and its ancestors are all included, even if they are not bottles or the bottles are not known to the telemetry server.
+// The descendants are only linked to the bottles in the graph (their other sources are omitted).
+// gorm.ErrRecordNotFound is returned if the bottle is not known.
+func GetLineage(con *gorm.DB, bottleDigest digest.Digest, numGenAncestors, numGenDescendants uint) (*types.Lineage, error) {
+	root := BottleRelative{}
+	if err := con.Table("bottles").
+		Select("bottles.*").
+		Preload("Labels").
+		Preload("Authors").
+		Preload("Sources").
+		Scopes(IncludeDigests("bottles"), FilterByDigest(bottleDigest, "bottles")).
+		First(&root).Error; err != nil {
+		return nil, err
+	}
+
+	ancestors, err := GetAncestors(con, bottleDigest, numGenAncestors)
+	if err != nil {
+		return nil, err
+	}
+
+	descendants, err := GetDescendants(con, bottleDigest, numGenDescendants)
+	if err != nil {
+		return nil, err
+	}
+
+	g := lineageGraph{
+		Lineage: types.Lineage{
+			Digest: bottleDigest,
+			Nodes:  []types.LineageNode{},
+			Edges:  []types.LineageEdge{},
+		},
+		ids:   map[digest.Digest]string{},
+		nodes: map[string]bool{},
+		edges: map[[3]string]bool{},
+	}
+	// the bottle is identified by the requested digest
+	for i, d := range root.Digests {
+		if d == bottleDigest {
+			root.Digests[0], root.Digests[i] = root.Digests[i], root.Digests[0]
+		}
+	}
+	g.addBottle(root, 0)
+
+	// the sources of each generation are the next generation of ancestors
+	children := Generation{root}
+	for i, gen := range ancestors {
+		generation := -(i + 1)
+		for _, b := range gen {
+			g.addBottle(b, generation)
+		}
+		for _, child := range children {
+			to := g.ids[child.Digests[0]]
+			for _, source := range child.Sources {
+				g.addSource(to, source, generation, true)
+			}
+		}
+		children = gen
+	}
+
+	for i, gen := range descendants {
+		for _, b := range gen {
+			to := g.addBottle(b, i+1)
+			for _, source := range b.Sources {
+				g.addSource(to, source, 0, false)
+			}
+		}
+	}
+
+	return &g.Lineage, nil
+}
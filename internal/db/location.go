@@ -0,0 +1,130 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern (with the escape character "\").
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ValidateLocationQuery validates the bottle digest and registry of the query.
+func ValidateLocationQuery(q types.LocationQuery) error {
+	multiError := q.BottleDigest.Validate()
+	if multiError != nil {
+		multiError = fmt.Errorf("invalid param \"bottle_digest\": %w", multiError)
+	}
+	if strings.ContainsAny(q.Registry, "/?#@ ") {
+		multiError = errors.Join(multiError, fmt.Errorf("invalid param \"registry\" (%q): must be a host (e.g., \"reg.example.com:5000\")", q.Registry))
+	}
+	return multiError
+}
+
+// FindLocations returns the locations (repository and manifest) of a bottle that match the query,
+// ranked by the most recent pull or push (most recent first).
+// The query must be valid (see ValidateLocationQuery).
+func FindLocations(con *gorm.DB, query types.LocationQuery) ([]types.LocationResponse, error) {
+	// filterLocations selects the events of the locations
+	filterLocations := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.
+			Joins("INNER JOIN bottles ON events.bottle_id = bottles.id").
+			Scopes(FilterByDigest(query.BottleDigest, "bottles"))
+		if query.Registry != "" {
+			tx = tx.Where(`events.repository LIKE ? ESCAPE '\'`, likeEscaper.Replace(query.Registry)+"/%")
+		}
+		if query.Public {
+			tx = tx.Where("events.auth_required = ?", false)
+		}
+		return tx
+	}
+
+	type location struct {
+		Repository   string
+		AuthRequired bool
+		Digest       digest.Digest
+		ManifestID   uint
+		LastSeen     time.Time
+	}
+
+	// the most recent event of each location
+	latest := con.Model(&Event{}).
+		Scopes(filterLocations).
+		Select(
+			"events.repository",
+			"events.auth_required",
+			"events.manifest_digest AS digest",
+			"events.manifest_id",
+			"events.timestamp AS last_seen",
+			"ROW_NUMBER() OVER (PARTITION BY events.repository, events.auth_required, events.manifest_digest ORDER BY events.timestamp DESC, events.id DESC) AS rank",
+		)
+
+	var locations []location
+	if err := con.
+		Table("(?) AS l", latest).
+		Select("l.repository", "l.auth_required", "l.digest", "l.manifest_id", "l.last_seen").
+		Where("l.rank = 1").
+		Order("l.last_seen DESC, l.repository, l.digest").
+		Scan(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	type tag struct {
+		Repository string
+		Digest     digest.Digest
+		Tag        string
+	}
+
+	var tags []tag
+	if err := con.Model(&Event{}).
+		Scopes(filterLocations).
+		Where("events.tag <> ''").
+		Distinct("events.repository AS repository", "events.manifest_digest AS digest", "events.tag AS tag").
+		Order("tag").
+		Scan(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	manifestIDs := make([]uint, len(locations))
+	for i, l := range locations {
+		manifestIDs[i] = l.ManifestID
+	}
+	var layers []Layer
+	if err := con.Where("manifest_id IN ?", manifestIDs).Order("location").Find(&layers).Error; err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		repository string
+		digest     digest.Digest
+	}
+	tagsByLocation := map[key][]string{}
+	for _, t := range tags {
+		k := key{t.Repository, t.Digest}
+		tagsByLocation[k] = append(tagsByLocation[k], t.Tag)
+	}
+	layersByManifest := map[uint][]ocispec.Descriptor{}
+	for _, l := range layers {
+		layersByManifest[l.ManifestID] = append(layersByManifest[l.ManifestID], l.Descriptor())
+	}
+
+	results := make([]types.LocationResponse, len(locations))
+	for i, l := range locations {
+		results[i] = types.LocationResponse{
+			Repository:   l.Repository,
+			AuthRequired: l.AuthRequired,
+			Digest:       l.Digest,
+			LastSeen:     l.LastSeen,
+			Tags:         tagsByLocation[key{l.Repository, l.Digest}],
+			Layers:       layersByManifest[l.ManifestID],
+		}
+	}
+	return results, nil
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gorm.io/gorm"
 
+	"github.com/act3-ai/bottle-schema/pkg/mediatype"
 	"github.com/act3-ai/bottle-schema/pkg/validation"
 	"github.com/act3-ai/go-common/pkg/httputil"
 
@@ -17,7 +19,7 @@ import (
 )
 
 // ManifestProcessorVersion is the current version of the processor code.  This is incremented after each measurable change to the ManifestProcessor().
-const ManifestProcessorVersion = 4
+const ManifestProcessorVersion = 5
 
 // ManifestProcessor handles bottle processing.
 type ManifestProcessor struct{}
@@ -63,6 +65,12 @@ func (p *ManifestProcessor) Process(con *gorm.DB, base Base) error {
 	if err := validateManifestAgainstBottle(manifest, bottle); err != nil {
 		return httputil.NewHTTPError(err, http.StatusBadRequest, "Manifest is not compatible with the referenced bottle: "+err.Error(), "manifest", manifest, "bottle", bottleDigest)
 	}
+	// manifests stored before the layers were checked are kept (so reprocessing them does not fail)
+	if base.ID == 0 {
+		if err := validateLayersAgainstParts(manifest, bottle); err != nil {
+			return httputil.NewHTTPError(err, http.StatusBadRequest, "Manifest is not compatible with the referenced bottle: "+err.Error(), "manifest", manifest, "bottle", bottleDigest)
+		}
+	}
 
 	dbManifest := Manifest{
 		Base:         base,
@@ -87,15 +95,40 @@ func validateManifestAgainstBottle(m ocispec.Manifest, b Bottle) error {
 	if nl != np {
 		return fmt.Errorf("there are %d layers but %d parts (they must be equal)", nl, np)
 	}
-	// TODO add validation of the media type of the descriptor matches the part type (file or directory)
-	// We need to add a "type" field to the schema first. This would hep avoid a simple attack on the integrity of the bottle (replacing a directory part with a file part of the same digest, the .tar file itself)
-	// TODO call schema's manifest validator
 	return nil
 }
 
+// validateLayersAgainstParts validates the media type and size of each layer against its part.
+// The number of layers must already be validated (see validateManifestAgainstBottle).
+func validateLayersAgainstParts(m ocispec.Manifest, b Bottle) error {
+	nl := len(m.Layers)
+
+	// The layer media type must match the part type (file or directory).
+	// Otherwise a directory part could be replaced by a file part of the same digest (the .tar file itself).
+	// Older bottles archive file parts as well so only directory parts (with a trailing slash) are checked.
+	// The layer media types are known (see validation.ValidateManifest).
+	var multiError error
+	for _, p := range b.Parts {
+		if int(p.Location) >= nl {
+			continue
+		}
+		l := m.Layers[p.Location]
+		if strings.HasSuffix(p.Name, "/") && !mediatype.IsArchived(l.MediaType) {
+			multiError = errors.Join(multiError, fmt.Errorf("layer %d has media type %q but part %q is a directory (it must be archived)", p.Location, l.MediaType, p.Name))
+		}
+		if mediatype.IsRaw(l.MediaType) && uint64(l.Size) != p.Size {
+			multiError = errors.Join(multiError, fmt.Errorf("layer %d is raw with %d bytes but part %q has %d bytes (they must be equal)", p.Location, l.Size, p.Name, p.Size))
+		}
+	}
+	return multiError
+}
+
 func convertLayer(old Layer, i int, l ocispec.Descriptor) (*Layer, error) {
 	layer := Layer{
-		Digest: l.Digest,
+		MediaType:   l.MediaType,
+		Digest:      l.Digest,
+		Size:        l.Size,
+		Annotations: l.Annotations,
 	}
 	layer.ID = old.ID
 	layer.Location = uint(i)
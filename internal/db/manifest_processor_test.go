@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/act3-ai/bottle-schema/pkg/mediatype"
+)
+
+func TestValidateManifestAgainstBottle(t *testing.T) {
+	part := func(location uint, name string, size uint64) Part {
+		p := Part{Name: name, Size: size, Digest: digest.FromString(name)}
+		p.Location = location
+		return p
+	}
+	bottle := Bottle{Parts: []Part{
+		part(0, "file.txt", 10),
+		part(1, "dir/", 100),
+	}}
+	manifest := func(layers ...ocispec.Descriptor) ocispec.Manifest {
+		return ocispec.Manifest{Layers: layers}
+	}
+	layer := func(mediaType string, size int64) ocispec.Descriptor {
+		return ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromString(mediaType), Size: size}
+	}
+
+	tests := []struct {
+		name     string
+		manifest ocispec.Manifest
+		wantErr  string
+	}{
+		{
+			name:     "valid",
+			manifest: manifest(layer(mediatype.MediaTypeLayer, 10), layer(mediatype.MediaTypeLayerTarZstd, 50)),
+		},
+		{
+			// older bottles archive files
+			name:     "archived file",
+			manifest: manifest(layer(mediatype.MediaTypeLayerTarGzip, 7), layer(mediatype.MediaTypeLayerTar, 100)),
+		},
+		{
+			name:     "missing layer",
+			manifest: manifest(layer(mediatype.MediaTypeLayer, 10)),
+			wantErr:  "there are 1 layers but 2 parts",
+		},
+		{
+			name:     "directory not archived",
+			manifest: manifest(layer(mediatype.MediaTypeLayer, 10), layer(mediatype.MediaTypeLayerZstd, 50)),
+			wantErr:  `part "dir/" is a directory`,
+		},
+		{
+			name:     "raw size mismatch",
+			manifest: manifest(layer(mediatype.MediaTypeLayer, 11), layer(mediatype.MediaTypeLayerTar, 100)),
+			wantErr:  `layer 0 is raw with 11 bytes but part "file.txt" has 10 bytes`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateManifestAgainstBottle(tt.manifest, bottle)
+			if err == nil {
+				err = validateLayersAgainstParts(tt.manifest, bottle)
+			}
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"net/url"
@@ -8,11 +9,15 @@ import (
 	"time"
 
 	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gorm.io/gorm"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/act3-ai/bottle-schema/pkg/selectors"
 	"github.com/act3-ai/bottle-schema/pkg/util"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/selector"
+	"github.com/act3-ai/data-telemetry/v3/internal/storage"
 )
 
 // Model is the base of all records.
@@ -39,9 +44,16 @@ type Base struct {
 }
 
 // Data stores the actual data for all types (blob, bottles, manifests, events).
+// When blob storage is configured the raw data is kept in the blob store and only the reference to it is saved in the database.
+// Otherwise the raw data is saved in the database, compressed with the configured codec.
+// RawData is populated when the record is found (unless found with SkipDecompression) so callers do not need to know where or how it is stored.
 type Data struct {
 	Model
-	RawData         []byte
+	RawData         []byte        `gorm:"-"`                        // the uncompressed data
+	StoredData      []byte        `gorm:"column:raw_data" json:"-"` // the data as saved in the database (compressed with Codec), nil when it is in the blob store
+	Codec           storage.Codec // compression of StoredData
+	StorageRef      string        // reference to the raw data in the blob store, empty when the raw data is in the database
+	Size            int64         // size of the raw data in bytes
 	CanonicalDigest digest.Digest `gorm:"uniqueIndex"` // canonical digest is an internal only digest (like blake2 or sha3 used for de-duplication)
 }
 
@@ -191,6 +203,7 @@ type Label struct {
 	Key          string  `gorm:"index"` // unique per bottle
 	Value        string  `gorm:"index"`
 	NumericValue float64 `gorm:"index" json:"-"` // We drop this is JSON marshalling to avoid issues with logging
+	VersionValue string  `gorm:"index" json:"-"` // sortable form of a semantic version value (see selector.VersionKey), empty otherwise
 }
 
 // GetLocation gets the index (key of the label).
@@ -198,13 +211,14 @@ func (l Label) GetLocation() string {
 	return l.Key
 }
 
-// BeforeSave is called before the struct is saved to the DB to convert the value to a float if possible.
+// BeforeSave is called before the struct is saved to the DB to convert the value to a float and a semantic version if possible.
 func (l *Label) BeforeSave(tx *gorm.DB) error {
 	val, err := strconv.ParseFloat(l.Value, 64)
 	if err != nil {
 		val = math.NaN()
 	}
 	l.NumericValue = val
+	l.VersionValue, _ = selector.VersionKey(l.Value)
 	return nil
 }
 
@@ -251,10 +265,15 @@ type Bottle struct {
 type Layer struct {
 	Model
 	ManifestID uint
-	Location   uint
+	Location   uint // the location of the part of the bottle
+
+	MediaType string        `gorm:"index"`
+	Digest    digest.Digest `gorm:"index"` // This is not tracked by the telemetry server so it is just a string (not a reference to a Digest record)
+	Size      int64         // compressed size (as stored in the registry)
 
-	Digest digest.Digest `gorm:"index"` // This is not tracked by the telemetry server so it is just a string (not a reference to a Digest record)
-	// TODO: Add other OCI descriptor fields
+	// AnnotationsStr is stored in the DB but code is expected to use Annotations directly.
+	AnnotationsStr string            `json:"-"`
+	Annotations    map[string]string `gorm:"-"`
 }
 
 // GetLocation gets the index (key of the annotation).
@@ -262,6 +281,39 @@ func (l Layer) GetLocation() uint {
 	return l.Location
 }
 
+// AfterFind is called after a find() to convert the AnnotationsStr to the Annotations map.
+func (l *Layer) AfterFind(tx *gorm.DB) error {
+	if tx.Error == nil && l.AnnotationsStr != "" {
+		if err := json.Unmarshal([]byte(l.AnnotationsStr), &l.Annotations); err != nil {
+			return fmt.Errorf("parsing annotations from database record: %w", err)
+		}
+	}
+	return nil
+}
+
+// BeforeSave is called before the struct is saved to the DB to convert the Annotations map to the AnnotationsStr.
+func (l *Layer) BeforeSave(tx *gorm.DB) error {
+	l.AnnotationsStr = ""
+	if len(l.Annotations) > 0 {
+		data, err := json.Marshal(l.Annotations)
+		if err != nil {
+			return fmt.Errorf("serializing annotations: %w", err)
+		}
+		l.AnnotationsStr = string(data)
+	}
+	return nil
+}
+
+// Descriptor is the OCI descriptor of the layer.
+func (l Layer) Descriptor() ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType:   l.MediaType,
+		Digest:      l.Digest,
+		Size:        l.Size,
+		Annotations: l.Annotations,
+	}
+}
+
 // Manifest is the OCI manifest v2 and points to a Bottle.
 type Manifest struct {
 	Base
@@ -294,6 +346,35 @@ type Event struct {
 	Username     string    `gorm:"index"`
 }
 
+// EventCount is the number of events (for a bottle, action, and user) that were deleted by garbage collection.
+// It preserves aggregate counts (e.g., the number of pulls of a bottle) after the events expire.
+// There may be many rows for the same bottle, action, and user so counts must be summed.
+type EventCount struct {
+	Model
+
+	BottleID     uint          `gorm:"index"`
+	BottleDigest digest.Digest `gorm:"index"`
+	Action       string
+	Username     string `gorm:"index"`
+	Count        int64
+	Bandwidth    uint64
+}
+
+// TagPush records the manifest (and bottle) a tag of a repository pointed to when it was pushed.
+// Tag pushes are derived from push events but they are kept when the events expire so the history of tags is preserved.
+type TagPush struct {
+	Model
+
+	Repository     string        `gorm:"uniqueIndex:idx_tag_push"`
+	Tag            string        `gorm:"uniqueIndex:idx_tag_push"`
+	ManifestDigest digest.Digest `gorm:"uniqueIndex:idx_tag_push"`
+	Timestamp      time.Time     `gorm:"uniqueIndex:idx_tag_push"`
+
+	BottleID     uint          `gorm:"index"`
+	BottleDigest digest.Digest `gorm:"index"`
+	Username     string
+}
+
 // Deprecates is a deprecated Bottle.
 type Deprecates struct {
 	BottleMemberLocated
@@ -320,15 +401,12 @@ type Signature struct {
 	PublicKey            string                // PEM encoded public key associated with signature (Verify)
 	PublicKeyFingerPrint digest.Digest         `gorm:"index"` // the digest of the public key
 	Annotations          []SignatureAnnotation // extra data, such as verify api, userid, etc.
-
-	// Trusted is used in the code but not saved in the database.
-	Trusted func(TrustAnchor) bool `gorm:"-"` // true if the signature identity can be validated against a given trust anchor (fingerprint+id known)
 }
 
-// AfterFind is called after a find() to add the Trusted func to the signature.
-func (s *Signature) AfterFind(tx *gorm.DB) error {
-	s.Trusted = func(ta TrustAnchor) bool { return ta.VerifyTrust() }
-	return nil
+// Trusted returns true if the signature identity can be validated against the given trust anchor (fingerprint+id known).
+// Trust is not saved in the database since it depends on the trust anchor.
+func (s *Signature) Trusted(ta TrustAnchor) bool {
+	return ta.VerifyTrust(s)
 }
 
 // SignatureAnnotation is used to record extra data on a signature, such as verify api, userid, etc.
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+)
+
+// PendingObject is an uploaded object that is waiting for the objects it depends on (e.g., an event uploaded before its manifest).
+// It is processed when the objects it depends on are uploaded.  Garbage collection deletes it if that does not happen before it expires.
+type PendingObject struct {
+	Model
+
+	Type         string              // type of the object (e.g., "event")
+	Digest       digest.Digest       // digest the object was uploaded with
+	Data         Data                // PendingObject belongs to Data
+	DataID       uint                `gorm:"uniqueIndex"`
+	ExpiresAt    time.Time           `gorm:"index"`
+	Dependencies []PendingDependency // PendingObject has many dependencies
+}
+
+// PendingDependency is the digest of an object that a PendingObject is waiting for.
+type PendingDependency struct {
+	Model
+	PendingObjectID uint
+	Digest          digest.Digest `gorm:"index"`
+}
+
+// AddPending adds the object (with the data) to the pending queue to wait for the missing objects.
+// An object that is already pending waits for the missing objects instead of the ones it was waiting for.  Its expiry is not changed.
+func AddPending(con *gorm.DB, itemType string, dataID uint, dgst digest.Digest, missing []digest.Digest, expiresAt time.Time) (*PendingObject, error) {
+	pending := &PendingObject{}
+	if err := con.Where(PendingObject{DataID: dataID}).
+		Attrs(PendingObject{Type: itemType, Digest: dgst, ExpiresAt: expiresAt}).
+		FirstOrCreate(pending).Error; err != nil {
+		return nil, fmt.Errorf("saving pending object: %w", err)
+	}
+
+	if err := con.Unscoped().Where("pending_object_id = ?", pending.ID).Delete(&PendingDependency{}).Error; err != nil {
+		return nil, fmt.Errorf("replacing dependencies of pending object: %w", err)
+	}
+	pending.Dependencies = make([]PendingDependency, len(missing))
+	for i, d := range missing {
+		pending.Dependencies[i] = PendingDependency{PendingObjectID: pending.ID, Digest: d}
+	}
+	if len(missing) > 0 {
+		if err := con.Create(&pending.Dependencies).Error; err != nil {
+			return nil, fmt.Errorf("saving dependencies of pending object: %w", err)
+		}
+	}
+	return pending, nil
+}
+
+// PendingDependents returns the pending objects (with their data) that are waiting for the data (by any of its digests).
+// Expired objects are not included.
+func PendingDependents(con *gorm.DB, dataID uint) ([]PendingObject, error) {
+	var digests []digest.Digest
+	if err := con.Model(&Digest{}).Where("data_id = ?", dataID).Pluck("digest", &digests).Error; err != nil {
+		return nil, fmt.Errorf("finding digests: %w", err)
+	}
+	var canonicalDigests []digest.Digest
+	if err := con.Model(&Data{}).Where("id = ?", dataID).Pluck("canonical_digest", &canonicalDigests).Error; err != nil {
+		return nil, fmt.Errorf("finding digests: %w", err)
+	}
+	digests = append(digests, canonicalDigests...)
+
+	dependents := []PendingObject{}
+	if err := con.Preload("Data").
+		Where("id IN (?)", con.Model(&PendingDependency{}).Select("pending_object_id").Where("digest IN ?", digests)).
+		Where("expires_at > ?", time.Now()).
+		Order("id").
+		Find(&dependents).Error; err != nil {
+		return nil, fmt.Errorf("finding pending objects: %w", err)
+	}
+	return dependents, nil
+}
+
+// RemovePending removes the object from the pending queue.  Its data is kept.
+func RemovePending(con *gorm.DB, pending *PendingObject) error {
+	if err := con.Unscoped().Where("pending_object_id = ?", pending.ID).Delete(&PendingDependency{}).Error; err != nil {
+		return fmt.Errorf("deleting dependencies of pending object: %w", err)
+	}
+	if err := con.Unscoped().Delete(pending).Error; err != nil {
+		return fmt.Errorf("deleting pending object: %w", err)
+	}
+	return nil
+}
+
+// expirePending deletes the pending objects that have expired.  Their data is deleted later if nothing else references it.
+func expirePending(tx *gorm.DB, now time.Time, report *GCReport) error {
+	expired := tx.Model(&PendingObject{}).Select("id").Where("expires_at < ?", now)
+	if err := tx.Unscoped().Where("pending_object_id IN (?)", expired).Delete(&PendingDependency{}).Error; err != nil {
+		return fmt.Errorf("deleting dependencies of expired pending objects: %w", err)
+	}
+	result := tx.Unscoped().Where("expires_at < ?", now).Delete(&PendingObject{})
+	if result.Error != nil {
+		return fmt.Errorf("deleting expired pending objects: %w", result.Error)
+	}
+	report.Pending += result.RowsAffected
+	return nil
+}
@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/act3-ai/go-common/pkg/logger"
 )
@@ -18,89 +20,118 @@ type Processor interface {
 	Version() uint
 }
 
+// DefaultReprocessBatchSize is the number of rows reprocessed in each transaction when not otherwise specified.
+const DefaultReprocessBatchSize = 100
+
+// ReprocessOptions control how rows are reprocessed.
+type ReprocessOptions struct {
+	// BatchSize is the number of rows reprocessed in each transaction, DefaultReprocessBatchSize is used if not positive
+	BatchSize int
+
+	// Force reprocesses all rows regardless of the version of the processor that last processed them
+	Force bool
+}
+
 // Reprocess reprocesses the given type that are out of date w.r.t. the provided processor.
-func Reprocess(ctx context.Context, con *gorm.DB, processor Processor) error {
+// Rows are reprocessed in batches (each in its own transaction) and progress is logged after each batch.
+// Since each row's processor version is updated when it is reprocessed, an interrupted call resumes where it left off.
+func Reprocess(ctx context.Context, con *gorm.DB, processor Processor, opts ReprocessOptions) error {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReprocessBatchSize
+	}
+
 	tableName := processor.PrimaryTable()
 	log := logger.FromContext(ctx).With("table", tableName)
 
-	numRows := 0
-	tx := con.Table(tableName).
-		Where("processor_version < ?", processor.Version()).
-		Preload("Data")
-	for {
-		// TODO batch this with gorm's FindInBatches()
-		/*
-			rows, err := tx.Rows()
-			if err != nil {
-				return err
-			}
-			defer rows.Close()
+	outOfDate := func() *gorm.DB {
+		tx := con.Table(tableName)
+		if !opts.Force {
+			tx = tx.Where("processor_version < ?", processor.Version())
+		}
+		return tx
+	}
 
-			base := Base{}
-			for rows.Next() {
-				if err := tx.ScanRows(rows, &base); err != nil {
-					return err
-				}
-		*/
+	var total int64
+	if err := outOfDate().Count(&total).Error; err != nil {
+		return fmt.Errorf("counting rows to reprocess: %w", err)
+	}
+	if total == 0 {
+		log.DebugContext(ctx, "Nothing to reprocess")
+		return nil
+	}
+	log.InfoContext(ctx, "Reprocessing", "rows", total, "version", processor.Version(), "batchSize", batchSize, "force", opts.Force)
 
-		base := Base{}
-		if err := tx.Take(&base).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				break
-			}
+	start := time.Now()
+	var numRows int64
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("reprocessing %s interrupted after %d rows: %w", tableName, numRows, err)
+		}
+
+		// keyset pagination so rows that fail to update their version are not revisited
+		batch := []Base{}
+		tx := outOfDate().
+			Preload("Data").
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(batchSize)
+		if err := tx.Find(&batch).Error; err != nil {
 			return err
 		}
-		log.DebugContext(ctx, "Reprocessing",
-			"id", base.ID,
-			"canonicalDigest", base.Data.CanonicalDigest,
-			"oldVersion", base.ProcessorVersion, "newVersion", processor.Version())
-		base.ProcessorVersion = processor.Version()
-		if err := processor.Process(con, base); err != nil {
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := con.Transaction(func(tx *gorm.DB) error {
+			for _, base := range batch {
+				log.DebugContext(ctx, "Reprocessing",
+					"id", base.ID,
+					"canonicalDigest", base.Data.CanonicalDigest,
+					"oldVersion", base.ProcessorVersion, "newVersion", processor.Version())
+				base.ProcessorVersion = processor.Version()
+				if err := processor.Process(tx, base); err != nil {
+					return fmt.Errorf("reprocessing %s with ID %d: %w", tableName, base.ID, err)
+				}
+			}
+			return nil
+		}); err != nil {
 			return err
 		}
 
-		numRows++
+		lastID = batch[len(batch)-1].ID
+		numRows += int64(len(batch))
+
+		left := max(total-numRows, 0)
+		elapsed := time.Since(start)
+		eta := time.Duration(float64(elapsed) / float64(numRows) * float64(left))
+		log.InfoContext(ctx, "Reprocessing progress",
+			"done", numRows, "left", left,
+			"elapsed", elapsed.Round(time.Millisecond).String(), "eta", eta.Round(time.Second).String())
 	}
-	log.InfoContext(ctx, "Reprocessed", "rows", numRows)
+	log.InfoContext(ctx, "Reprocessed", "rows", numRows, "duration", time.Since(start).Round(time.Millisecond).String())
 	return nil
 }
 
-/*
-// Not used yet
-
-// ReprocessBatch reprocesses the given type that are out of date w.r.t. the provided processor
-func ReprocessBatch(con *gorm.DB, processor Processor) error {
-	batchSize := 10
-	tableName := processor.PrimaryTable()
-
-	tx := con.Table(tableName).
-		Where("processor_version < ?", processor.Version()).
-		Preload("Data")
-	results := []Base{}
-	result := tx.FindInBatches(&results, batchSize, func(tx *gorm.DB, batch int) error {
-		updated := make([]any, tx.RowsAffected)
-		for i, base := range results {
-			log.V(1).Info("Reprocessing",
-				"id", base.ID,
-				"canonical digest", base.Data.CanonicalDigest,
-				"old version", base.ProcessorVersion, "new version", processor.Version())
-			base.ProcessorVersion = processor.Version()
-			new, err := processor.Process(con, base)
-			if err != nil {
-				return err
-			}
-			updated[i] = new
-			if err := con.Session(&gorm.Session{FullSaveAssociations: true}).Save(new).Error; err != nil {
-				return err
-			}
+// ReprocessAll reprocesses all types that are out of date w.r.t. their processors.
+// Types are reprocessed in dependency order.
+func ReprocessAll(ctx context.Context, con *gorm.DB, scheme *runtime.Scheme, opts ReprocessOptions) error {
+	for _, processor := range Processors(scheme) {
+		if err := Reprocess(ctx, con, processor, opts); err != nil {
+			return err
 		}
-		// return con.Table(tableName).Session(&gorm.Session{FullSaveAssociations: true}).Updates(updated).Error
-		return nil
-	})
-	if result.Error != nil {
-		return result.Error
 	}
-	log.Info("Reprocessed", "rows", result.RowsAffected)
 	return nil
 }
-*/
+
+// Processors returns a processor for each type in the order that they need to be processed.
+func Processors(scheme *runtime.Scheme) []Processor {
+	return []Processor{
+		&BlobProcessor{},
+		NewBottleProcessor(scheme),
+		&ManifestProcessor{},
+		&EventProcessor{},
+		&SignatureProcessor{},
+	}
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
+	"github.com/act3-ai/bottle-schema/pkg/mediatype"
+	"github.com/act3-ai/go-common/pkg/redact"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+type ProcessorTestSuite struct {
+	suite.Suite
+	ctx context.Context
+	con *gorm.DB
+}
+
+func (s *ProcessorTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	scheme := runtime.NewScheme()
+	s.NoError(bottle.AddToScheme(scheme))
+	myDB, err := Open(s.ctx, v1alpha2.Database{
+		DSN: redact.SecretURL("file::memory:"),
+	}, scheme)
+	s.NoError(err)
+	s.con = myDB
+}
+
+// createOutdatedBlobs adds blobs that were processed with an old processor version.
+func (s *ProcessorTestSuite) createOutdatedBlobs(n int) {
+	for i := range n {
+		raw := []byte(fmt.Sprintf("blob %d", i))
+		blob := &Blob{
+			Base: Base{
+				ProcessorVersion: 0,
+				Data: Data{
+					RawData:         raw,
+					CanonicalDigest: digest.FromBytes(raw),
+				},
+			},
+		}
+		s.NoError(s.con.Create(blob).Error)
+	}
+}
+
+func (s *ProcessorTestSuite) countOutdatedBlobs() int64 {
+	var count int64
+	s.NoError(s.con.Table("blobs").Where("processor_version < ?", BlobProcessorVersion).Count(&count).Error)
+	return count
+}
+
+func (s *ProcessorTestSuite) TestReprocessBatches() {
+	s.createOutdatedBlobs(7)
+	s.Equal(int64(7), s.countOutdatedBlobs())
+
+	s.NoError(Reprocess(s.ctx, s.con, &BlobProcessor{}, ReprocessOptions{BatchSize: 3}))
+	s.Equal(int64(0), s.countOutdatedBlobs())
+
+	// nothing left to do
+	s.NoError(Reprocess(s.ctx, s.con, &BlobProcessor{}, ReprocessOptions{BatchSize: 3}))
+}
+
+func (s *ProcessorTestSuite) TestReprocessResumes() {
+	s.createOutdatedBlobs(4)
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+	s.ErrorIs(Reprocess(ctx, s.con, &BlobProcessor{}, ReprocessOptions{BatchSize: 2}), context.Canceled)
+	s.Equal(int64(4), s.countOutdatedBlobs())
+
+	s.NoError(Reprocess(s.ctx, s.con, &BlobProcessor{}, ReprocessOptions{BatchSize: 2}))
+	s.Equal(int64(0), s.countOutdatedBlobs())
+}
+
+func (s *ProcessorTestSuite) TestReprocessForce() {
+	s.createOutdatedBlobs(3)
+	s.NoError(Reprocess(s.ctx, s.con, &BlobProcessor{}, ReprocessOptions{}))
+
+	counts, err := ProcessorVersionCounts(s.con, "blobs")
+	s.NoError(err)
+	s.Equal([]ProcessorVersionCount{{ProcessorVersion: BlobProcessorVersion, Count: 3}}, counts)
+
+	// forcing reprocesses rows that are already up to date
+	s.NoError(s.con.Table("blobs").Where("id = ?", 1).Update("processor_version", BlobProcessorVersion+1).Error)
+	s.NoError(Reprocess(s.ctx, s.con, &BlobProcessor{}, ReprocessOptions{Force: true}))
+
+	counts, err = ProcessorVersionCounts(s.con, "blobs")
+	s.NoError(err)
+	s.Equal([]ProcessorVersionCount{{ProcessorVersion: BlobProcessorVersion, Count: 3}}, counts)
+}
+
+func (s *ProcessorTestSuite) TestRunAsLeaderSqlite() {
+	s.createOutdatedBlobs(2)
+
+	// SQLite is never shared so we are always the leader
+	isLeader, err := RunAsLeader(s.ctx, s.con, ReprocessTask, func(con *gorm.DB) error {
+		return Reprocess(s.ctx, con, &BlobProcessor{}, ReprocessOptions{})
+	})
+	s.NoError(err)
+	s.True(isLeader)
+	s.Equal(int64(0), s.countOutdatedBlobs())
+
+	s.NoError(WaitForLeader(s.ctx, s.con, ReprocessTask))
+}
+
+func (s *ProcessorTestSuite) TestReprocessKeepsManifests() {
+	// the directory part of the bottle is not archived (this was accepted before the layers were checked)
+	bottleData := []byte("bottle")
+	bottleDigest := digest.FromBytes(bottleData)
+	b := &Bottle{
+		Base:  Base{ProcessorVersion: BottleProcessorVersion, Data: Data{RawData: bottleData, CanonicalDigest: bottleDigest}},
+		Parts: []Part{{Name: "dir/", Size: 100, Digest: digest.FromString("dir")}},
+	}
+	s.Require().NoError(s.con.Create(b).Error)
+	s.Require().NoError(s.con.Create(&Digest{DataID: b.DataID, Digest: bottleDigest}).Error)
+
+	raw, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.Descriptor{MediaType: mediatype.MediaTypeBottleConfig, Digest: bottleDigest, Size: int64(len(bottleData))},
+		Layers:    []ocispec.Descriptor{{MediaType: mediatype.MediaTypeLayer, Digest: digest.FromString("dir"), Size: 100}},
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(s.con.Create(&Manifest{
+		Base: Base{ProcessorVersion: 4, Data: Data{RawData: raw, CanonicalDigest: digest.FromBytes(raw)}},
+	}).Error)
+
+	s.NoError(Reprocess(s.ctx, s.con, &ManifestProcessor{}, ReprocessOptions{BatchSize: 10}))
+	var m Manifest
+	s.NoError(s.con.Preload("Layers").First(&m).Error)
+	s.Equal(uint(ManifestProcessorVersion), m.ProcessorVersion)
+	s.Equal(mediatype.MediaTypeLayer, m.Layers[0].MediaType)
+
+	// but it is rejected when it is uploaded
+	s.ErrorContains((&ManifestProcessor{}).Process(s.con, Base{Data: Data{RawData: raw}}), `part "dir/" is a directory`)
+}
+
+func TestProcessorTestSuite(t *testing.T) {
+	suite.Run(t, new(ProcessorTestSuite))
+}
@@ -97,10 +97,15 @@ func IncludeIsDeprecated() func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// pullCounts is a table (bottle_id, bottle_digest, username, pulls) of the number of pull events.
+// It includes the counts of pull events that were deleted by garbage collection so the pulls must be summed.
+const pullCounts = `(SELECT bottle_id, bottle_digest, username, COUNT(*) AS pulls FROM events WHERE action = 'pull' GROUP BY bottle_id, bottle_digest, username
+UNION ALL SELECT bottle_id, bottle_digest, username, count AS pulls FROM event_counts WHERE action = 'pull' AND deleted_at IS NULL)`
+
 // IncludeNumPulls includes an extra column "num_pulls" which is the number of pull events for the bottle.
 func IncludeNumPulls() func(db *gorm.DB) *gorm.DB {
 	return func(con *gorm.DB) *gorm.DB {
-		tx := con.Joins("LEFT JOIN (SELECT bottle_id, COUNT(*) pull_count FROM events WHERE action='pull' GROUP BY bottle_id) pulls_table ON bottles.id = pulls_table.bottle_id")
+		tx := con.Joins("LEFT JOIN (SELECT bottle_id, CAST(SUM(pulls) AS BIGINT) pull_count FROM " + pullCounts + " pull_counts GROUP BY bottle_id) pulls_table ON bottles.id = pulls_table.bottle_id")
 		tx.Statement.Selects = append(
 			tx.Statement.Selects,
 			"MAX(COALESCE(pulls_table.pull_count, 0)) as num_pulls",
@@ -135,9 +140,9 @@ func FindArtifact(con *gorm.DB, bottleDigest digest.Digest, artifactPath string)
 
 // UserPulls will query the events and return a mapping of username to number of pulls for a given bottle.
 func UserPulls(con *gorm.DB, dgst digest.Digest, limit int) (map[string]int, error) {
-	tx := con.Table("events").
-		Select("username, COUNT(*) AS pull_count").
-		Where("action = 'pull' AND bottle_digest = ?", dgst).
+	tx := con.Table(pullCounts+" pull_counts").
+		Select("username, CAST(SUM(pulls) AS BIGINT) AS pull_count").
+		Where("bottle_digest = ?", dgst).
 		Limit(limit).
 		Group("username")
 
@@ -161,7 +166,7 @@ func UserPulls(con *gorm.DB, dgst digest.Digest, limit int) (map[string]int, err
 // BottlePulls will query the events and return the pull Request count of the provided digest.
 func BottlePulls(con *gorm.DB, dgst digest.Digest) int64 {
 	var result int64
-	con.Table("events").Where("bottle_digest = ? and action = ?", dgst, "pull").Count(&result)
+	con.Table(pullCounts+" pull_counts").Select("COALESCE(CAST(SUM(pulls) AS BIGINT), 0)").Where("bottle_digest = ?", dgst).Scan(&result)
 	return result
 }
 
@@ -200,9 +205,37 @@ func SignaturesCount(con *gorm.DB) int64 {
 	return result
 }
 
-// BlobDataBytes will get the total number of bytes for data blobs in DB.
+// BlobDataBytes will get the total number of bytes (uncompressed) for data blobs in DB.
 func BlobDataBytes(con *gorm.DB) int64 {
 	var result int64
-	con.Table("data").Select("sum(length(raw_data))").Find(&result)
+	con.Table("data").Select("sum(size)").Find(&result)
 	return result
 }
+
+// StoredDataBytes will get the total number of bytes for data blobs as stored (after compression).
+// Data in blob storage is not compressed.
+func StoredDataBytes(con *gorm.DB) int64 {
+	var result int64
+	con.Table("data").Select("sum(CASE WHEN raw_data IS NULL THEN size ELSE length(raw_data) END)").Find(&result)
+	return result
+}
+
+// ProcessorVersionCount is the number of rows in a table that were last processed by a given processor version.
+type ProcessorVersionCount struct {
+	ProcessorVersion uint
+	Count            int64
+}
+
+// ProcessorVersionCounts will query the table and return the number of rows for each processor version.
+func ProcessorVersionCounts(con *gorm.DB, table string) ([]ProcessorVersionCount, error) {
+	tx := con.Table(table).
+		Select("processor_version, COUNT(*) AS count").
+		Group("processor_version").
+		Order("processor_version")
+
+	results := []ProcessorVersionCount{}
+	if err := tx.Find(&results).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -234,6 +234,7 @@ func FindParents(con *gorm.DB, digests []digest.Digest) (Generation, error) {
 	tx = con.Select("bottles.*").
 		Table("bottles").
 		Preload("Labels").
+		Preload("Authors").
 		Preload("Sources").
 		Scopes(IncludeDigests("bottles"), ParentsOf(digests), RankByNumPulls())
 
@@ -264,6 +265,7 @@ func FindChildren(con *gorm.DB, digests []digest.Digest) (Generation, error) {
 	tx := con.Select("bottles.*").
 		Table("bottles").
 		Preload("Labels").
+		Preload("Authors").
 		Preload("Sources").
 		Scopes(IncludeDigests("bottles"), ChildrenOf(digests), RankByNumPulls())
 
@@ -8,13 +8,12 @@ import (
 
 	"github.com/opencontainers/go-digest"
 	"gorm.io/gorm"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/selection"
 
 	"github.com/act3-ai/go-common/pkg/httputil"
 	"github.com/act3-ai/go-common/pkg/logger"
 
 	"github.com/act3-ai/data-telemetry/v3/internal/features"
+	"github.com/act3-ai/data-telemetry/v3/internal/selector"
 )
 
 // FilterByDigest will use the digest to filter the query for an object type
@@ -116,10 +115,9 @@ func RankByDescription(description string) func(db *gorm.DB) *gorm.DB {
 func RankByNumPulls() func(db *gorm.DB) *gorm.DB {
 	return func(con *gorm.DB) *gorm.DB {
 		ranks := con.Session(&gorm.Session{NewDB: true}).
-			Table("events").
-			Select("events.bottle_id, COUNT(events.id) as pull_score").
-			Where("events.action = 'pull'").
-			Group("events.bottle_id")
+			Table(pullCounts + " pull_counts").
+			Select("pull_counts.bottle_id, CAST(SUM(pull_counts.pulls) AS BIGINT) as pull_score").
+			Group("pull_counts.bottle_id")
 
 		if con.Name() == "postgres" {
 			con.Statement.Selects = append(con.Statement.Selects, "COALESCE(SUM(pull_score), 0) as pull_score")
@@ -133,20 +131,6 @@ func RankByNumPulls() func(db *gorm.DB) *gorm.DB {
 	}
 }
 
-// FilterByTrustLevel selects records that include a trust level matching the trust level string eg "verified", "trusted".
-func FilterByTrustLevel(filter string) func(db *gorm.DB) *gorm.DB {
-	return func(con *gorm.DB) *gorm.DB {
-		switch filter {
-		case "verified":
-			return con.Where("verified = true")
-		case "trusted":
-			return con.Where("trusted = true")
-		default:
-			return con
-		}
-	}
-}
-
 // FilterByPublicKeyFP scopes the request to signatures with a given fingerprint.
 func FilterByPublicKeyFP(fp digest.Digest) func(db *gorm.DB) *gorm.DB {
 	return func(con *gorm.DB) *gorm.DB {
@@ -155,6 +139,7 @@ func FilterByPublicKeyFP(fp digest.Digest) func(db *gorm.DB) *gorm.DB {
 }
 
 // FilterBySelectors scopes the request to a bottle selector.
+// See selector.Parse for the syntax.
 func FilterBySelectors(selectors []string) func(db *gorm.DB) *gorm.DB {
 	return func(con *gorm.DB) *gorm.DB {
 		ctx := con.Statement.Context
@@ -162,27 +147,23 @@ func FilterBySelectors(selectors []string) func(db *gorm.DB) *gorm.DB {
 
 		expr3 := con.Session(&gorm.Session{NewDB: true})
 		// multiple selectors are combined in an OR fashion
-		for i, selector := range selectors {
-			log.DebugContext(ctx, "Applying", "selector", selector)
+		for i, s := range selectors {
+			log.DebugContext(ctx, "Applying", "selector", s)
 
-			// TODO this parser only supports integer valued gt and lt comparison and we want float64 support
-			// We need to extend/rewrite it in the future possibly pulling the code from ace/data/tool
-			sel, err := labels.Parse(selector)
+			requirements, err := selector.Parse(s)
 			if err != nil {
 				err = httputil.NewHTTPError(err, http.StatusBadRequest, err.Error())
 				con.AddError(err) //nolint:errcheck
 				return con
 			}
 
-			requirements, selectable := sel.Requirements()
-			if !selectable || len(requirements) == 0 {
+			if len(requirements) == 0 {
 				return con
 			}
 
 			// We need a new session without any clauses/expressions
 			expr2 := con.Session(&gorm.Session{NewDB: true})
 			for j, requirement := range requirements {
-				// see https://github.com/kubernetes/apimachinery/blob/4a9e16b3571218c2e15b8d0319f0b8e0e3fbacf5/pkg/labels/selector.go#L218
 				log.DebugContext(ctx, "Processing selector requirement", "requirement", requirement.String())
 				labelTable := fmt.Sprintf("label%dx%d", i, j)
 				expr := conditionForRequirement(con, labelTable, requirement)
@@ -197,85 +178,92 @@ func FilterBySelectors(selectors []string) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
-func conditionForRequirement(con *gorm.DB, labelTable string, requirement labels.Requirement) *gorm.DB {
+func conditionForRequirement(con *gorm.DB, labelTable string, requirement selector.Requirement) *gorm.DB {
 	// We need a new session without any clauses/expressions
 	expr := con.Session(&gorm.Session{NewDB: true})
 
-	switch requirement.Operator() {
-	case selection.Equals, selection.DoubleEquals:
-		value, ok := requirement.Values().PopAny()
-		if !ok {
-			// this should not be possible
-			con.AddError(fmt.Errorf("value is required for key %s", requirement.Key())) //nolint:errcheck
+	if requirement.Ordered() {
+		column, values, err := orderedValues(labelTable, requirement)
+		if err != nil {
+			con.AddError(err) //nolint:errcheck
 			return con
 		}
-		expr = expr.Where(labelTable+".key = ?", requirement.Key()).
-			Where(labelTable+".value = ?", value)
-	case selection.NotEquals:
-		value, ok := requirement.Values().PopAny()
-		if !ok {
-			// this should not be possible
-			con.AddError(fmt.Errorf("value is required for key %s", requirement.Key())) //nolint:errcheck
-			return con
+		expr = expr.Where(labelTable+".key = ?", requirement.Key)
+		if requirement.Kind == selector.Number && con.Name() == "postgres" {
+			// non-numeric values are stored as NaN which Postgres orders above every number
+			expr = expr.Where(column + " <> 'NaN'")
+		}
+		if requirement.Kind == selector.Version {
+			// non-version values are stored as "" which sorts below every version
+			expr = expr.Where(column + " <> ''")
 		}
+		if requirement.Operator == selector.Range {
+			return expr.Where(column+" BETWEEN ? AND ?", values[0], values[1])
+		}
+		return expr.Where(fmt.Sprintf("%s %s ?", column, requirement.Operator), values[0])
+	}
+
+	switch requirement.Operator {
+	case selector.Equals:
+		expr = expr.Where(labelTable+".key = ?", requirement.Key).
+			Where(labelTable+".value = ?", requirement.Values[0])
+	case selector.NotEquals:
 		subQuery := con.Session(&gorm.Session{NewDB: true}).
 			Table("labels").
 			Select("labels.bottle_id").
-			Where("labels.key = ? AND labels.value = ?", requirement.Key(), value)
+			Where("labels.key = ? AND labels.value = ?", requirement.Key, requirement.Values[0])
 		expr = expr.Where("bottles.id NOT IN (?)", subQuery)
-		// expr = expr.Where(labelTable+".key != ? AND "+labelTable+".value != ?", requirement.Key(), value)
-	case selection.In:
-		expr = expr.Where(labelTable+".key = ?", requirement.Key()).
-			Where(labelTable+".value IN ?", requirement.Values().List())
-	case selection.NotIn:
-		// expr = expr.Where(labelTable+".key = ?", requirement.Key()).
-		// 	Where(labelTable+".value NOT IN ?", requirement.Values().List())
+	case selector.In:
+		expr = expr.Where(labelTable+".key = ?", requirement.Key).
+			Where(labelTable+".value IN ?", requirement.Values)
+	case selector.NotIn:
 		subQuery := con.Session(&gorm.Session{NewDB: true}).
 			Table("labels").
 			Select("labels.bottle_id").
-			Where("labels.key = ? AND labels.value IN (?)", requirement.Key(), requirement.Values().List())
+			Where("labels.key = ? AND labels.value IN (?)", requirement.Key, requirement.Values)
 		expr = expr.Where("bottles.id NOT IN (?)", subQuery)
-	case selection.Exists: // selector="key1"
+	case selector.Exists: // selector="key1"
 		// no condition on value, since we are only checking if the key exists (with any value or no value)
-		expr = expr.Where(labelTable+".key = ?", requirement.Key())
-	case selection.DoesNotExist: // selector="!key1"
+		expr = expr.Where(labelTable+".key = ?", requirement.Key)
+	case selector.DoesNotExist: // selector="!key1"
 		subQuery := con.Session(&gorm.Session{NewDB: true}).
 			Table("labels").
 			Select("labels.bottle_id").
-			Where("labels.key = ?", requirement.Key())
+			Where("labels.key = ?", requirement.Key)
 		expr = expr.Where("bottles.id NOT IN (?)", subQuery)
-	case selection.GreaterThan:
-		value, ok := requirement.Values().PopAny()
-		if !ok {
-			// this should not be possible
-			con.AddError(fmt.Errorf("value is required for key %s", requirement.Key())) //nolint:errcheck
-			return con
-		}
-		v, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			con.AddError(fmt.Errorf("value must be a float for key %s", requirement.Key())) //nolint:errcheck
-			return con
-		}
-		expr = expr.Where(labelTable+".key = ?", requirement.Key()).
-			Where(labelTable+".numeric_value > ?", v)
-	case selection.LessThan:
-		value, ok := requirement.Values().PopAny()
-		if !ok {
-			// this should not be possible
-			con.AddError(fmt.Errorf("value is required for key %s", requirement.Key())) //nolint:errcheck
-			return con
-		}
-		v, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			con.AddError(fmt.Errorf("value must be a float for key %s", requirement.Key())) //nolint:errcheck
-			return con
-		}
-		expr = expr.Where(labelTable+".key = ?", requirement.Key()).
-			Where(labelTable+".numeric_value < ?", v)
 	}
 	return expr
 }
 
+// orderedValues returns the label column and the values to compare it to for an ordered requirement.
+func orderedValues(labelTable string, requirement selector.Requirement) (string, []any, error) {
+	values := make([]any, len(requirement.Values))
+	for i, value := range requirement.Values {
+		switch requirement.Kind {
+		case selector.Number:
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("value must be a float for key %s", requirement.Key)
+			}
+			values[i] = v
+		case selector.Version:
+			v, ok := selector.VersionKey(value)
+			if !ok {
+				return "", nil, fmt.Errorf("value must be a semantic version for key %s", requirement.Key)
+			}
+			values[i] = v
+		default:
+			return "", nil, fmt.Errorf("value must be a float or a semantic version for key %s", requirement.Key)
+		}
+	}
+
+	column := labelTable + ".numeric_value"
+	if requirement.Kind == selector.Version {
+		column = labelTable + ".version_value"
+	}
+	return column, values, nil
+}
+
 // WithSignature scopes the request to bottles with a signature that has the given fingerprint.
 func WithSignature(digests []digest.Digest) func(db *gorm.DB) *gorm.DB {
 	return func(con *gorm.DB) *gorm.DB {
@@ -302,6 +302,58 @@ func (s *ScopesTestSuite) TestSelectorFilter() {
 	}
 }
 
+func (s *ScopesTestSuite) TestOrderedSelectorFilter() {
+	values := []struct {
+		accuracy string
+		version  string
+	}{
+		{"0.91", "1.2.0"},
+		{"0.95", "v1.10.0"},
+		{"0.97", "2.0.0-rc.1"},
+		{"high", "latest"},
+	}
+	for i, v := range values {
+		s.commitBottle(&Bottle{
+			Base:        Base{DataID: uint(i + 1)},
+			Description: fmt.Sprintf("test bottle %d", i+1),
+			Labels: []Label{
+				{Key: "accuracy", Value: v.accuracy},
+				{Key: "version", Value: v.version},
+			},
+		})
+	}
+
+	selectorTests := []struct {
+		selectors         []string
+		expectedBottleIDs []uint
+	}{
+		{[]string{"accuracy>0.93"}, []uint{2, 3}},
+		{[]string{"accuracy>=0.95"}, []uint{2, 3}},
+		{[]string{"accuracy<0.95"}, []uint{1}},
+		{[]string{"accuracy<=0.95"}, []uint{1, 2}},
+		{[]string{"accuracy in [0.91, 0.95]"}, []uint{1, 2}},
+		{[]string{"accuracy in [0.92, 0.99], version<2.0.0"}, []uint{2, 3}},
+		{[]string{"version>1.9.0"}, []uint{2, 3}},
+		{[]string{"version>=v2.0.0"}, []uint{}},
+		{[]string{"version in [1.0.0, 1.99.0]"}, []uint{1, 2}},
+		{[]string{"version>=1.10"}, []uint{2, 3}},
+		{[]string{"version in [1.9, 1.10]"}, []uint{2}},
+		{[]string{"accuracy<0.92", "version>=2.0.0-rc.1"}, []uint{1, 3}},
+	}
+
+	for _, tt := range selectorTests {
+		var ids []uint
+		s.NoError(s.con.Table("bottles").
+			Scopes(FilterBySelectors(tt.selectors)).
+			Distinct().
+			Pluck("bottles.data_id", &ids).Error, tt.selectors)
+		s.ElementsMatch(tt.expectedBottleIDs, ids, tt.selectors)
+	}
+
+	var entries []Bottle
+	s.Error(s.con.Table("bottles").Scopes(FilterBySelectors([]string{"accuracy>high"})).Find(&entries).Error)
+}
+
 func (s *ScopesTestSuite) commitBottle(b *Bottle) {
 	dgst := digest.FromString(fmt.Sprintf("%d", b.DataID))
 
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/selector"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// ValidateSearchQuery returns an error if any fields of the bottle search are invalid.
+func ValidateSearchQuery(q types.SearchQuery) error {
+	var multiError error
+
+	validateDigest := func(dgst digest.Digest, fieldName string) {
+		if len(dgst.String()) > 0 {
+			err := dgst.Validate()
+			if err != nil {
+				multiError = errors.Join(multiError, fmt.Errorf("invalid search param \"%s\" (%s): %w", fieldName, dgst.String(), err))
+			}
+		}
+	}
+
+	validateDigest(q.SignatureFingerprint, "signature-fingerprint")
+	validateDigest(q.ParentsOf, "parents-of")
+	validateDigest(q.ChildrenOf, "children-of")
+	validateDigest(q.DeprecatedBy, "deprecated-by")
+	validateDigest(q.Deprecates, "deprecates")
+	for _, part := range q.PartDigests {
+		validateDigest(part, "part-digest")
+	}
+
+	for _, labelSelector := range q.LabelSelectors {
+		_, err := selector.Parse(labelSelector)
+		if err != nil {
+			multiError = errors.Join(multiError, fmt.Errorf("invalid search param \"label-selector\" (%s): %w", labelSelector, err))
+		}
+	}
+
+	for _, signatureAnnotation := range q.SignatureAnnotations {
+		sigAnnParts := strings.Split(signatureAnnotation, "=")
+		if len(sigAnnParts) != 2 {
+			multiError = errors.Join(multiError, fmt.Errorf("invalid search param \"signature-annotation\" (%s)", signatureAnnotation))
+		}
+	}
+
+	return multiError
+}
+
+// SearchBottles is a scope that filters the bottles by the bottle search (used by the catalog of the web app and the REST API).
+// Sorting by metric (see SortByMetric) and paging are left to the caller.
+func SearchBottles(q types.SearchQuery) func(db *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		// add selectors
+		tx = tx.Scopes(FilterBySelectors(q.LabelSelectors))
+
+		// description matching
+		tx = tx.Scopes(RankByDescription(q.Description))
+
+		// searching Author name or Author email
+		tx = tx.Scopes(SearchByAuthor(q.Author))
+
+		tx = tx.Scopes(SearchByRepository(q.Repository))
+
+		if len(q.SignatureFingerprint) > 0 {
+			tx = tx.Scopes(WithSignature([]digest.Digest{q.SignatureFingerprint}))
+		}
+
+		// signature annotation matching
+		tx = tx.Scopes(WithSignatureAnnotations(q.SignatureAnnotations))
+
+		if len(q.ParentsOf) > 0 {
+			tx = tx.Scopes(ParentsOf([]digest.Digest{q.ParentsOf}))
+		}
+
+		if len(q.ChildrenOf) > 0 {
+			tx = tx.Scopes(ChildrenOf([]digest.Digest{q.ChildrenOf}))
+		}
+
+		// If we are using "deprecated by" we can assume they want to see deprecated bottles
+		showDeprecated := q.ShowDeprecated
+		if len(q.DeprecatedBy) > 0 {
+			tx = tx.Scopes(DeprecatedBy(q.DeprecatedBy))
+			showDeprecated = true
+		}
+
+		if len(q.Deprecates) > 0 {
+			tx = tx.Scopes(DeprecatesThis(q.Deprecates))
+		}
+
+		if !showDeprecated {
+			tx = tx.Scopes(ExcludeDeprecated())
+		}
+
+		if len(q.PartDigests) > 0 {
+			tx = tx.Scopes(FilterByParts(q.PartDigests))
+		}
+
+		if len(q.Metrics) > 0 {
+			tx = tx.Scopes(FilterByMetric(q.Metrics))
+		}
+
+		if !q.CreatedBefore.IsZero() {
+			tx = tx.Where("bottles.created_at <= ?", q.CreatedBefore)
+		}
+
+		return tx
+	}
+}
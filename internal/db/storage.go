@@ -0,0 +1,296 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/storage"
+)
+
+// blobStorePlugin makes the blob store available to the Data hooks of every session of the connection.
+type blobStorePlugin struct {
+	store storage.Store
+}
+
+// Name implements gorm.Plugin.
+func (p *blobStorePlugin) Name() string {
+	return "telemetry:blobstore"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *blobStorePlugin) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// UseBlobStore stores the raw data of new Data records in the store instead of the database.
+func UseBlobStore(con *gorm.DB, store storage.Store) error {
+	if err := con.Use(&blobStorePlugin{store: store}); err != nil {
+		return fmt.Errorf("registering blob store: %w", err)
+	}
+	return nil
+}
+
+// BlobStore returns the blob store of the connection or nil if the raw data is stored in the database.
+func BlobStore(con *gorm.DB) storage.Store {
+	p, ok := con.Config.Plugins[(&blobStorePlugin{}).Name()].(*blobStorePlugin)
+	if !ok {
+		return nil
+	}
+	return p.store
+}
+
+// compressionPlugin makes the codec available to the Data hooks of every session of the connection.
+type compressionPlugin struct {
+	codec storage.Codec
+}
+
+// Name implements gorm.Plugin.
+func (p *compressionPlugin) Name() string {
+	return "telemetry:compression"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *compressionPlugin) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// UseCompression compresses the raw data of new Data records that are saved in the database with the codec.
+func UseCompression(con *gorm.DB, codec storage.Codec) error {
+	if err := con.Use(&compressionPlugin{codec: codec}); err != nil {
+		return fmt.Errorf("registering compression: %w", err)
+	}
+	return nil
+}
+
+// Compression returns the codec used to compress raw data saved in the database.
+func Compression(con *gorm.DB) storage.Codec {
+	p, ok := con.Config.Plugins[(&compressionPlugin{}).Name()].(*compressionPlugin)
+	if !ok {
+		return storage.CodecNone
+	}
+	return p.codec
+}
+
+// BeforeSave is called before the struct is saved to the DB to put the raw data in the blob store (if configured)
+// or to compress it for saving in the database.  Data that was already stored (i.e., found) is not stored again.
+func (d *Data) BeforeSave(tx *gorm.DB) error {
+	if d.RawData != nil {
+		d.Size = int64(len(d.RawData))
+	}
+
+	if store := BlobStore(tx); store != nil || d.StorageRef != "" {
+		if d.StorageRef == "" {
+			ref, err := store.Put(tx.Statement.Context, d.CanonicalDigest, d.RawData)
+			if err != nil {
+				return fmt.Errorf("storing data %s: %w", d.CanonicalDigest, err)
+			}
+			d.StorageRef = ref
+		}
+		d.StoredData = nil
+		d.Codec = storage.CodecNone
+		return nil
+	}
+
+	codec := Compression(tx)
+	if d.StoredData != nil && d.Codec == codec {
+		return nil
+	}
+	stored, err := codec.Encode(d.RawData)
+	if err != nil {
+		return fmt.Errorf("compressing data %s: %w", d.CanonicalDigest, err)
+	}
+	d.StoredData = stored
+	d.Codec = codec
+	return nil
+}
+
+// skipDecompressionKey is the gorm setting that keeps AfterFind from decompressing the data saved in the database.
+const skipDecompressionKey = "telemetry:skip_decompression"
+
+// SkipDecompression returns a session that finds Data saved in the database without decompressing it (RawData is nil).
+// It is for callers that can use the compressed data as is.  Call Data.Decompress to get the raw data.
+func SkipDecompression(tx *gorm.DB) *gorm.DB {
+	return tx.Set(skipDecompressionKey, true)
+}
+
+// Decompress sets the raw data from the data saved in the database if it is not set already (see SkipDecompression).
+func (d *Data) Decompress() error {
+	if d.RawData != nil || d.StorageRef != "" {
+		return nil
+	}
+	data, err := d.Codec.Decode(d.StoredData)
+	if err != nil {
+		return fmt.Errorf("decompressing data %s: %w", d.CanonicalDigest, err)
+	}
+	d.RawData = data
+	return nil
+}
+
+// AfterFind is called after a find() to get the raw data from the blob store or to decompress it.
+func (d *Data) AfterFind(tx *gorm.DB) error {
+	if tx.Error != nil || d.RawData != nil {
+		return nil
+	}
+
+	if d.StorageRef == "" {
+		if skip, _ := tx.Get(skipDecompressionKey); skip == true {
+			return nil
+		}
+		return d.Decompress()
+	}
+
+	store := BlobStore(tx)
+	if store == nil {
+		return fmt.Errorf("data %s is in blob storage (%s) but blob storage is not configured", d.CanonicalDigest, d.StorageRef)
+	}
+	data, err := store.Get(tx.Statement.Context, d.StorageRef)
+	if err != nil {
+		return fmt.Errorf("getting data %s: %w", d.CanonicalDigest, err)
+	}
+	d.RawData = data
+	return nil
+}
+
+// MoveToBlobStore moves the raw data that is still in the database to the blob store.
+// Rows are moved in batches and an interrupted call resumes where it left off.  It returns the number of rows moved.
+func MoveToBlobStore(ctx context.Context, con *gorm.DB, batchSize int) (int64, error) {
+	store := BlobStore(con)
+	if store == nil {
+		return 0, errors.New("blob storage is not configured")
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultReprocessBatchSize
+	}
+	log := logger.FromContext(ctx)
+
+	inDatabase := func() *gorm.DB {
+		return con.Model(&Data{}).Where("storage_ref IS NULL OR storage_ref = ''")
+	}
+
+	var total int64
+	if err := inDatabase().Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("counting data to move: %w", err)
+	}
+	if total == 0 {
+		log.DebugContext(ctx, "No data to move to blob storage")
+		return 0, nil
+	}
+	log.InfoContext(ctx, "Moving data to blob storage", "rows", total, "batchSize", batchSize)
+
+	start := time.Now()
+	var moved int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return moved, fmt.Errorf("moving data interrupted after %d rows: %w", moved, err)
+		}
+
+		batch := []Data{}
+		if err := inDatabase().Order("id ASC").Limit(batchSize).Find(&batch).Error; err != nil {
+			return moved, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, d := range batch {
+			ref, err := store.Put(ctx, d.CanonicalDigest, d.RawData)
+			if err != nil {
+				return moved, fmt.Errorf("storing data %s: %w", d.CanonicalDigest, err)
+			}
+			// UpdateColumns skips the hooks
+			if err := con.Model(&Data{}).Where("id = ?", d.ID).UpdateColumns(map[string]any{
+				"storage_ref": ref,
+				"size":        len(d.RawData),
+				"raw_data":    nil,
+				"codec":       storage.CodecNone,
+			}).Error; err != nil {
+				return moved, fmt.Errorf("updating data %s: %w", d.CanonicalDigest, err)
+			}
+		}
+
+		moved += int64(len(batch))
+		log.InfoContext(ctx, "Moving data progress", "done", moved, "left", max(total-moved, 0), "elapsed", time.Since(start))
+	}
+
+	log.InfoContext(ctx, "Moved data to blob storage", "rows", moved, "elapsed", time.Since(start))
+	return moved, nil
+}
+
+// Recompress compresses the raw data saved in the database with the configured codec (see UseCompression).
+// Data compressed with another codec (or not compressed) is recompressed in batches, each in its own transaction.
+// An interrupted call resumes where it left off.  It returns the number of rows recompressed.
+func Recompress(ctx context.Context, con *gorm.DB, batchSize int) (int64, error) {
+	codec := Compression(con)
+	if batchSize <= 0 {
+		batchSize = DefaultReprocessBatchSize
+	}
+	log := logger.FromContext(ctx).With("codec", codec)
+
+	outOfDate := func() *gorm.DB {
+		return con.Model(&Data{}).
+			Where("storage_ref IS NULL OR storage_ref = ''").
+			Where("raw_data IS NOT NULL").
+			Where("coalesce(codec, '') <> ?", codec)
+	}
+
+	var total int64
+	if err := outOfDate().Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("counting data to compress: %w", err)
+	}
+	if total == 0 {
+		log.DebugContext(ctx, "No data to compress")
+		return 0, nil
+	}
+	log.InfoContext(ctx, "Compressing data", "rows", total, "batchSize", batchSize)
+
+	start := time.Now()
+	var done int64
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return done, fmt.Errorf("compressing data interrupted after %d rows: %w", done, err)
+		}
+
+		// keyset pagination so rows that fail to compress are not revisited
+		batch := []Data{}
+		if err := outOfDate().Where("id > ?", lastID).Order("id ASC").Limit(batchSize).Find(&batch).Error; err != nil {
+			return done, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := con.Transaction(func(tx *gorm.DB) error {
+			for _, d := range batch {
+				stored, err := codec.Encode(d.RawData)
+				if err != nil {
+					return fmt.Errorf("compressing data %s: %w", d.CanonicalDigest, err)
+				}
+				// UpdateColumns skips the hooks
+				if err := tx.Model(&Data{}).Where("id = ?", d.ID).UpdateColumns(map[string]any{
+					"raw_data": stored,
+					"codec":    codec,
+					"size":     len(d.RawData),
+				}).Error; err != nil {
+					return fmt.Errorf("updating data %s: %w", d.CanonicalDigest, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return done, err
+		}
+
+		lastID = batch[len(batch)-1].ID
+		done += int64(len(batch))
+		log.InfoContext(ctx, "Compressing data progress", "done", done, "left", max(total-done, 0), "elapsed", time.Since(start))
+	}
+
+	log.InfoContext(ctx, "Compressed data", "rows", done, "elapsed", time.Since(start))
+	return done, nil
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/bottle-schema/pkg/mediatype"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/selector"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// ValidateStorageQuery validates the label selectors of the query.
+func ValidateStorageQuery(q types.StorageQuery) error {
+	var multiError error
+	for _, labelSelector := range q.LabelSelectors {
+		if _, err := selector.Parse(labelSelector); err != nil {
+			multiError = errors.Join(multiError, fmt.Errorf("invalid param \"label-selector\" (%s): %w", labelSelector, err))
+		}
+	}
+	return multiError
+}
+
+// StorageStats computes the storage of the layers of the manifests of the bottles that match the query.
+// The query must be valid (see ValidateStorageQuery).
+func StorageStats(con *gorm.DB, query types.StorageQuery) (*types.StorageStats, error) {
+	// layers is the layers (with their parts) of the manifests of the bottles
+	layers := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Model(&Layer{}).
+			Joins("INNER JOIN manifests ON manifests.id = layers.manifest_id AND manifests.deleted_at IS NULL").
+			Joins("LEFT JOIN parts ON parts.bottle_id = manifests.bottle_id AND parts.location = layers.location AND parts.deleted_at IS NULL")
+		if len(query.LabelSelectors) > 0 {
+			// a subquery so the joins on labels do not repeat layers
+			tx = tx.Where("manifests.bottle_id IN (?)", selectedBottleIDs(tx, query.LabelSelectors))
+		}
+		return tx
+	}
+
+	stats := &types.StorageStats{MediaTypes: []types.MediaTypeStorage{}}
+
+	counts := struct {
+		Bottles   int64
+		Manifests int64
+	}{}
+	if err := con.Scopes(layers).
+		Select("COUNT(DISTINCT manifests.bottle_id) AS bottles, COUNT(DISTINCT manifests.id) AS manifests").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	stats.Bottles = counts.Bottles
+	stats.Manifests = counts.Manifests
+
+	if err := con.Scopes(layers).
+		Select("layers.media_type AS media_type, COUNT(*) AS layers, " +
+			"CAST(COALESCE(SUM(parts.size), 0) AS BIGINT) AS uncompressed_bytes, CAST(COALESCE(SUM(layers.size), 0) AS BIGINT) AS compressed_bytes").
+		Group("layers.media_type").
+		Order("layers.media_type").
+		Scan(&stats.MediaTypes).Error; err != nil {
+		return nil, err
+	}
+
+	// a layer is stored once no matter how many manifests reference it
+	distinctLayers := con.Scopes(layers).
+		Select("layers.digest, MAX(layers.size) AS size").
+		Group("layers.digest")
+	if err := con.Table("(?) AS distinct_layers", distinctLayers).
+		Select("CAST(COALESCE(SUM(distinct_layers.size), 0) AS BIGINT)").
+		Scan(&stats.DeduplicatedBytes).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range stats.MediaTypes {
+		m := &stats.MediaTypes[i]
+		// layers that were not reprocessed since media types were recorded do not have one
+		if m.MediaType != "" {
+			m.Archived = mediatype.IsArchived(m.MediaType)
+			m.Compressed = mediatype.IsCompressed(m.MediaType)
+		}
+		stats.Layers += m.Layers
+		stats.UncompressedBytes += m.UncompressedBytes
+		stats.CompressedBytes += m.CompressedBytes
+		if m.Archived {
+			stats.ArchivedLayers += m.Layers
+		}
+	}
+	return stats, nil
+}
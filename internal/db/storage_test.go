@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
+	"github.com/act3-ai/go-common/pkg/redact"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/storage"
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+type StorageTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	scheme  *runtime.Scheme
+	dsn     string
+	blobDir string
+}
+
+func (s *StorageTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.scheme = runtime.NewScheme()
+	s.NoError(bottle.AddToScheme(s.scheme))
+
+	dir := s.T().TempDir()
+	s.dsn = "file:" + filepath.Join(dir, "test.db")
+	s.blobDir = filepath.Join(dir, "blobs")
+}
+
+// open opens the database with filesystem blob storage (or raw data in the database with the compression).
+func (s *StorageTestSuite) open(blobStorage bool, compression string) *gorm.DB {
+	conf := v1alpha2.Database{DSN: redact.SecretURL(s.dsn), Compression: compression}
+	if blobStorage {
+		conf.Storage = v1alpha2.BlobStorage{Type: "filesystem", Path: s.blobDir}
+	}
+	con, err := Open(s.ctx, conf, s.scheme)
+	s.Require().NoError(err)
+	s.T().Cleanup(func() {
+		sqlDB, err := con.DB()
+		s.NoError(err)
+		s.NoError(sqlDB.Close())
+	})
+	return con
+}
+
+// createBlobs adds blobs and returns their data.
+func (s *StorageTestSuite) createBlobs(con *gorm.DB, n int) [][]byte {
+	raws := make([][]byte, n)
+	for i := range n {
+		raws[i] = []byte(strings.Repeat(fmt.Sprintf("blob %d ", i), 20))
+		s.Require().NoError(con.Create(&Blob{
+			Base: Base{
+				ProcessorVersion: BlobProcessorVersion,
+				Data: Data{
+					RawData:         raws[i],
+					CanonicalDigest: CanonicalDigestAlgorithm.FromBytes(raws[i]),
+				},
+			},
+		}).Error)
+	}
+	return raws
+}
+
+// rawDataInDatabase returns the number of rows with raw data in the database.
+func (s *StorageTestSuite) rawDataInDatabase(con *gorm.DB) int64 {
+	var count int64
+	s.NoError(con.Model(&Data{}).Where("raw_data IS NOT NULL").Count(&count).Error)
+	return count
+}
+
+// assertBlobs checks that the blobs are found with their data.
+func (s *StorageTestSuite) assertBlobs(con *gorm.DB, raws [][]byte) {
+	var blobs []Blob
+	s.Require().NoError(con.Preload("Data").Order("id").Find(&blobs).Error)
+	s.Require().Len(blobs, len(raws))
+	for i, blob := range blobs {
+		s.Equal(raws[i], blob.Data.RawData)
+		s.Equal(int64(len(raws[i])), blob.Data.Size)
+	}
+}
+
+func (s *StorageTestSuite) TestStore() {
+	con := s.open(true, "")
+	raws := s.createBlobs(con, 3)
+
+	s.Equal(int64(0), s.rawDataInDatabase(con))
+	s.assertBlobs(con, raws)
+
+	// the blobs are content-addressable
+	dgst := CanonicalDigestAlgorithm.FromBytes(raws[0])
+	_, err := os.Stat(filepath.Join(s.blobDir, dgst.Algorithm().String(), dgst.Encoded()[:2], dgst.Encoded()))
+	s.NoError(err)
+
+	var total int64
+	for _, raw := range raws {
+		total += int64(len(raw))
+	}
+	s.Equal(total, BlobDataBytes(con))
+
+	// reprocessing saves the data again
+	s.NoError(Reprocess(s.ctx, con, &BlobProcessor{}, ReprocessOptions{Force: true}))
+	s.Equal(int64(0), s.rawDataInDatabase(con))
+	s.assertBlobs(con, raws)
+}
+
+func (s *StorageTestSuite) TestMoveToBlobStore() {
+	con := s.open(false, "")
+	raws := s.createBlobs(con, 5)
+	s.Equal(int64(5), s.rawDataInDatabase(con))
+
+	// data is moved when the database is opened with blob storage
+	con = s.open(true, "")
+	s.Equal(int64(0), s.rawDataInDatabase(con))
+	s.assertBlobs(con, raws)
+
+	// nothing left to move
+	moved, err := MoveToBlobStore(s.ctx, con, 2)
+	s.NoError(err)
+	s.Equal(int64(0), moved)
+
+	// new data is stored in the blob store along side the moved data
+	raw := []byte("new blob")
+	s.NoError(con.Create(&Blob{Base: Base{Data: Data{RawData: raw, CanonicalDigest: digest.FromBytes(raw)}}}).Error)
+	s.assertBlobs(con, append(raws, raw))
+}
+
+func (s *StorageTestSuite) TestBlobStoreNotConfigured() {
+	s.createBlobs(s.open(true, ""), 1)
+
+	var blob Blob
+	s.Error(s.open(false, "").Preload("Data").First(&blob).Error)
+}
+
+// codecs returns the number of rows saved in the database with each codec.
+func (s *StorageTestSuite) codecs(con *gorm.DB) map[storage.Codec]int {
+	var data []Data
+	s.Require().NoError(SkipDecompression(con).Find(&data).Error)
+	counts := map[storage.Codec]int{}
+	for _, d := range data {
+		counts[d.Codec]++
+	}
+	return counts
+}
+
+func (s *StorageTestSuite) TestCompression() {
+	con := s.open(false, "zstd")
+	raws := s.createBlobs(con, 3)
+
+	s.Equal(map[storage.Codec]int{storage.CodecZstd: 3}, s.codecs(con))
+	s.assertBlobs(con, raws)
+	s.Less(StoredDataBytes(con), BlobDataBytes(con))
+
+	// the data is only decompressed when asked
+	var blob Blob
+	s.Require().NoError(SkipDecompression(con).Preload("Data").First(&blob).Error)
+	s.Nil(blob.Data.RawData)
+	s.NoError(blob.Data.Decompress())
+	s.Equal(raws[0], blob.Data.RawData)
+
+	// reprocessing does not compress the data again
+	s.NoError(Reprocess(s.ctx, con, &BlobProcessor{}, ReprocessOptions{Force: true}))
+	s.assertBlobs(con, raws)
+}
+
+func (s *StorageTestSuite) TestRecompress() {
+	raws := s.createBlobs(s.open(false, ""), 5)
+
+	con := s.open(false, "gzip")
+	s.Equal(map[storage.Codec]int{storage.CodecNone: 5}, s.codecs(con))
+	s.Equal(BlobDataBytes(con), StoredDataBytes(con))
+
+	done, err := Recompress(s.ctx, con, 2)
+	s.NoError(err)
+	s.Equal(int64(5), done)
+	s.Equal(map[storage.Codec]int{storage.CodecGzip: 5}, s.codecs(con))
+	s.assertBlobs(con, raws)
+	s.Less(StoredDataBytes(con), BlobDataBytes(con))
+
+	// nothing left to do
+	done, err = Recompress(s.ctx, con, 2)
+	s.NoError(err)
+	s.Equal(int64(0), done)
+
+	// switching codecs recompresses (and turning compression off decompresses)
+	for _, compression := range []string{"zstd", "none"} {
+		con := s.open(false, compression)
+		done, err := Recompress(s.ctx, con, 0)
+		s.NoError(err)
+		s.Equal(int64(5), done)
+		s.assertBlobs(con, raws)
+	}
+	s.Equal(map[storage.Codec]int{storage.CodecNone: 5}, s.codecs(con))
+}
+
+func TestStorageTestSuite(t *testing.T) {
+	suite.Run(t, new(StorageTestSuite))
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// toType converts the tag push to its API type.
+func (p TagPush) toType() types.TagPush {
+	return types.TagPush{
+		Repository:     p.Repository,
+		Tag:            p.Tag,
+		ManifestDigest: p.ManifestDigest,
+		BottleDigest:   p.BottleDigest,
+		Timestamp:      p.Timestamp,
+		Username:       p.Username,
+	}
+}
+
+// FindRepositoryTags returns the history of the tags of the repository.
+// If tag is not empty only the history of that tag is returned.
+func FindRepositoryTags(con *gorm.DB, repository, tag string) (*types.RepositoryTags, error) {
+	tx := con.Where("repository = ?", repository).
+		Order("tag").
+		Order("timestamp DESC").
+		Order("id DESC")
+	if tag != "" {
+		tx = tx.Where("tag = ?", tag)
+	}
+
+	pushes := []TagPush{}
+	if err := tx.Find(&pushes).Error; err != nil {
+		return nil, err
+	}
+
+	tags := &types.RepositoryTags{
+		Repository: repository,
+		Tags:       []types.TagHistory{},
+	}
+	for i, p := range pushes {
+		// pushes are ordered by tag so a new tag starts a new history
+		if i == 0 || p.Tag != pushes[i-1].Tag {
+			tags.Tags = append(tags.Tags, types.TagHistory{Tag: p.Tag})
+		}
+		history := &tags.Tags[len(tags.Tags)-1]
+		history.Pushes = append(history.Pushes, p.toType())
+	}
+	return tags, nil
+}
+
+// ResolveTag returns the push of the tag that the tag pointed to at the time (now if the time is zero).
+// It returns gorm.ErrRecordNotFound if the tag was not pushed by then.
+func ResolveTag(con *gorm.DB, repository, tag string, at time.Time) (*types.TagPush, error) {
+	tx := con.Where("repository = ? AND tag = ?", repository, tag).
+		Order("timestamp DESC").
+		Order("id DESC")
+	if !at.IsZero() {
+		tx = tx.Where("timestamp <= ?", at.UTC())
+	}
+
+	push := TagPush{}
+	if err := tx.First(&push).Error; err != nil {
+		return nil, err
+	}
+	result := push.toType()
+	return &result, nil
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/act3-ai/go-common/pkg/logger"
+)
+
+// Tombstone records a bottle that was removed by an administrator (e.g., because its metadata leaked sensitive information).
+// The objects removed with the bottle are not accepted again so mirrors do not re-upload them.
+type Tombstone struct {
+	Model
+
+	BottleDigest digest.Digest     `gorm:"index"` // digest the bottle was removed by
+	Username     string            // who removed the bottle (empty when authentication is disabled)
+	Reason       string            // why the bottle was removed
+	Digests      []TombstoneDigest // Tombstone has many digests
+}
+
+// TombstoneDigest is a digest (including the canonical digest) of data removed with a bottle.
+type TombstoneDigest struct {
+	Model
+	TombstoneID uint
+	Digest      digest.Digest `gorm:"uniqueIndex"`
+}
+
+// bottleChildren are the "has many" members of a bottle (and the counts of its expired events and the pushes of tags to it).
+var bottleChildren = []any{&Author{}, &Source{}, &Metric{}, &PublicArtifact{}, &Label{}, &Annotation{}, &Part{}, &Deprecates{}, &EventCount{}, &TagPush{}}
+
+// RemoveBottle deletes the bottle with the digest along with everything that references it (manifests, events, signatures, and counts)
+// and the public artifacts that no other bottle references.  Rows are deleted permanently (not soft deleted) since they may hold sensitive information.
+// A tombstone with the digests of the removed data is left in its place.  It returns gorm.ErrRecordNotFound if the bottle does not exist.
+func RemoveBottle(ctx context.Context, con *gorm.DB, dgst digest.Digest, username, reason string) (*Tombstone, error) {
+	log := logger.FromContext(ctx).With("bottle", dgst)
+
+	tombstone := &Tombstone{BottleDigest: dgst, Username: username, Reason: reason}
+	var report GCReport
+	var refs []string
+	err := con.Transaction(func(tx *gorm.DB) error {
+		bottle := Bottle{}
+		if err := tx.Scopes(FilterByDigest(dgst, "bottles")).Preload("PublicArtifacts").First(&bottle).Error; err != nil {
+			return fmt.Errorf("finding bottle %s: %w", dgst, err)
+		}
+
+		// the IDs include 0 so "IN" is never empty
+		dataIDs := []uint{bottle.DataID}
+		collect := func(model any, query string, args ...any) ([]uint, error) {
+			rows := []Base{}
+			if err := tx.Unscoped().Model(model).Select("id", "data_id").Where(query, args...).Scan(&rows).Error; err != nil {
+				return nil, err
+			}
+			ids := []uint{0}
+			for _, row := range rows {
+				ids = append(ids, row.ID)
+				dataIDs = append(dataIDs, row.DataID)
+			}
+			return ids, nil
+		}
+		manifestIDs, err := collect(&Manifest{}, "bottle_id = ?", bottle.ID)
+		if err != nil {
+			return fmt.Errorf("finding manifests: %w", err)
+		}
+		eventIDs, err := collect(&Event{}, "bottle_id = ? OR manifest_id IN ?", bottle.ID, manifestIDs)
+		if err != nil {
+			return fmt.Errorf("finding events: %w", err)
+		}
+		signatureIDs, err := collect(&Signature{}, "bottle_id = ? OR manifest_id IN ?", bottle.ID, manifestIDs)
+		if err != nil {
+			return fmt.Errorf("finding signatures: %w", err)
+		}
+		artifactDataIDs := []uint{0}
+		for _, artifact := range bottle.PublicArtifacts {
+			artifactDataIDs = append(artifactDataIDs, artifact.DataID)
+		}
+		dataIDs = append(dataIDs, artifactDataIDs...)
+
+		// members before the objects they belong to
+		deletes := []func() *gorm.DB{
+			func() *gorm.DB { return tx.Unscoped().Where("manifest_id IN ?", manifestIDs).Delete(&Layer{}) },
+			func() *gorm.DB {
+				return tx.Unscoped().Where("signature_id IN ?", signatureIDs).Delete(&SignatureAnnotation{})
+			},
+		}
+		for _, model := range bottleChildren {
+			deletes = append(deletes, func() *gorm.DB { return tx.Unscoped().Where("bottle_id = ?", bottle.ID).Delete(model) })
+		}
+		deletes = append(deletes,
+			func() *gorm.DB { return tx.Unscoped().Where("id IN ?", eventIDs).Delete(&Event{}) },
+			func() *gorm.DB { return tx.Unscoped().Where("id IN ?", signatureIDs).Delete(&Signature{}) },
+			func() *gorm.DB { return tx.Unscoped().Where("id IN ?", manifestIDs).Delete(&Manifest{}) },
+			func() *gorm.DB { return tx.Unscoped().Where("id = ?", bottle.ID).Delete(&Bottle{}) },
+			// artifacts that are still referenced by another bottle are kept
+			func() *gorm.DB {
+				return tx.Unscoped().Where("data_id IN ?", artifactDataIDs).
+					Where("NOT EXISTS (SELECT 1 FROM public_artifacts WHERE public_artifacts.data_id = blobs.data_id AND public_artifacts.deleted_at IS NULL)").
+					Delete(&Blob{})
+			},
+		)
+		for _, del := range deletes {
+			if err := del().Error; err != nil {
+				return fmt.Errorf("removing bottle %s: %w", dgst, err)
+			}
+		}
+
+		// the data of the removed objects (unless something else still references it) is tombstoned and deleted
+		var orphanIDs []uint
+		if err := unreferencedData(tx).Where("id IN ?", dataIDs).Pluck("id", &orphanIDs).Error; err != nil {
+			return fmt.Errorf("finding removed data: %w", err)
+		}
+		var digests, canonicalDigests []digest.Digest
+		if err := tx.Model(&Digest{}).Where("data_id IN ?", append(orphanIDs, bottle.DataID)).Pluck("digest", &digests).Error; err != nil {
+			return fmt.Errorf("finding digests of removed data: %w", err)
+		}
+		if err := tx.Model(&Data{}).Where("id IN ?", append(orphanIDs, bottle.DataID)).Pluck("canonical_digest", &canonicalDigests).Error; err != nil {
+			return fmt.Errorf("finding digests of removed data: %w", err)
+		}
+		digests = append(digests, canonicalDigests...)
+		digests = append(digests, dgst)
+		slices.Sort(digests)
+		for _, d := range slices.Compact(digests) {
+			tombstone.Digests = append(tombstone.Digests, TombstoneDigest{Digest: d})
+		}
+
+		refs, err = deleteUnreferencedData(tx, tx.Model(&Data{}).Where("id IN ?", append(orphanIDs, 0)), &report)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Digests").Create(tombstone).Error; err != nil {
+			return fmt.Errorf("saving tombstone: %w", err)
+		}
+		for i := range tombstone.Digests {
+			tombstone.Digests[i].TombstoneID = tombstone.ID
+		}
+		// digests of data that was removed with another bottle are already tombstoned
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tombstone.Digests).Error; err != nil {
+			return fmt.Errorf("saving tombstone digests: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleteFromBlobStore(ctx, con, refs)
+	log.InfoContext(ctx, "Removed bottle", "username", username, "reason", reason,
+		"data", report.Data, "digests", report.Digests, "bytes", report.Bytes)
+	return tombstone, nil
+}
+
+// IsRemoved returns true if any of the digests is of data that was removed with a bottle (see RemoveBottle).
+func IsRemoved(con *gorm.DB, dgsts ...digest.Digest) (bool, error) {
+	var count int64
+	if err := con.Model(&TombstoneDigest{}).Where("digest IN ?", dgsts).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("checking tombstones: %w", err)
+	}
+	return count > 0, nil
+}
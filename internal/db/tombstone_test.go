@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
+	"github.com/act3-ai/go-common/pkg/redact"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+func TestRemoveBottle(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, bottle.AddToScheme(scheme))
+	con, err := Open(ctx, v1alpha2.Database{DSN: redact.SecretURL("file:" + filepath.Join(t.TempDir(), "test.db"))}, scheme)
+	require.NoError(t, err)
+
+	data := func(raw string) Data {
+		return Data{RawData: []byte(raw), CanonicalDigest: CanonicalDigestAlgorithm.FromString(raw)}
+	}
+	blob := func(raw string) Blob {
+		b := Blob{Base: Base{Data: data(raw)}}
+		require.NoError(t, con.Create(&b).Error)
+		require.NoError(t, con.Create(&Digest{DataID: b.DataID, Digest: digest.SHA256.FromString(raw)}).Error)
+		return b
+	}
+	secret := blob("secret artifact")
+	shared := blob("shared artifact")
+
+	bottles := make([]Bottle, 2)
+	for i, raw := range []string{"leaky bottle", "other bottle"} {
+		bottles[i] = Bottle{
+			Base:            Base{Data: data(raw)},
+			Description:     raw,
+			Authors:         []Author{{Email: "someone@example.com"}},
+			Labels:          []Label{{Key: "k", Value: "v"}},
+			PublicArtifacts: []PublicArtifact{{Name: "shared", DataID: shared.DataID}},
+		}
+		if i == 0 {
+			bottles[i].PublicArtifacts = append(bottles[i].PublicArtifacts, PublicArtifact{Name: "secret", DataID: secret.DataID})
+		}
+		require.NoError(t, con.Create(&bottles[i]).Error)
+		require.NoError(t, con.Create(&Digest{DataID: bottles[i].DataID, Digest: digest.SHA256.FromString(raw)}).Error)
+	}
+	manifest := Manifest{Base: Base{Data: data("manifest")}, BottleID: bottles[0].ID, Layers: []Layer{{Digest: digest.FromString("layer")}}}
+	require.NoError(t, con.Create(&manifest).Error)
+	require.NoError(t, con.Create(&Event{Base: Base{Data: data("event")}, ManifestID: manifest.ID, BottleID: bottles[0].ID}).Error)
+
+	dgst := digest.SHA256.FromString("leaky bottle")
+	tombstone, err := RemoveBottle(ctx, con, dgst, "admin", "leaked an email address")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", tombstone.Username)
+
+	count := func(model any) int64 {
+		var n int64
+		require.NoError(t, con.Unscoped().Model(model).Count(&n).Error)
+		return n
+	}
+	assert.Equal(t, int64(1), count(&Bottle{}))
+	assert.Equal(t, int64(1), count(&Author{}))
+	assert.Equal(t, int64(1), count(&Label{}))
+	assert.Equal(t, int64(1), count(&PublicArtifact{}))
+	assert.Equal(t, int64(0), count(&Manifest{}))
+	assert.Equal(t, int64(0), count(&Layer{}))
+	assert.Equal(t, int64(0), count(&Event{}))
+	// the shared artifact is still used by the other bottle
+	assert.Equal(t, int64(1), count(&Blob{}))
+	assert.Equal(t, int64(2), count(&Data{}))
+
+	removed, err := IsRemoved(con, digest.SHA256.FromString("secret artifact"))
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = IsRemoved(con, digest.SHA256.FromString("shared artifact"))
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	_, err = RemoveBottle(ctx, con, dgst, "admin", "again")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
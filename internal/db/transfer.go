@@ -0,0 +1,116 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// defaultTransferLimit is the number of bottles, repositories, and users in transfer statistics when the limit is not given.
+const defaultTransferLimit = 20
+
+// bottleSizes is a table (bottle_id, size) of the size of each bottle (the sum of the sizes of its parts).
+const bottleSizes = `(SELECT bottle_id, SUM(size) AS size FROM parts WHERE deleted_at IS NULL GROUP BY bottle_id)`
+
+// ValidateTransferQuery validates the interval, limit, label selectors, and time range of the query.
+func ValidateTransferQuery(q types.TransferQuery) error {
+	multiError := errors.Join(validateInterval(q.Interval), validateEventFilter(q.EventFilter))
+	if q.Limit < 0 {
+		multiError = errors.Join(multiError, fmt.Errorf("invalid param \"limit\" (%d): must not be negative", q.Limit))
+	}
+	return multiError
+}
+
+// TransferStats computes the bytes transferred by the events that match the query for each bottle, repository, and user
+// and the average throughput of each repository over time.
+// See types.TransferStats for which expired events are included.
+// The query must be valid (see ValidateTransferQuery).
+func TransferStats(con *gorm.DB, query types.TransferQuery) (*types.TransferStats, error) {
+	if query.Interval == "" {
+		query.Interval = types.IntervalDay
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultTransferLimit
+	}
+
+	stats := &types.TransferStats{Interval: query.Interval}
+
+	// each row of a transfers table is a bottle, repository, and user with a number of events
+	retained := con.Model(&Event{}).
+		Scopes(filterEvents(query.EventFilter)).
+		Select("events.bottle_id, events.bottle_digest, events.repository, events.username, 1 AS events")
+	all := retained
+	if query.Start.IsZero() && query.End.IsZero() {
+		// expired events are only counted by bottle and user (see EventCount) and their times are unknown
+		expired := con.Model(&EventCount{}).
+			Scopes(filterEventCounts(query.EventFilter)).
+			Select("event_counts.bottle_id, event_counts.bottle_digest, '' AS repository, event_counts.username, event_counts.count AS events")
+		all = con.Raw("? UNION ALL ?", retained, expired)
+	}
+
+	totals := func(transfers *gorm.DB, column string) ([]types.TransferTotal, error) {
+		results := []types.TransferTotal{}
+		err := con.Table("(?) AS transfers", transfers).
+			Joins("LEFT JOIN " + bottleSizes + " bottle_sizes ON bottle_sizes.bottle_id = transfers.bottle_id").
+			Select(column + " AS name, SUM(transfers.events) AS events, CAST(COALESCE(SUM(transfers.events * bottle_sizes.size), 0) AS BIGINT) AS bytes").
+			Group(column).
+			Order("bytes DESC, name").
+			Limit(query.Limit).
+			Scan(&results).Error
+		return results, err
+	}
+
+	var err error
+	if stats.Bottles, err = totals(all, "transfers.bottle_digest"); err != nil {
+		return nil, err
+	}
+	if stats.Repositories, err = totals(retained, "transfers.repository"); err != nil {
+		return nil, err
+	}
+	if stats.Users, err = totals(all, "transfers.username"); err != nil {
+		return nil, err
+	}
+
+	bucket, err := truncateTimestamp(con, query.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		Bucket     string
+		Repository string
+		Events     int64
+		Bandwidth  float64
+	}
+
+	// events without a bandwidth did not report it
+	var results []result
+	if err := con.Model(&Event{}).
+		Scopes(filterEvents(query.EventFilter)).
+		Where("events.bandwidth > 0").
+		Select(bucket + " AS bucket, events.repository AS repository, COUNT(*) AS events, CAST(AVG(events.bandwidth) AS DOUBLE PRECISION) AS bandwidth").
+		Group("bucket, events.repository").
+		Order("events.repository, bucket").
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	stats.Throughput = []types.ThroughputSeries{}
+	for i, r := range results {
+		start, err := time.Parse(bucketLayout, r.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time bucket %q: %w", r.Bucket, err)
+		}
+		// results are ordered by repository so a new repository starts a new series
+		if i == 0 || r.Repository != results[i-1].Repository {
+			stats.Throughput = append(stats.Throughput, types.ThroughputSeries{Repository: r.Repository})
+		}
+		series := &stats.Throughput[len(stats.Throughput)-1]
+		series.Buckets = append(series.Buckets, types.ThroughputBucket{Start: start, Events: r.Events, Bandwidth: r.Bandwidth})
+	}
+	return stats, nil
+}
@@ -1,14 +1,163 @@
 package db
 
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
 // TrustAnchor is a source which we can validate an signature owner's identity against.
 type TrustAnchor interface {
-	VerifyTrust() bool
+	VerifyTrust(sig *Signature) bool
+}
+
+// TrustPolicy is a TrustAnchor built from the server's trust configuration.
+// The zero value (and a nil pointer) trusts nothing.
+type TrustPolicy struct {
+	fingerprints map[digest.Digest]struct{}
+	roots        *x509.CertPool
+	identities   []v1alpha2.IdentityConstraint
+}
+
+// NewTrustPolicy creates a TrustPolicy from the configuration.
+func NewTrustPolicy(conf v1alpha2.Trust) (*TrustPolicy, error) {
+	p := &TrustPolicy{
+		fingerprints: make(map[digest.Digest]struct{}, len(conf.Fingerprints)),
+	}
+
+	for _, fp := range conf.Fingerprints {
+		d, err := digest.Parse(fp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted fingerprint %q: %w", fp, err)
+		}
+		p.fingerprints[d] = struct{}{}
+	}
+
+	if len(conf.RootCAs) > 0 {
+		p.roots = x509.NewCertPool()
+		for i, ca := range conf.RootCAs {
+			if !p.roots.AppendCertsFromPEM([]byte(ca)) {
+				return nil, fmt.Errorf("no PEM encoded certificates found in trusted root CA %d", i)
+			}
+		}
+	}
+
+	for _, id := range conf.Identities {
+		if id.Annotation == "" {
+			return nil, errors.New("identity constraint is missing the annotation key")
+		}
+		if _, err := path.Match(id.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q for identity annotation %q: %w", id.Pattern, id.Annotation, err)
+		}
+	}
+	p.identities = conf.Identities
+
+	return p, nil
+}
+
+// VerifyTrust returns true if the signature's key is anchored by the policy and the signer's identity satisfies all the identity constraints.
+// The signature's annotations must be loaded.
+func (p *TrustPolicy) VerifyTrust(sig *Signature) bool {
+	if p == nil {
+		return false
+	}
+	return p.isAnchored(sig) && p.isIdentityAllowed(sig)
+}
+
+// isAnchored returns true if the signature was made with a trusted key or a certificate that chains to a trusted root.
+func (p *TrustPolicy) isAnchored(sig *Signature) bool {
+	if _, ok := p.fingerprints[sig.PublicKeyFingerPrint]; ok {
+		return true
+	}
+
+	if p.roots == nil {
+		return false
+	}
+
+	chain, signingTime := signerCertificates(sig)
+	if len(chain) == 0 {
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         p.roots,
+		Intermediates: intermediates,
+		CurrentTime:   signingTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// isIdentityAllowed returns true if the signature annotations satisfy every identity constraint.
+func (p *TrustPolicy) isIdentityAllowed(sig *Signature) bool {
+	for _, id := range p.identities {
+		matched := false
+		for _, a := range sig.Annotations {
+			if a.Key != id.Annotation {
+				continue
+			}
+			if ok, _ := path.Match(id.Pattern, a.Value); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
 }
 
-// DefaultTrustAnchor can be used to get a trusted value back when no other trust anchor is available.
-type DefaultTrustAnchor struct{}
+// signerCertificates returns the certificate chain (leaf first) used to make the signature along with the time of signing.
+// Notary signatures carry the chain in the signature envelope while other signatures may have PEM encoded certificates in the public key.
+func signerCertificates(sig *Signature) ([]*x509.Certificate, time.Time) {
+	if sig.SignatureType == types.NotarySignatureType {
+		for _, parse := range []func([]byte) (signature.Envelope, error){jws.ParseEnvelope, cose.ParseEnvelope} {
+			env, err := parse(sig.Signature)
+			if err != nil {
+				continue
+			}
+			content, err := env.Content()
+			if err != nil {
+				return nil, time.Time{}
+			}
+			return content.SignerInfo.CertificateChain, content.SignerInfo.SignedAttributes.SigningTime
+		}
+		return nil, time.Time{}
+	}
 
-// VerifyTrust on the DefaultTrustAnchor always returns false.
-func (t *DefaultTrustAnchor) VerifyTrust() bool {
-	return false
+	var chain []*x509.Certificate
+	rest := []byte(sig.PublicKey)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, time.Time{}
+		}
+		chain = append(chain, cert)
+	}
+	return chain, sig.CreatedAt
 }
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+type TrustTestSuite struct {
+	suite.Suite
+}
+
+func (s *TrustTestSuite) TestEmptyPolicy() {
+	p, err := NewTrustPolicy(v1alpha2.Trust{})
+	s.NoError(err)
+
+	sig := &Signature{PublicKeyFingerPrint: digest.FromString("key")}
+	s.False(sig.Trusted(p))
+	s.False(sig.Trusted(&TrustPolicy{}))
+}
+
+func (s *TrustTestSuite) TestFingerprintAndIdentity() {
+	fp := digest.FromString("key")
+	p, err := NewTrustPolicy(v1alpha2.Trust{
+		Fingerprints: []string{fp.String()},
+		Identities: []v1alpha2.IdentityConstraint{
+			{Annotation: "email", Pattern: "*@example.com"},
+		},
+	})
+	s.NoError(err)
+
+	sig := &Signature{
+		PublicKeyFingerPrint: fp,
+		Annotations:          []SignatureAnnotation{{Key: "email", Value: "alice@example.com"}},
+	}
+	s.True(sig.Trusted(p))
+
+	sig.Annotations = []SignatureAnnotation{{Key: "email", Value: "mallory@example.org"}}
+	s.False(sig.Trusted(p))
+
+	sig.Annotations = nil
+	s.False(sig.Trusted(p))
+
+	sig.Annotations = []SignatureAnnotation{{Key: "email", Value: "alice@example.com"}}
+	sig.PublicKeyFingerPrint = digest.FromString("other key")
+	s.False(sig.Trusted(p))
+}
+
+func (s *TrustTestSuite) TestRootCA() {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.NoError(err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	s.NoError(err)
+	caCert, err := x509.ParseCertificate(caDER)
+	s.NoError(err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.NoError(err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, &leafKey.PublicKey, caKey)
+	s.NoError(err)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	leafPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+
+	p, err := NewTrustPolicy(v1alpha2.Trust{RootCAs: []string{string(caPEM)}})
+	s.NoError(err)
+
+	sig := &Signature{
+		SignatureType: types.CosignSignatureType,
+		PublicKey:     string(leafPEM),
+	}
+	sig.CreatedAt = time.Now()
+	s.True(sig.Trusted(p))
+
+	// a self-signed certificate does not chain to the root
+	selfDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, leafTemplate, &leafKey.PublicKey, leafKey)
+	s.NoError(err)
+	sig.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: selfDER}))
+	s.False(sig.Trusted(p))
+
+	_, err = NewTrustPolicy(v1alpha2.Trust{RootCAs: []string{"not a certificate"}})
+	s.Error(err)
+}
+
+func (s *TrustTestSuite) TestInvalidConfiguration() {
+	_, err := NewTrustPolicy(v1alpha2.Trust{Fingerprints: []string{"bogus"}})
+	s.Error(err)
+
+	_, err = NewTrustPolicy(v1alpha2.Trust{Identities: []v1alpha2.IdentityConstraint{{Pattern: "*"}}})
+	s.Error(err)
+
+	_, err = NewTrustPolicy(v1alpha2.Trust{Identities: []v1alpha2.IdentityConstraint{{Annotation: "email", Pattern: "["}}})
+	s.Error(err)
+}
+
+func TestTrustTestSuite(t *testing.T) {
+	suite.Run(t, new(TrustTestSuite))
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"time"
+)
+
+// Webhook delivery statuses.
+const (
+	// WebhookDeliveryPending is a delivery that has not been delivered yet (it may have failed attempts).
+	WebhookDeliveryPending = "pending"
+
+	// WebhookDeliveryDelivered is a delivery that the webhook accepted.
+	WebhookDeliveryDelivered = "delivered"
+
+	// WebhookDeliveryFailed is a delivery that will not be attempted again.
+	WebhookDeliveryFailed = "failed"
+)
+
+// WebhookDelivery is a payload to be POSTed to a webhook.
+// The table is both the durable retry queue and the delivery log.
+type WebhookDelivery struct {
+	Model
+
+	Webhook string `gorm:"index"` // name of the webhook in the server configuration
+	URL     string // URL of the webhook when the delivery was queued
+	Event   string // webhook event (e.g., "deprecation")
+	Payload []byte // JSON payload, it is signed when it is sent
+
+	Status        string    `gorm:"index:idx_webhook_deliveries_due"`
+	NextAttemptAt time.Time `gorm:"index:idx_webhook_deliveries_due"` // also used as a lease while a delivery is in flight
+	Attempts      uint
+
+	LastStatusCode int    // HTTP status code of the last attempt (zero if no response was received)
+	LastError      string // reason the last attempt failed
+	DeliveredAt    *time.Time
+}
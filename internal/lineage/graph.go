@@ -0,0 +1,190 @@
+package lineage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+var dotStringReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+
+// dotString returns the string as a quoted DOT ID.
+func dotString(s string) string {
+	return `"` + dotStringReplacer.Replace(s) + `"`
+}
+
+// nodeLabel returns the text to display for the node.
+func nodeLabel(node types.LineageNode) string {
+	label := shortID(node)
+	if node.Description != "" {
+		description := node.Description
+		if runes := []rune(description); len(runes) > 40 {
+			description = string(runes[:40]) + "..."
+		}
+		label += "\n" + description
+	}
+	return label
+}
+
+// edgeLabel returns the text to display for the edge.
+func edgeLabel(edge types.LineageEdge) string {
+	label := edge.Name
+	if len(edge.PartSelectors) > 0 {
+		label += "\n" + strings.Join(edge.PartSelectors, " | ")
+	}
+	return label
+}
+
+// writeDOT writes the lineage as a GraphViz DOT digraph (from left to right with ancestors first).
+// The bottle of the lineage is bold, bottles not known to the telemetry server are dashed, and other sources are ellipses.
+func writeDOT(w io.Writer, lineage *types.Lineage) error {
+	var b strings.Builder
+	b.WriteString("digraph lineage {\n\trankdir=LR;\n\tnode [shape=box];\n")
+
+	for _, node := range lineage.Nodes {
+		attrs := []string{"label=" + dotString(nodeLabel(node))}
+		switch node.Kind {
+		case types.LineageNodeURI:
+			attrs = append(attrs, "shape=ellipse", "URL="+dotString(node.ID))
+		case types.LineageNodeUnknownBottle:
+			attrs = append(attrs, "style=dashed")
+		default:
+			if node.Generation == 0 {
+				attrs = append(attrs, "style=bold")
+			}
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", dotString(node.ID), strings.Join(attrs, ", "))
+	}
+
+	for _, edge := range lineage.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s];\n", dotString(edge.From), dotString(edge.To), dotString(edgeLabel(edge)))
+	}
+
+	b.WriteString("}\n")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing DOT: %w", err)
+	}
+	return nil
+}
+
+// graphML is a GraphML document (see http://graphml.graphdrawing.org/).
+type graphML struct {
+	XMLName xml.Name     `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys are the attributes of the nodes and edges.
+var graphMLKeys = []graphMLKey{
+	{ID: "kind", For: "node", AttrName: "kind", AttrType: "string"},
+	{ID: "generation", For: "node", AttrName: "generation", AttrType: "int"},
+	{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+	{ID: "description", For: "node", AttrName: "description", AttrType: "string"},
+	{ID: "digests", For: "node", AttrName: "digests", AttrType: "string"},
+	{ID: "labels", For: "node", AttrName: "labels", AttrType: "string"},
+	{ID: "authors", For: "node", AttrName: "authors", AttrType: "string"},
+	{ID: "name", For: "edge", AttrName: "name", AttrType: "string"},
+	{ID: "partSelectors", For: "edge", AttrName: "partSelectors", AttrType: "string"},
+}
+
+// writeGraphML writes the lineage as GraphML.  Lists (e.g., the digests of a bottle) are separated by spaces and part selectors by " | ".
+func writeGraphML(w io.Writer, lineage *types.Lineage) error {
+	doc := graphML{
+		Keys: graphMLKeys,
+		Graph: graphMLGraph{
+			ID:          "lineage",
+			EdgeDefault: "directed",
+		},
+	}
+
+	for _, node := range lineage.Nodes {
+		n := graphMLNode{
+			ID: node.ID,
+			Data: []graphMLData{
+				{Key: "kind", Value: string(node.Kind)},
+				{Key: "generation", Value: strconv.Itoa(node.Generation)},
+				{Key: "label", Value: shortID(node)},
+			},
+		}
+		if node.Description != "" {
+			n.Data = append(n.Data, graphMLData{Key: "description", Value: node.Description})
+		}
+		if len(node.Digests) > 0 {
+			digests := make([]string, len(node.Digests))
+			for i, d := range node.Digests {
+				digests[i] = d.String()
+			}
+			n.Data = append(n.Data, graphMLData{Key: "digests", Value: strings.Join(digests, " ")})
+		}
+		if len(node.Labels) > 0 {
+			n.Data = append(n.Data, graphMLData{Key: "labels", Value: strings.Join(sortedLabels(node.Labels), " ")})
+		}
+		if len(node.Authors) > 0 {
+			authors := make([]string, len(node.Authors))
+			for i, a := range node.Authors {
+				authors[i] = a.Name
+			}
+			n.Data = append(n.Data, graphMLData{Key: "authors", Value: strings.Join(authors, ", ")})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, n)
+	}
+
+	for _, edge := range lineage.Edges {
+		e := graphMLEdge{
+			Source: edge.From,
+			Target: edge.To,
+			Data:   []graphMLData{{Key: "name", Value: edge.Name}},
+		}
+		if len(edge.PartSelectors) > 0 {
+			e.Data = append(e.Data, graphMLData{Key: "partSelectors", Value: strings.Join(edge.PartSelectors, " | ")})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, e)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("writing GraphML: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encoding GraphML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("writing GraphML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package lineage exports the lineage graphs of bottles (see types.Lineage) in standard formats.
+// W3C PROV (as PROV-JSON or PROV-O in Turtle) is intended for provenance tools and GraphViz DOT and GraphML for graph tools.
+package lineage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// Format is a format to export a lineage graph in.
+type Format string
+
+const (
+	// FormatJSON is the JSON of types.Lineage (as returned by the REST API).
+	FormatJSON Format = "json"
+
+	// FormatPROVJSON is W3C PROV-JSON.
+	FormatPROVJSON Format = "prov-json"
+
+	// FormatPROVO is W3C PROV-O serialized as Turtle.
+	FormatPROVO Format = "prov-o"
+
+	// FormatDOT is GraphViz DOT.
+	FormatDOT Format = "dot"
+
+	// FormatGraphML is GraphML.
+	FormatGraphML Format = "graphml"
+)
+
+// Formats are all the supported formats.
+var Formats = []Format{FormatJSON, FormatPROVJSON, FormatPROVO, FormatDOT, FormatGraphML}
+
+// ParseFormat returns the format with the name or an error if the format is not supported.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unsupported lineage format %q (supported formats are %s)", name, strings.Join(names, ", "))
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPROVO:
+		return "text/turtle"
+	case FormatDOT:
+		return "text/vnd.graphviz"
+	case FormatGraphML:
+		return "application/graphml+xml"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the file extension (with the leading ".") of the format.
+func (f Format) Extension() string {
+	switch f {
+	case FormatPROVJSON:
+		return ".prov.json"
+	case FormatPROVO:
+		return ".ttl"
+	case FormatDOT:
+		return ".dot"
+	case FormatGraphML:
+		return ".graphml"
+	default:
+		return ".json"
+	}
+}
+
+// Write writes the lineage to w in the format.
+func Write(w io.Writer, lineage *types.Lineage, format Format) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, lineage)
+	case FormatPROVJSON:
+		return writeJSON(w, newPROVDocument(lineage))
+	case FormatPROVO:
+		return writePROVO(w, lineage)
+	case FormatDOT:
+		return writeDOT(w, lineage)
+	case FormatGraphML:
+		return writeGraphML(w, lineage)
+	default:
+		_, err := ParseFormat(string(format))
+		return err
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("encoding lineage: %w", err)
+	}
+	return nil
+}
+
+// nodeIRI returns the IRI of the node.  Bottles use the "bottle:" URI scheme of bottle sources.
+func nodeIRI(node types.LineageNode) string {
+	if node.Kind == types.LineageNodeURI {
+		return node.ID
+	}
+	return "bottle:" + node.ID
+}
+
+// authorKey identifies an author across bottles (by email if known, otherwise by name).
+func authorKey(author types.Author) string {
+	if author.Email != "" {
+		return "email:" + author.Email
+	}
+	return "name:" + author.Name
+}
+
+// shortID abbreviates the ID of a bottle for display (URIs are left as is).
+func shortID(node types.LineageNode) string {
+	if node.Kind == types.LineageNodeURI {
+		return node.ID
+	}
+	alg, encoded, found := strings.Cut(node.ID, ":")
+	if !found || len(encoded) <= 12 {
+		return node.ID
+	}
+	return alg + ":" + encoded[:12]
+}
@@ -0,0 +1,112 @@
+package lineage
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+const (
+	parentID  = "sha256:1c62b7c436992270b5ac7fc683debef0bbf461fe296d85d823ffc18f68dae33d"
+	childID   = "sha256:eb7e9899b608573061edbf85340300ff5548734a0028ba607be98367f221647e"
+	unknownID = "sha256:42a8efd3483c60a4364d3f6f328ee1897facdbffb043b51941424a34121bbbe9"
+	sourceURI = "http://data.example.com/for-bottle-2"
+)
+
+func testLineage() *types.Lineage {
+	return &types.Lineage{
+		Digest: childID,
+		Nodes: []types.LineageNode{
+			{
+				ID: childID, Kind: types.LineageNodeBottle, Digests: []digest.Digest{childID},
+				Description: `A "derived" dataset`, Labels: map[string]string{"type": "testing"},
+				Authors: []types.Author{{Name: "Jane Smith", Email: "jane.smith@example.com"}},
+			},
+			{
+				ID: parentID, Kind: types.LineageNodeBottle, Generation: -1, Digests: []digest.Digest{parentID},
+				Authors: []types.Author{{Name: "Jane Smith", Email: "jane.smith@example.com"}, {Name: "John Doe"}},
+			},
+			{ID: sourceURI, Kind: types.LineageNodeURI, Generation: -1},
+			{ID: unknownID, Kind: types.LineageNodeUnknownBottle, Generation: -2},
+		},
+		Edges: []types.LineageEdge{
+			{From: parentID, To: childID, Name: "Training dataset", PartSelectors: []string{"label2=otherlabel", "also=notthere"}},
+			{From: sourceURI, To: childID, Name: "Original"},
+			{From: unknownID, To: parentID, Name: "Unknown"},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		format, err := ParseFormat(string(f))
+		assert.NoError(t, err)
+		assert.Equal(t, f, format)
+	}
+	_, err := ParseFormat("svg")
+	assert.ErrorContains(t, err, "graphml")
+}
+
+func TestWrite(t *testing.T) {
+	write := func(format Format) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, testLineage(), format))
+		return buf.Bytes()
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var lineage types.Lineage
+		require.NoError(t, json.Unmarshal(write(FormatJSON), &lineage))
+		assert.Equal(t, *testLineage(), lineage)
+	})
+
+	t.Run("prov-json", func(t *testing.T) {
+		var doc provDocument
+		require.NoError(t, json.Unmarshal(write(FormatPROVJSON), &doc))
+		assert.Len(t, doc.Entity, 4)
+		assert.Contains(t, doc.Entity, "bottle:"+childID)
+		assert.Contains(t, doc.Entity, "uri2:")
+		assert.Equal(t, sourceURI, doc.Prefix["uri2"])
+		// the same author is one agent
+		assert.Len(t, doc.Agent, 2)
+		assert.Len(t, doc.WasAttributedTo, 3)
+		require.Len(t, doc.WasDerivedFrom, 3)
+		derivation := doc.WasDerivedFrom["_:derivation0"]
+		assert.Equal(t, "bottle:"+childID, derivation["prov:generatedEntity"])
+		assert.Equal(t, "bottle:"+parentID, derivation["prov:usedEntity"])
+		assert.Equal(t, []any{"label2=otherlabel", "also=notthere"}, derivation["telemetry:partSelector"])
+	})
+
+	t.Run("prov-o", func(t *testing.T) {
+		ttl := string(write(FormatPROVO))
+		assert.Contains(t, ttl, "<bottle:"+childID+"> a prov:Entity, telemetry:Bottle ;\n\trdfs:label \"A \\\"derived\\\" dataset\"")
+		assert.Contains(t, ttl, "prov:wasDerivedFrom <"+sourceURI+">")
+		assert.Contains(t, ttl, "telemetry:partSelector \"label2=otherlabel\", \"also=notthere\"")
+		assert.Contains(t, ttl, "<bottle:"+unknownID+"> a prov:Entity, telemetry:UnknownBottle .")
+		assert.Contains(t, ttl, "_:author1 a prov:Agent, prov:Person ;\n\trdfs:label \"John Doe\" .")
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		dot := string(write(FormatDOT))
+		assert.Contains(t, dot, `"`+parentID+`" -> "`+childID+`" [label="Training dataset\nlabel2=otherlabel | also=notthere"];`)
+		assert.Contains(t, dot, `"`+sourceURI+`" [label="`+sourceURI+`", shape=ellipse, URL="`+sourceURI+`"];`)
+		assert.Contains(t, dot, `label="sha256:eb7e9899b608\nA \"derived\" dataset", style=bold`)
+	})
+
+	t.Run("graphml", func(t *testing.T) {
+		var doc graphML
+		require.NoError(t, xml.Unmarshal(write(FormatGraphML), &doc))
+		assert.Len(t, doc.Graph.Nodes, 4)
+		require.Len(t, doc.Graph.Edges, 3)
+		assert.Equal(t, unknownID, doc.Graph.Edges[2].Source)
+		assert.Contains(t, doc.Graph.Nodes[1].Data, graphMLData{Key: "authors", Value: "Jane Smith, John Doe"})
+	})
+}
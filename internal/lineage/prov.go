@@ -0,0 +1,236 @@
+package lineage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// telemetryNamespace is the namespace of the types and attributes that are not part of PROV.
+const telemetryNamespace = "urn:telemetry:"
+
+// provDocument is a PROV-JSON document (see https://www.w3.org/submissions/prov-json/).
+type provDocument struct {
+	Prefix          map[string]string         `json:"prefix"`
+	Entity          map[string]provAttributes `json:"entity,omitempty"`
+	Agent           map[string]provAttributes `json:"agent,omitempty"`
+	WasDerivedFrom  map[string]provAttributes `json:"wasDerivedFrom,omitempty"`
+	WasAttributedTo map[string]provAttributes `json:"wasAttributedTo,omitempty"`
+}
+
+type provAttributes map[string]any
+
+// provQualifiedName is a PROV-JSON value that is a qualified name (instead of a string).
+func provQualifiedName(name string) map[string]string {
+	return map[string]string{"$": name, "type": "prov:QUALIFIED_NAME"}
+}
+
+// sortedLabels returns the labels as "key=value" sorted by key.
+func sortedLabels(labels map[string]string) []string {
+	result := make([]string, 0, len(labels))
+	for k, v := range labels {
+		result = append(result, k+"="+v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// newPROVDocument converts the lineage to PROV-JSON.
+// Bottles and other sources are PROV entities, sources are PROV derivations (with the name and part selectors of the source), and authors are PROV agents.
+func newPROVDocument(lineage *types.Lineage) provDocument {
+	doc := provDocument{
+		Prefix: map[string]string{
+			"bottle":    "bottle:",
+			"telemetry": telemetryNamespace,
+		},
+		Entity:          map[string]provAttributes{},
+		Agent:           map[string]provAttributes{},
+		WasDerivedFrom:  map[string]provAttributes{},
+		WasAttributedTo: map[string]provAttributes{},
+	}
+
+	ids := make(map[string]string, len(lineage.Nodes))
+	agents := map[string]string{}
+	for _, node := range lineage.Nodes {
+		id := nodeIRI(node)
+		attrs := provAttributes{}
+		switch node.Kind {
+		case types.LineageNodeURI:
+			// a URI is not a valid qualified name so each URI gets a prefix (and the local part is empty)
+			prefix := fmt.Sprintf("uri%d", len(ids))
+			doc.Prefix[prefix] = node.ID
+			id = prefix + ":"
+		case types.LineageNodeUnknownBottle:
+			attrs["prov:type"] = provQualifiedName("telemetry:UnknownBottle")
+		default:
+			attrs["prov:type"] = provQualifiedName("telemetry:Bottle")
+			if node.Description != "" {
+				attrs["prov:label"] = node.Description
+			}
+			digests := make([]string, len(node.Digests))
+			for i, d := range node.Digests {
+				digests[i] = d.String()
+			}
+			attrs["telemetry:digest"] = digests
+			if len(node.Labels) > 0 {
+				attrs["telemetry:label"] = sortedLabels(node.Labels)
+			}
+		}
+		ids[node.ID] = id
+		doc.Entity[id] = attrs
+
+		for _, author := range node.Authors {
+			key := authorKey(author)
+			agentID, ok := agents[key]
+			if !ok {
+				agentID = fmt.Sprintf("telemetry:author%d", len(agents))
+				agents[key] = agentID
+				agent := provAttributes{
+					"prov:type":  provQualifiedName("prov:Person"),
+					"prov:label": author.Name,
+				}
+				if author.Email != "" {
+					agent["telemetry:email"] = author.Email
+				}
+				if author.URL != "" {
+					agent["telemetry:url"] = author.URL
+				}
+				doc.Agent[agentID] = agent
+			}
+			doc.WasAttributedTo[fmt.Sprintf("_:attribution%d", len(doc.WasAttributedTo))] = provAttributes{
+				"prov:entity": id,
+				"prov:agent":  agentID,
+			}
+		}
+	}
+
+	for i, edge := range lineage.Edges {
+		derivation := provAttributes{
+			"prov:generatedEntity": ids[edge.To],
+			"prov:usedEntity":      ids[edge.From],
+		}
+		if edge.Name != "" {
+			derivation["prov:label"] = edge.Name
+		}
+		if len(edge.PartSelectors) > 0 {
+			derivation["telemetry:partSelector"] = edge.PartSelectors
+		}
+		doc.WasDerivedFrom[fmt.Sprintf("_:derivation%d", i)] = derivation
+	}
+
+	return doc
+}
+
+// turtleIRI returns the IRI in Turtle syntax (characters that are not allowed are escaped).
+func turtleIRI(iri string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range iri {
+		if r <= ' ' || strings.ContainsRune("<>\"{}|^`\\", r) {
+			fmt.Fprintf(&b, "\\u%04X", r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+var turtleStringReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// turtleString returns the string as a Turtle literal.
+func turtleString(s string) string {
+	return `"` + turtleStringReplacer.Replace(s) + `"`
+}
+
+// turtleStrings returns the strings as a list of Turtle literals (objects of the same predicate).
+func turtleStrings(values []string) string {
+	literals := make([]string, len(values))
+	for i, v := range values {
+		literals[i] = turtleString(v)
+	}
+	return strings.Join(literals, ", ")
+}
+
+// writePROVO writes the lineage as PROV-O in Turtle (with the same mapping as newPROVDocument).
+func writePROVO(w io.Writer, lineage *types.Lineage) error {
+	var b strings.Builder
+	b.WriteString("@prefix prov: <http://www.w3.org/ns/prov#> .\n")
+	b.WriteString("@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .\n")
+	fmt.Fprintf(&b, "@prefix telemetry: <%s> .\n", telemetryNamespace)
+
+	iris := make(map[string]string, len(lineage.Nodes))
+	for _, node := range lineage.Nodes {
+		iris[node.ID] = turtleIRI(nodeIRI(node))
+	}
+
+	agents := map[string]string{}
+	var agentStatements []string
+	for _, node := range lineage.Nodes {
+		statement := []string{"a prov:Entity"}
+		switch node.Kind {
+		case types.LineageNodeUnknownBottle:
+			statement[0] += ", telemetry:UnknownBottle"
+		case types.LineageNodeBottle:
+			statement[0] += ", telemetry:Bottle"
+			if node.Description != "" {
+				statement = append(statement, "rdfs:label "+turtleString(node.Description))
+			}
+			digests := make([]string, len(node.Digests))
+			for i, d := range node.Digests {
+				digests[i] = d.String()
+			}
+			statement = append(statement, "telemetry:digest "+turtleStrings(digests))
+			if len(node.Labels) > 0 {
+				statement = append(statement, "telemetry:label "+turtleStrings(sortedLabels(node.Labels)))
+			}
+		}
+
+		for _, author := range node.Authors {
+			key := authorKey(author)
+			agent, ok := agents[key]
+			if !ok {
+				agent = fmt.Sprintf("_:author%d", len(agents))
+				agents[key] = agent
+				agentStatement := []string{"a prov:Agent, prov:Person", "rdfs:label " + turtleString(author.Name)}
+				if author.Email != "" {
+					agentStatement = append(agentStatement, "telemetry:email "+turtleString(author.Email))
+				}
+				if author.URL != "" {
+					agentStatement = append(agentStatement, "telemetry:url "+turtleString(author.URL))
+				}
+				agentStatements = append(agentStatements, agent+" "+strings.Join(agentStatement, " ;\n\t")+" .\n")
+			}
+			statement = append(statement, "prov:wasAttributedTo "+agent)
+		}
+
+		for _, edge := range lineage.Edges {
+			if edge.To != node.ID {
+				continue
+			}
+			statement = append(statement, "prov:wasDerivedFrom "+iris[edge.From])
+			derivation := []string{"a prov:Derivation", "prov:entity " + iris[edge.From]}
+			if edge.Name != "" {
+				derivation = append(derivation, "rdfs:label "+turtleString(edge.Name))
+			}
+			if len(edge.PartSelectors) > 0 {
+				derivation = append(derivation, "telemetry:partSelector "+turtleStrings(edge.PartSelectors))
+			}
+			statement = append(statement, "prov:qualifiedDerivation [\n\t\t"+strings.Join(derivation, " ;\n\t\t")+"\n\t]")
+		}
+
+		fmt.Fprintf(&b, "\n%s %s .\n", iris[node.ID], strings.Join(statement, " ;\n\t"))
+	}
+
+	for _, s := range agentStatements {
+		b.WriteString("\n" + s)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing PROV-O: %w", err)
+	}
+	return nil
+}
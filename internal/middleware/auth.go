@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zitadel/oidc/v3/pkg/client"
+	"github.com/zitadel/oidc/v3/pkg/client/rp"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+// Principal is an authenticated user.
+type Principal struct {
+	Username string
+	Groups   []string
+}
+
+// matches returns true if the principal satisfies the rule.  A nil principal is anonymous and never matches.
+func (p *Principal) matches(rule v1alpha2.AccessRule) bool {
+	if p == nil {
+		return false
+	}
+	if rule.Authenticated || slices.Contains(rule.Users, p.Username) {
+		return true
+	}
+	return slices.ContainsFunc(p.Groups, func(group string) bool {
+		return slices.Contains(rule.Groups, group)
+	})
+}
+
+// Authenticator validates OIDC bearer tokens (JWTs) and authorizes requests with the configured access rules.
+type Authenticator struct {
+	conf   v1alpha2.Auth
+	client *http.Client
+
+	mu     sync.Mutex
+	keySet oidc.KeySet
+}
+
+// NewAuthenticator creates an Authenticator.  It returns nil when authentication is disabled (no issuer).
+// The issuer's signing keys are discovered when the first token is validated.
+func NewAuthenticator(conf v1alpha2.Auth) *Authenticator {
+	if conf.Issuer == "" {
+		return nil
+	}
+	if conf.UsernameClaim == "" {
+		conf.UsernameClaim = "sub"
+	}
+	if conf.GroupsClaim == "" {
+		conf.GroupsClaim = "groups"
+	}
+	return &Authenticator{
+		conf:   conf,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// getKeySet returns the issuer's key set, discovering it if necessary.
+// Failed discovery is retried on the next call.
+func (a *Authenticator) getKeySet(ctx context.Context) (oidc.KeySet, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.keySet != nil {
+		return a.keySet, nil
+	}
+
+	discovery, err := client.Discover(ctx, a.conf.Issuer, a.client)
+	if err != nil {
+		return nil, fmt.Errorf("discovering OIDC issuer %s: %w", a.conf.Issuer, err)
+	}
+	a.keySet = rp.NewRemoteKeySet(a.client, discovery.JwksURI)
+	return a.keySet, nil
+}
+
+// authenticate validates the token and returns the principal it identifies.
+// Errors that are not client errors are returned when the token cannot be validated (e.g., the issuer is unavailable).
+func (a *Authenticator) authenticate(ctx context.Context, token string) (*Principal, error) {
+	invalid := func(err error) error {
+		return httputil.NewHTTPError(err, http.StatusUnauthorized, "Invalid bearer token")
+	}
+
+	claims := &oidc.AccessTokenClaims{}
+	payload, err := oidc.ParseToken(token, claims)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	if err := oidc.CheckIssuer(claims, a.conf.Issuer); err != nil {
+		return nil, invalid(err)
+	}
+	if a.conf.Audience != "" {
+		if err := oidc.CheckAudience(claims, a.conf.Audience); err != nil {
+			return nil, invalid(err)
+		}
+	}
+	if err := oidc.CheckExpiration(claims, 0); err != nil {
+		return nil, invalid(err)
+	}
+
+	keySet, err := a.getKeySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := oidc.CheckSignature(ctx, token, payload, claims, nil, keySet); err != nil {
+		return nil, invalid(err)
+	}
+
+	p := &Principal{}
+	if a.conf.UsernameClaim == "sub" {
+		p.Username = claims.Subject
+	} else if username, ok := claims.Claims[a.conf.UsernameClaim].(string); ok {
+		p.Username = username
+	}
+	if p.Username == "" {
+		return nil, invalid(fmt.Errorf("claim %q is missing", a.conf.UsernameClaim))
+	}
+
+	switch groups := claims.Claims[a.conf.GroupsClaim].(type) {
+	case []any:
+		for _, group := range groups {
+			if g, ok := group.(string); ok {
+				p.Groups = append(p.Groups, g)
+			}
+		}
+	case string:
+		p.Groups = strings.Fields(groups)
+	}
+
+	return p, nil
+}
+
+// isAdmin returns true if the principal is an admin.
+func (a *Authenticator) isAdmin(p *Principal) bool {
+	return p.matches(a.conf.Admin)
+}
+
+// canRead returns true if the principal may read.
+func (a *Authenticator) canRead(p *Principal) bool {
+	return a.conf.Read == nil || p.matches(*a.conf.Read) || a.isAdmin(p)
+}
+
+// canWrite returns true if the principal may upload objects of the type.
+func (a *Authenticator) canWrite(p *Principal, itemType string) bool {
+	if p == nil {
+		return false
+	}
+	rule, ok := a.conf.Write[itemType]
+	if !ok {
+		rule, ok = a.conf.Write["*"]
+	}
+	return !ok || p.matches(rule) || a.isAdmin(p)
+}
+
+// authKey is how we find the authentication state in a context.Context.
+type authKey struct{}
+
+type authState struct {
+	auth      *Authenticator
+	principal *Principal
+}
+
+// TokenCookie is the cookie that holds the bearer token of browsers (e.g., set by an authenticating proxy in front of the web app).
+// It is only used for reads so a cross-site request cannot use it to upload.
+const TokenCookie = "telemetry-token"
+
+// AuthMiddleware returns a middleware that authenticates the bearer token (if any) and enforces the read rule on GET and HEAD requests.
+// The token is taken from the Authorization header or, for reads, from the TokenCookie.
+// Write access is enforced by the handlers with AuthorizeWrite since it depends on the type of object.
+// When auth is nil every request is allowed.
+func AuthMiddleware(auth *Authenticator) middlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if auth == nil {
+			return next
+		}
+		return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
+			ctx := r.Context()
+			read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+			var token string
+			if header := r.Header.Get("Authorization"); header != "" {
+				var ok bool
+				token, ok = strings.CutPrefix(header, "Bearer ")
+				if !ok {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+					return httputil.NewHTTPError(nil, http.StatusUnauthorized, "Only bearer tokens are supported")
+				}
+			} else if cookie, err := r.Cookie(TokenCookie); err == nil && read {
+				token = cookie.Value
+			}
+
+			state := &authState{auth: auth}
+			if token != "" {
+				p, err := auth.authenticate(ctx, token)
+				if err != nil {
+					var clientErr httputil.ClientError
+					if errors.As(err, &clientErr) {
+						w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					}
+					return err
+				}
+				state.principal = p
+				logger.FromContext(ctx).DebugContext(ctx, "Authenticated", "username", p.Username, "groups", p.Groups)
+			}
+
+			if read && !auth.canRead(state.principal) {
+				return deny(state.principal, "read")
+			}
+
+			ctx = context.WithValue(ctx, authKey{}, state)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+	}
+}
+
+// deny returns a 401 error for anonymous requests and a 403 error otherwise.
+func deny(p *Principal, action string) error {
+	if p == nil {
+		return httputil.NewHTTPError(nil, http.StatusUnauthorized, "Authentication is required to "+action)
+	}
+	return httputil.NewHTTPError(nil, http.StatusForbidden, fmt.Sprintf("User %q is not allowed to %s", p.Username, action), "groups", p.Groups)
+}
+
+// PrincipalFromContext returns the authenticated user.  It returns nil for anonymous requests and when authentication is disabled.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	if state, ok := ctx.Value(authKey{}).(*authState); ok {
+		return state.principal
+	}
+	return nil
+}
+
+// AuthorizeWrite returns an error if the request is not allowed to upload objects of the type.
+func AuthorizeWrite(ctx context.Context, itemType string) error {
+	state, ok := ctx.Value(authKey{}).(*authState)
+	if !ok || state.auth.canWrite(state.principal, itemType) {
+		return nil
+	}
+	return deny(state.principal, "upload "+itemType+"s")
+}
+
+// AuthorizeUsername returns an error if the request is not allowed to act on behalf of the username (e.g., the username of an event).
+// Users may only act on behalf of themselves unless they are an admin.
+func AuthorizeUsername(ctx context.Context, username string) error {
+	state, ok := ctx.Value(authKey{}).(*authState)
+	if !ok || state.auth.isAdmin(state.principal) {
+		return nil
+	}
+	if state.principal == nil || state.principal.Username != username {
+		return deny(state.principal, fmt.Sprintf("act on behalf of %q", username))
+	}
+	return nil
+}
+
+// RequireAdmin returns a handler that only allows admins when authentication is enabled.
+// Use RequireAuthenticatedAdmin for destructive operations.
+func RequireAdmin(next http.Handler) http.Handler {
+	return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
+		if state, ok := r.Context().Value(authKey{}).(*authState); ok && !state.auth.isAdmin(state.principal) {
+			return deny(state.principal, "administer")
+		}
+		next.ServeHTTP(w, r)
+		return nil
+	})
+}
+
+// RequireAuthenticatedAdmin returns a handler that only allows admins.  Unlike RequireAdmin every request is denied when
+// authentication is disabled since nobody can be identified as an admin.
+func RequireAuthenticatedAdmin(next http.Handler) http.Handler {
+	return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
+		state, ok := r.Context().Value(authKey{}).(*authState)
+		if !ok {
+			return httputil.NewHTTPError(nil, http.StatusForbidden, "Authentication must be configured to administer")
+		}
+		if !state.auth.isAdmin(state.principal) {
+			return deny(state.principal, "administer")
+		}
+		next.ServeHTTP(w, r)
+		return nil
+	})
+}
@@ -0,0 +1,227 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/act3-ai/go-common/pkg/logger"
+	"github.com/act3-ai/go-common/pkg/test"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	ttest "github.com/act3-ai/data-telemetry/v3/internal/testing"
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+type AuthTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	issuer *ttest.Issuer
+	server *httptest.Server
+}
+
+func (s *AuthTestSuite) SetupTest() {
+	s.ctx = logger.NewContext(context.Background(), test.Logger(s.T(), 0))
+
+	var err error
+	s.issuer, err = ttest.NewIssuer()
+	s.Require().NoError(err)
+	s.T().Cleanup(s.issuer.Close)
+
+	auth := middleware.NewAuthenticator(v1alpha2.Auth{
+		Issuer:   s.issuer.URL,
+		Audience: "telemetry",
+		Read:     &v1alpha2.AccessRule{Authenticated: true},
+		Write: map[string]v1alpha2.AccessRule{
+			"bottle": {Groups: []string{"publishers"}},
+		},
+		Admin: v1alpha2.AccessRule{Users: []string{"root"}},
+	})
+
+	// the handler authorizes uploads the way the API does
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if r.Method == http.MethodPut {
+			itemType := r.URL.Query().Get("type")
+			if err := middleware.AuthorizeWrite(ctx, itemType); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if username := r.URL.Query().Get("username"); username != "" {
+				if err := middleware.AuthorizeUsername(ctx, username); err != nil {
+					http.Error(w, err.Error(), http.StatusForbidden)
+					return
+				}
+			}
+		}
+		if p := middleware.PrincipalFromContext(ctx); p != nil {
+			_, _ = w.Write([]byte(p.Username))
+		}
+	})
+
+	apiMux := http.NewServeMux()
+	apiMux.Handle("/", handler)
+	apiMux.Handle("/admin", middleware.RequireAdmin(handler))
+	apiMux.Handle("/takedown", middleware.RequireAuthenticatedAdmin(handler))
+	s.server = httptest.NewServer(middleware.AuthMiddleware(auth)(apiMux))
+	s.T().Cleanup(s.server.Close)
+}
+
+// token returns a signed token with the claims (merged with valid defaults).
+func (s *AuthTestSuite) token(claims map[string]any) string {
+	token, err := s.issuer.Token(claims)
+	s.Require().NoError(err)
+	return token
+}
+
+// request performs the request with the authorization header (if not empty) and returns the status code and body.
+func (s *AuthTestSuite) request(method, target, authorization string) (int, string) {
+	req, err := http.NewRequestWithContext(s.ctx, method, s.server.URL+target, nil)
+	s.Require().NoError(err)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	return resp.StatusCode, string(body)
+}
+
+func (s *AuthTestSuite) TestRead() {
+	code, _ := s.request(http.MethodGet, "/", "")
+	s.Equal(http.StatusUnauthorized, code)
+
+	code, body := s.request(http.MethodGet, "/", "Bearer "+s.token(nil))
+	s.Equal(http.StatusOK, code)
+	s.Equal("alice", body)
+
+	code, _ = s.request(http.MethodGet, "/", "Basic YWxpY2U6cGFzc3dvcmQ=")
+	s.Equal(http.StatusUnauthorized, code)
+}
+
+func (s *AuthTestSuite) TestInvalidToken() {
+	for name, claims := range map[string]map[string]any{
+		"expired":  {"exp": time.Now().Add(-time.Hour).Unix()},
+		"issuer":   {"iss": "https://example.com"},
+		"audience": {"aud": []string{"other"}},
+	} {
+		code, _ := s.request(http.MethodGet, "/", "Bearer "+s.token(claims))
+		s.Equal(http.StatusUnauthorized, code, name)
+	}
+
+	// tampered signature
+	token := s.token(nil)
+	code, _ := s.request(http.MethodGet, "/", "Bearer "+token[:len(token)-4]+"AAAA")
+	s.Equal(http.StatusUnauthorized, code)
+}
+
+func (s *AuthTestSuite) TestWrite() {
+	alice := "Bearer " + s.token(nil)
+	publisher := "Bearer " + s.token(map[string]any{"sub": "bob", "groups": []string{"publishers"}})
+
+	// types without a rule may be uploaded by any authenticated user
+	code, _ := s.request(http.MethodPut, "/?type=manifest", alice)
+	s.Equal(http.StatusOK, code)
+
+	code, _ = s.request(http.MethodPut, "/?type=bottle", alice)
+	s.Equal(http.StatusForbidden, code)
+
+	code, _ = s.request(http.MethodPut, "/?type=bottle", publisher)
+	s.Equal(http.StatusOK, code)
+
+	// users may only upload their own events
+	code, _ = s.request(http.MethodPut, "/?type=event&username=alice", alice)
+	s.Equal(http.StatusOK, code)
+
+	code, _ = s.request(http.MethodPut, "/?type=event&username=bob", alice)
+	s.Equal(http.StatusForbidden, code)
+}
+
+func (s *AuthTestSuite) TestAdmin() {
+	root := "Bearer " + s.token(map[string]any{"sub": "root"})
+
+	code, _ := s.request(http.MethodGet, "/admin", "Bearer "+s.token(nil))
+	s.Equal(http.StatusForbidden, code)
+
+	code, _ = s.request(http.MethodGet, "/admin", root)
+	s.Equal(http.StatusOK, code)
+
+	code, _ = s.request(http.MethodDelete, "/takedown", "")
+	s.Equal(http.StatusUnauthorized, code)
+
+	code, _ = s.request(http.MethodDelete, "/takedown", "Bearer "+s.token(nil))
+	s.Equal(http.StatusForbidden, code)
+
+	code, _ = s.request(http.MethodDelete, "/takedown", root)
+	s.Equal(http.StatusOK, code)
+
+	// admins bypass the write rules
+	code, _ = s.request(http.MethodPut, "/?type=bottle", root)
+	s.Equal(http.StatusOK, code)
+
+	code, _ = s.request(http.MethodPut, "/?type=event&username=alice", root)
+	s.Equal(http.StatusOK, code)
+}
+
+func (s *AuthTestSuite) TestCookie() {
+	cookie := &http.Cookie{Name: middleware.TokenCookie, Value: s.token(nil)}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, s.server.URL+"/", nil)
+	s.Require().NoError(err)
+	req.AddCookie(cookie)
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("alice", string(body))
+
+	// the cookie is not used for uploads (so a cross-site request cannot upload)
+	req, err = http.NewRequestWithContext(s.ctx, http.MethodPut, s.server.URL+"/?type=manifest", nil)
+	s.Require().NoError(err)
+	req.AddCookie(cookie)
+	resp, err = s.server.Client().Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+}
+
+func (s *AuthTestSuite) TestDisabled() {
+	s.Nil(middleware.NewAuthenticator(v1alpha2.Auth{}))
+
+	server := httptest.NewServer(middleware.AuthMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.NoError(middleware.AuthorizeWrite(r.Context(), "bottle"))
+		s.NoError(middleware.AuthorizeUsername(r.Context(), "anyone"))
+	})))
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPut, server.URL, nil)
+	s.Require().NoError(err)
+	resp, err := server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	// nobody is an admin
+	takedown := httptest.NewServer(middleware.AuthMiddleware(nil)(middleware.RequireAuthenticatedAdmin(http.NotFoundHandler())))
+	defer takedown.Close()
+
+	req, err = http.NewRequestWithContext(s.ctx, http.MethodDelete, takedown.URL, nil)
+	s.Require().NoError(err)
+	resp, err = takedown.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthTestSuite))
+}
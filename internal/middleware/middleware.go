@@ -2,17 +2,14 @@
 package middleware
 
 import (
-	"bytes"
 	"context"
-	"crypto/ed25519"
-	"encoding/base64"
-	"io"
 	"net/http"
 
 	"gorm.io/gorm"
 
-	"github.com/act3-ai/go-common/pkg/httputil"
 	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
 )
 
 type middlewareFunc = func(http.Handler) http.Handler
@@ -45,42 +42,24 @@ func DatabaseMiddleware(con *gorm.DB) middlewareFunc {
 	}
 }
 
-// SignatureVerifyMiddleware records timing metrics.
-func SignatureVerifyMiddleware(next http.Handler) http.Handler {
-	return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
-		bodyBytes, err := io.ReadAll(r.Body)
-		if err != nil {
-			return httputil.NewHTTPError(err, http.StatusInternalServerError, "Unable to read body")
-		}
-		// must close
-		if err := r.Body.Close(); err != nil {
-			return err
-		}
-		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-
-		// Grab the signature
-		signatureBase64 := r.Header.Get("x-signature")
-		signature, err := base64.StdEncoding.DecodeString(signatureBase64)
-		if err != nil {
-			return httputil.NewHTTPError(err, http.StatusInternalServerError, "Incorrectly formatted x-signature header")
-		}
-
-		// Grab the public key
-		pubkeyBase64 := r.Header.Get("x-publickey")
-		pubkey, err := base64.StdEncoding.DecodeString(pubkeyBase64)
-		if err != nil {
-			return httputil.NewHTTPError(err, http.StatusInternalServerError, "Incorrectly formatted x-publickey header")
-		}
+// trustAnchorKey is how we find the trust anchor in a context.Context.
+type trustAnchorKey struct{}
 
-		// Do the actual verification
-		if !ed25519.Verify(pubkey, bodyBytes, signature) {
-			return httputil.NewHTTPError(nil, http.StatusBadRequest, "Unable to verify signature")
-		}
-
-		// call the next handler because everything checked out
-		next.ServeHTTP(w, r)
-		return nil
-	})
+// TrustAnchorFromContext returns the trust anchor used to decide if signatures are trusted.
+// When no trust anchor is available nothing is trusted.
+func TrustAnchorFromContext(ctx context.Context) db.TrustAnchor {
+	if v := ctx.Value(trustAnchorKey{}); v != nil {
+		return v.(db.TrustAnchor)
+	}
+	return &db.TrustPolicy{}
 }
 
-var _ middlewareFunc = SignatureVerifyMiddleware
+// TrustAnchorMiddleware returns a middleware that injects the trust anchor into the context.
+func TrustAnchorMiddleware(ta db.TrustAnchor) middlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), trustAnchorKey{}, ta)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// SignatureVerifier verifies that request bodies are signed by one of the registered client keys.
+type SignatureVerifier struct {
+	required bool
+
+	// names of the keys by the base64 encoded raw public key
+	names map[string]string
+}
+
+// NewSignatureVerifier creates a SignatureVerifier.  It returns nil when request signing is disabled (no keys and not required).
+func NewSignatureVerifier(conf v1alpha2.RequestSigning) (*SignatureVerifier, error) {
+	if len(conf.Keys) == 0 {
+		if conf.Required {
+			return nil, errors.New("request signing is required but no keys are registered")
+		}
+		return nil, nil
+	}
+
+	v := &SignatureVerifier{
+		required: conf.Required,
+		names:    make(map[string]string, len(conf.Keys)),
+	}
+	for _, key := range conf.Keys {
+		if key.Name == "" {
+			return nil, errors.New("client key name is required")
+		}
+		pubkey, err := parsePublicKey(key.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("client key %q: %w", key.Name, err)
+		}
+		v.names[base64.StdEncoding.EncodeToString(pubkey)] = key.Name
+	}
+	return v, nil
+}
+
+// parsePublicKey parses a PEM encoded or base64 encoded raw ed25519 public key.
+func parsePublicKey(s string) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing PEM public key: %w", err)
+		}
+		pubkey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is a %T, not an ed25519 key", key)
+		}
+		return pubkey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decoding public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key is %d bytes, expected %d", len(raw), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// signingKeyKey is how we find the name of the key that signed the request in a context.Context.
+type signingKeyKey struct{}
+
+// SigningKeyFromContext returns the name of the client key that signed the request.  It returns "" for unsigned requests.
+func SigningKeyFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(signingKeyKey{}).(string)
+	return name
+}
+
+// SignatureVerifyMiddleware returns a middleware that verifies the ed25519 signature of the body of upload (PUT and POST) requests.
+// The signature and public key are sent base64 encoded in the X-Signature and X-Publickey headers.
+// The public key must be registered.  Unsigned uploads are rejected when signing is required.
+// Other requests (e.g., GET and DELETE) are not verified.  When v is nil every request is allowed.
+func SignatureVerifyMiddleware(v *SignatureVerifier) middlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if v == nil {
+			return next
+		}
+		return httputil.RootHandler(func(w http.ResponseWriter, r *http.Request) error {
+			// only uploads are signed (the signature covers the body, not the method or URL)
+			if r.Method != http.MethodPut && r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return nil
+			}
+
+			ctx := r.Context()
+
+			// Grab the signature
+			signatureBase64 := r.Header.Get(types.HeaderSignature)
+			if signatureBase64 == "" {
+				if v.required {
+					return httputil.NewHTTPError(nil, http.StatusUnauthorized, "Request signature is required")
+				}
+				next.ServeHTTP(w, r)
+				return nil
+			}
+			signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+			if err != nil {
+				return httputil.NewHTTPError(err, http.StatusBadRequest, "Incorrectly formatted x-signature header")
+			}
+
+			// Grab the public key
+			pubkey, err := base64.StdEncoding.DecodeString(r.Header.Get(types.HeaderPublicKey))
+			if err != nil || len(pubkey) != ed25519.PublicKeySize {
+				return httputil.NewHTTPError(err, http.StatusBadRequest, "Incorrectly formatted x-publickey header")
+			}
+			name, ok := v.names[base64.StdEncoding.EncodeToString(pubkey)]
+			if !ok {
+				return httputil.NewHTTPError(nil, http.StatusForbidden, "Public key is not registered")
+			}
+
+			bodyBytes, err := io.ReadAll(r.Body)
+			if err != nil {
+				return httputil.NewHTTPError(err, http.StatusInternalServerError, "Unable to read body")
+			}
+			// must close
+			if err := r.Body.Close(); err != nil {
+				return err
+			}
+			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+			// Do the actual verification
+			if !ed25519.Verify(pubkey, bodyBytes, signature) {
+				return httputil.NewHTTPError(nil, http.StatusBadRequest, "Unable to verify signature", "key", name)
+			}
+			logger.FromContext(ctx).DebugContext(ctx, "Verified request signature", "key", name)
+
+			// call the next handler because everything checked out
+			ctx = context.WithValue(ctx, signingKeyKey{}, name)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+	}
+}
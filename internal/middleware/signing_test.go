@@ -0,0 +1,130 @@
+package middleware_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+func TestNewSignatureVerifier(t *testing.T) {
+	pubkey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pubkey)
+	require.NoError(t, err)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	tests := []struct {
+		name    string
+		conf    v1alpha2.RequestSigning
+		wantNil bool
+		wantErr bool
+	}{
+		{"disabled", v1alpha2.RequestSigning{}, true, false},
+		{"required without keys", v1alpha2.RequestSigning{Required: true}, true, true},
+		{"base64", v1alpha2.RequestSigning{Keys: []v1alpha2.ClientKey{{Name: "a", PublicKey: base64.StdEncoding.EncodeToString(pubkey)}}}, false, false},
+		{"PEM", v1alpha2.RequestSigning{Keys: []v1alpha2.ClientKey{{Name: "a", PublicKey: pemKey}}}, false, false},
+		{"short key", v1alpha2.RequestSigning{Keys: []v1alpha2.ClientKey{{Name: "a", PublicKey: "c2hvcnQ="}}}, true, true},
+		{"missing name", v1alpha2.RequestSigning{Keys: []v1alpha2.ClientKey{{PublicKey: pemKey}}}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := middleware.NewSignatureVerifier(tt.conf)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantNil, v == nil)
+		})
+	}
+}
+
+func TestSignatureVerifyMiddleware(t *testing.T) {
+	pubkey, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	v, err := middleware.NewSignatureVerifier(v1alpha2.RequestSigning{
+		Keys: []v1alpha2.ClientKey{{Name: "edge", PublicKey: base64.StdEncoding.EncodeToString(pubkey)}},
+	})
+	require.NoError(t, err)
+
+	handler := middleware.SignatureVerifyMiddleware(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(middleware.SigningKeyFromContext(r.Context())))
+	}))
+
+	body := []byte(`{"hello":"world"}`)
+	sign := func(key ed25519.PrivateKey, signed []byte) http.Header {
+		return http.Header{
+			types.HeaderSignature: []string{base64.StdEncoding.EncodeToString(ed25519.Sign(key, signed))},
+			types.HeaderPublicKey: []string{base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		wantCode int
+		wantKey  string
+	}{
+		{"unsigned", nil, http.StatusOK, ""},
+		{"signed", sign(key, body), http.StatusOK, "edge"},
+		{"tampered", sign(key, []byte("something else")), http.StatusBadRequest, ""},
+		{"unregistered", sign(otherKey, body), http.StatusForbidden, ""},
+		{"malformed", http.Header{types.HeaderSignature: []string{"!!!"}}, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/blob", bytes.NewReader(body))
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, tt.wantKey, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSignatureVerifyMiddlewareRequired(t *testing.T) {
+	pubkey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	v, err := middleware.NewSignatureVerifier(v1alpha2.RequestSigning{
+		Required: true,
+		Keys:     []v1alpha2.ClientKey{{Name: "edge", PublicKey: base64.StdEncoding.EncodeToString(pubkey)}},
+	})
+	require.NoError(t, err)
+
+	handler := middleware.SignatureVerifyMiddleware(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// only uploads must be signed
+	for method, wantCode := range map[string]int{
+		http.MethodPut:    http.StatusUnauthorized,
+		http.MethodPost:   http.StatusUnauthorized,
+		http.MethodGet:    http.StatusOK,
+		http.MethodDelete: http.StatusOK,
+	} {
+		req := httptest.NewRequest(method, "/bottle", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, wantCode, rec.Code, method)
+	}
+}
@@ -0,0 +1,401 @@
+// Package selector parses bottle label selectors.
+//
+// The grammar is a superset of Kubernetes label selectors.  Ordered comparisons (>, >=, <, <=) and inclusive ranges
+// (key in [low, high]) compare numbers as floats and semantic versions by precedence.  The values of version keys
+// (e.g., version>=1.10) are always semantic versions.
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Operator is the relationship between a label and the values of a requirement.
+type Operator string
+
+// Operators.
+const (
+	Equals         Operator = "="
+	NotEquals      Operator = "!="
+	In             Operator = "in"
+	NotIn          Operator = "notin"
+	Exists         Operator = "exists"
+	DoesNotExist   Operator = "!"
+	GreaterThan    Operator = ">"
+	GreaterOrEqual Operator = ">="
+	LessThan       Operator = "<"
+	LessOrEqual    Operator = "<="
+	Range          Operator = "range"
+)
+
+// Kind is how the values of an ordered requirement are compared.
+type Kind int
+
+// Kinds.
+const (
+	// String values are only compared for equality
+	String Kind = iota
+
+	// Number values are compared as floats
+	Number
+
+	// Version values are compared as semantic versions
+	Version
+)
+
+// Requirement is a condition on one label.
+type Requirement struct {
+	Key      string
+	Operator Operator
+
+	// Values has one value for =, !=, and the comparisons, two values (the inclusive bounds) for ranges, and one or more values for in and notin
+	Values []string
+
+	// Kind is how the values are compared.  It is String for unordered operators.
+	Kind Kind
+}
+
+// Ordered returns true if the requirement compares values by order (comparisons and ranges).
+func (r Requirement) Ordered() bool {
+	switch r.Operator {
+	case GreaterThan, GreaterOrEqual, LessThan, LessOrEqual, Range:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns the requirement in selector syntax.
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	case In, NotIn:
+		return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ", "))
+	case Range:
+		return fmt.Sprintf("%s in [%s, %s]", r.Key, r.Values[0], r.Values[1])
+	default:
+		return r.Key + string(r.Operator) + strings.Join(r.Values, "")
+	}
+}
+
+// Selector is a list of requirements that must all match.  An empty selector matches everything.
+type Selector []Requirement
+
+// String returns the selector in selector syntax.
+func (s Selector) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Parse parses a comma separated list of requirements.  The requirements are:
+//
+//	key = value, key == value    the label equals the value
+//	key != value                 the label does not equal the value (or does not exist)
+//	key in (value1, value2)      the label equals one of the values
+//	key notin (value1, value2)   the label equals none of the values (or does not exist)
+//	key                          the label exists
+//	!key                         the label does not exist
+//	key > value, key >= value    the label is greater than (or equal to) the value
+//	key < value, key <= value    the label is less than (or equal to) the value
+//	key in [low, high]           the label is between low and high (inclusive)
+//
+// The values of ordered requirements must be numbers (e.g., accuracy>0.93) or semantic versions (e.g., version>=v1.2.0).
+// The values of version keys (keys named "version", with or without a prefix) are semantic versions even if they look like numbers (e.g., version>=1.10 is version>=1.10.0).
+// The bounds of a range must be the same kind.
+func Parse(selector string) (Selector, error) {
+	p := &parser{tokens: lex(selector)}
+	sel := Selector{}
+	if p.peek().kind == tokenEnd {
+		return sel, nil
+	}
+	for {
+		r, err := p.requirement()
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+		sel = append(sel, r)
+
+		switch t := p.next(); t.kind {
+		case tokenEnd:
+			return sel, nil
+		case tokenComma:
+		default:
+			return nil, fmt.Errorf("invalid selector %q: expected \",\" but found %q", selector, t.text)
+		}
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenIdentifier
+	tokenComma
+	tokenOperator
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex splits the selector into tokens.  Whitespace is insignificant.
+func lex(s string) []token {
+	tokens := []token{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ","})
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenOpenParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenCloseParen, ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{tokenOpenBracket, "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{tokenCloseBracket, "]"})
+			i++
+		case strings.IndexByte("=!<>", c) >= 0:
+			// operators are one character optionally followed by "=" (e.g., "=", "==", "!=", ">=")
+			j := i + 1
+			if j < len(s) && s[j] == '=' {
+				j++
+			}
+			tokens = append(tokens, token{tokenOperator, s[i:j]})
+			i = j
+		default:
+			j := i
+			for j < len(s) && strings.IndexByte(" \t\n\r,()[]=!<>", s[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdentifier, s[i:j]})
+			i = j
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{tokenEnd, "end of selector"}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, expected string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("expected %s but found %q", expected, t.text)
+	}
+	return t, nil
+}
+
+func (p *parser) key() (string, error) {
+	t, err := p.expect(tokenIdentifier, "a key")
+	if err != nil {
+		return "", err
+	}
+	if errs := validation.IsQualifiedName(t.text); len(errs) > 0 {
+		return "", fmt.Errorf("invalid key %q: %s", t.text, strings.Join(errs, "; "))
+	}
+	return t.text, nil
+}
+
+func (p *parser) requirement() (Requirement, error) {
+	if t := p.peek(); t.kind == tokenOperator && t.text == "!" {
+		p.next()
+		key, err := p.key()
+		return Requirement{Key: key, Operator: DoesNotExist}, err
+	}
+
+	key, err := p.key()
+	if err != nil {
+		return Requirement{}, err
+	}
+	r := Requirement{Key: key}
+
+	switch t := p.peek(); {
+	case t.kind == tokenEnd || t.kind == tokenComma:
+		r.Operator = Exists
+		return r, nil
+	case t.kind == tokenIdentifier && (t.text == "in" || t.text == "notin"):
+		p.next()
+		r.Operator = Operator(t.text)
+		if t.text == "in" && p.peek().kind == tokenOpenBracket {
+			return p.rangeRequirement(r)
+		}
+		r.Values, err = p.set()
+		return r, err
+	case t.kind == tokenOperator:
+		p.next()
+		switch t.text {
+		case "=", "==":
+			r.Operator = Equals
+		case "!=":
+			r.Operator = NotEquals
+		case ">", ">=", "<", "<=":
+			r.Operator = Operator(t.text)
+		default:
+			return r, fmt.Errorf("unknown operator %q", t.text)
+		}
+		// an empty value is allowed for equality (like Kubernetes label selectors)
+		value := ""
+		if v := p.peek(); v.kind == tokenIdentifier {
+			value = p.next().text
+		}
+		r.Values = []string{value}
+		if r.Ordered() {
+			r.Kind, err = kindOf(r.Key, value)
+		}
+		return r, err
+	default:
+		return r, fmt.Errorf("expected an operator but found %q", t.text)
+	}
+}
+
+// set parses "(value1, value2, ...)".
+func (p *parser) set() ([]string, error) {
+	if _, err := p.expect(tokenOpenParen, "\"(\""); err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for {
+		t, err := p.expect(tokenIdentifier, "a value")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, t.text)
+		switch t := p.next(); t.kind {
+		case tokenCloseParen:
+			return values, nil
+		case tokenComma:
+		default:
+			return nil, fmt.Errorf("expected \",\" or \")\" but found %q", t.text)
+		}
+	}
+}
+
+// rangeRequirement parses "[low, high]".
+func (p *parser) rangeRequirement(r Requirement) (Requirement, error) {
+	r.Operator = Range
+	p.next() // [
+	low, err := p.expect(tokenIdentifier, "the lower bound")
+	if err != nil {
+		return r, err
+	}
+	if _, err := p.expect(tokenComma, "\",\""); err != nil {
+		return r, err
+	}
+	high, err := p.expect(tokenIdentifier, "the upper bound")
+	if err != nil {
+		return r, err
+	}
+	if _, err := p.expect(tokenCloseBracket, "\"]\""); err != nil {
+		return r, err
+	}
+	r.Values = []string{low.text, high.text}
+
+	lowKind, err := kindOf(r.Key, low.text)
+	if err != nil {
+		return r, err
+	}
+	highKind, err := kindOf(r.Key, high.text)
+	if err != nil {
+		return r, err
+	}
+	if lowKind != highKind {
+		return r, fmt.Errorf("range bounds %q and %q must both be numbers or both be versions", low.text, high.text)
+	}
+	r.Kind = lowKind
+	return r, nil
+}
+
+// isVersionKey returns true if the values of the key are semantic versions (the name of the key, without its prefix, is "version").
+func isVersionKey(key string) bool {
+	_, name, _ := strings.Cut(key, "/")
+	if name == "" {
+		name = key
+	}
+	return name == "version"
+}
+
+// kindOf returns how the value of an ordered requirement on the key is compared.
+func kindOf(key, value string) (Kind, error) {
+	if value == "" {
+		return String, errors.New("a value is required for comparisons")
+	}
+	if isVersionKey(key) {
+		// "1.10" is a version (after "1.9") not a number (before "1.9")
+		if _, ok := VersionKey(value); !ok {
+			return String, fmt.Errorf("value %q of key %q must be a semantic version", value, key)
+		}
+		return Version, nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return Number, nil
+	}
+	if _, ok := VersionKey(value); ok {
+		return Version, nil
+	}
+	return String, fmt.Errorf("value %q must be a number or a semantic version", value)
+}
+
+// VersionKey returns a string that sorts (byte-wise) in semantic version precedence order.
+// It returns false if the value is not a version.  Missing minor and patch versions are zero (e.g., "v2" is "2.0.0").
+// Build metadata is ignored.
+func VersionKey(value string) (string, bool) {
+	v, err := semver.NewVersion(value)
+	if err != nil {
+		return "", false
+	}
+
+	key := fmt.Sprintf("%020d.%020d.%020d", v.Major(), v.Minor(), v.Patch())
+	if v.Prerelease() == "" {
+		// a release has higher precedence than any of its pre-releases and "~" sorts after "-"
+		return key + "~", true
+	}
+
+	// numeric identifiers are compared numerically and have lower precedence than alphanumeric identifiers
+	identifiers := strings.Split(v.Prerelease(), ".")
+	for i, id := range identifiers {
+		if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+			identifiers[i] = fmt.Sprintf("%020d", n)
+		}
+	}
+	return key + "-" + strings.Join(identifiers, "."), true
+}
@@ -0,0 +1,106 @@
+package selector
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     Selector
+		wantErr  bool
+	}{
+		{"", Selector{}, false},
+		{"foo=bar", Selector{{Key: "foo", Operator: Equals, Values: []string{"bar"}}}, false},
+		{"foo == bar", Selector{{Key: "foo", Operator: Equals, Values: []string{"bar"}}}, false},
+		{"foo=", Selector{{Key: "foo", Operator: Equals, Values: []string{""}}}, false},
+		{"foo!=bar,baz", Selector{
+			{Key: "foo", Operator: NotEquals, Values: []string{"bar"}},
+			{Key: "baz", Operator: Exists},
+		}, false},
+		{"!foo", Selector{{Key: "foo", Operator: DoesNotExist}}, false},
+		{"example.com/foo in (a, b)", Selector{{Key: "example.com/foo", Operator: In, Values: []string{"a", "b"}}}, false},
+		{"foo notin (a)", Selector{{Key: "foo", Operator: NotIn, Values: []string{"a"}}}, false},
+		{"accuracy>0.93", Selector{{Key: "accuracy", Operator: GreaterThan, Values: []string{"0.93"}, Kind: Number}}, false},
+		{"accuracy >= 0.93, loss<=-1e-3", Selector{
+			{Key: "accuracy", Operator: GreaterOrEqual, Values: []string{"0.93"}, Kind: Number},
+			{Key: "loss", Operator: LessOrEqual, Values: []string{"-1e-3"}, Kind: Number},
+		}, false},
+		{"version<v1.2.0-rc.1", Selector{{Key: "version", Operator: LessThan, Values: []string{"v1.2.0-rc.1"}, Kind: Version}}, false},
+		{"accuracy in [0.9, 0.95]", Selector{{Key: "accuracy", Operator: Range, Values: []string{"0.9", "0.95"}, Kind: Number}}, false},
+		{"version in [1.0.0, v2.0.0]", Selector{{Key: "version", Operator: Range, Values: []string{"1.0.0", "v2.0.0"}, Kind: Version}}, false},
+		{"version>=1.10", Selector{{Key: "version", Operator: GreaterOrEqual, Values: []string{"1.10"}, Kind: Version}}, false},
+		{"example.com/version in [1, 1.9]", Selector{{Key: "example.com/version", Operator: Range, Values: []string{"1", "1.9"}, Kind: Version}}, false},
+		{"revision>=1.10", Selector{{Key: "revision", Operator: GreaterOrEqual, Values: []string{"1.10"}, Kind: Number}}, false},
+		{"accuracy in [1, v2.0.0]", nil, true},
+		{"version>0.9x", nil, true},
+		{"accuracy>high", nil, true},
+		{"accuracy>", nil, true},
+		{"foo in (a, b", nil, true},
+		{"foo in [1, 2", nil, true},
+		{"foo bar", nil, true},
+		{"foo=bar,", nil, true},
+		{"foo=<bar", nil, true},
+		{"-foo=bar", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := Parse(tt.selector)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	for _, s := range []string{
+		"foo=bar,foo!=baz,!foo,foo",
+		"foo in (a, b),foo notin (c)",
+		"accuracy>=0.93,version<v2,accuracy in [0.9, 0.95]",
+	} {
+		sel, err := Parse(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, sel.String())
+	}
+}
+
+func TestVersionKey(t *testing.T) {
+	// in increasing precedence
+	versions := []string{
+		"0.9.0",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"v1.0.0",
+		"1.0.1",
+		"1.2",
+		"1.10.0",
+		"10.0.0",
+	}
+	keys := make([]string, len(versions))
+	for i, v := range versions {
+		key, ok := VersionKey(v)
+		assert.True(t, ok, v)
+		keys[i] = key
+	}
+	assert.True(t, sort.StringsAreSorted(keys))
+
+	// build metadata is ignored
+	a, _ := VersionKey("1.0.0+build.1")
+	b, _ := VersionKey("1.0.0")
+	assert.Equal(t, a, b)
+
+	_, ok := VersionKey("latest")
+	assert.False(t, ok)
+}
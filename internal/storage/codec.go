@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is the compression of data at rest.  The values are the HTTP content-coding names so compressed data can be
+// sent as is with a Content-Encoding header.
+type Codec string
+
+// Codecs.
+const (
+	// CodecNone stores the data uncompressed
+	CodecNone Codec = ""
+
+	// CodecGzip compresses with gzip
+	CodecGzip Codec = "gzip"
+
+	// CodecZstd compresses with Zstandard
+	CodecZstd Codec = "zstd"
+)
+
+// ParseCodec returns the codec with the name ("none", "gzip", or "zstd").  An empty name is CodecNone.
+func ParseCodec(name string) (Codec, error) {
+	switch name {
+	case "", "none":
+		return CodecNone, nil
+	case string(CodecGzip), string(CodecZstd):
+		return Codec(name), nil
+	default:
+		return CodecNone, fmt.Errorf("unknown compression %q, must be one of none, gzip, or zstd", name)
+	}
+}
+
+// String implements fmt.Stringer.
+func (c Codec) String() string {
+	if c == CodecNone {
+		return "none"
+	}
+	return string(c)
+}
+
+// zstd encoders and decoders are expensive to create and safe for concurrent use (with EncodeAll and DecodeAll)
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) { return zstd.NewWriter(nil) })
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) { return zstd.NewReader(nil) })
+)
+
+// Encode compresses the data.
+func (c Codec) Encode(data []byte) ([]byte, error) {
+	switch c {
+	case CodecNone:
+		return data, nil
+	case CodecGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("gzip compressing: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("gzip compressing: %w", err)
+		}
+		return buf.Bytes(), nil
+	case CodecZstd:
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, fmt.Errorf("creating zstd encoder: %w", err)
+		}
+		return enc.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q", string(c))
+	}
+}
+
+// Decode decompresses the data.
+func (c Codec) Decode(data []byte) ([]byte, error) {
+	switch c {
+	case CodecNone:
+		return data, nil
+	case CodecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gzip decompressing: %w", err)
+		}
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip decompressing: %w", err)
+		}
+		return decoded, nil
+	case CodecZstd:
+		dec, err := zstdDecoder()
+		if err != nil {
+			return nil, fmt.Errorf("creating zstd decoder: %w", err)
+		}
+		decoded, err := dec.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd decompressing: %w", err)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q", string(c))
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const filesystemScheme = "file"
+
+// Filesystem stores blobs in a directory at <dir>/<algorithm>/<first two characters>/<encoded digest>.
+type Filesystem struct {
+	dir string
+}
+
+// NewFilesystem creates a store in the directory (that is created if it does not exist).
+func NewFilesystem(dir string) (*Filesystem, error) {
+	if dir == "" {
+		return nil, errors.New("filesystem storage requires a path")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating storage directory: %w", err)
+	}
+	return &Filesystem{dir: dir}, nil
+}
+
+func (f *Filesystem) path(dgst digest.Digest) string {
+	encoded := dgst.Encoded()
+	return filepath.Join(f.dir, dgst.Algorithm().String(), encoded[:2], encoded)
+}
+
+// Put implements Store.
+func (f *Filesystem) Put(ctx context.Context, dgst digest.Digest, data []byte) (string, error) {
+	k, err := key(dgst)
+	if err != nil {
+		return "", err
+	}
+	ref := filesystemScheme + ":" + k
+
+	p := f.path(dgst)
+	if _, err := os.Stat(p); err == nil {
+		// content-addressable so it is already stored (the new modification time keeps it from being collected as garbage)
+		now := time.Now()
+		if err := os.Chtimes(p, now, now); err != nil {
+			return "", fmt.Errorf("touching blob: %w", err)
+		}
+		return ref, nil
+	}
+
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating blob directory: %w", err)
+	}
+
+	// write to a temporary file and rename it so readers never see a partial blob
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("creating blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("writing blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("writing blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return "", fmt.Errorf("storing blob: %w", err)
+	}
+	return ref, nil
+}
+
+// Get implements Store.
+func (f *Filesystem) Get(ctx context.Context, ref string) ([]byte, error) {
+	dgst, err := parseRef(filesystemScheme, ref)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(f.path(dgst))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, dgst)
+		}
+		return nil, fmt.Errorf("reading blob: %w", err)
+	}
+	if err := verify(dgst, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete implements Store.
+func (f *Filesystem) Delete(ctx context.Context, ref string) error {
+	dgst, err := parseRef(filesystemScheme, ref)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(f.path(dgst)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting blob: %w", err)
+	}
+	return nil
+}
+
+// Walk implements Store.
+func (f *Filesystem) Walk(ctx context.Context, fn func(ref string, modified time.Time) error) error {
+	return filepath.WalkDir(f.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// temporary files are blobs being written
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(f.dir, p)
+		if err != nil {
+			return err
+		}
+		// <algorithm>/<first two characters>/<encoded digest>
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 3 {
+			return nil
+		}
+		ref := filesystemScheme + ":" + parts[0] + "/" + parts[2]
+		if _, err := parseRef(filesystemScheme, ref); err != nil {
+			return nil //nolint:nilerr // not a blob
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("reading blob: %w", err)
+		}
+		return fn(ref, info.ModTime())
+	})
+}
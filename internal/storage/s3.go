@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+const s3Scheme = "s3"
+
+// S3 stores blobs in a bucket of an S3 compatible object store at <prefix><algorithm>/<encoded digest>.
+// Requests are signed with AWS Signature Version 4.
+type S3 struct {
+	client    *http.Client
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+
+	// now is the time used to sign requests (overridden in tests)
+	now func() time.Time
+}
+
+// NewS3 creates a store in the configured bucket.
+func NewS3(conf v1alpha2.S3Storage) (*S3, error) {
+	if conf.Endpoint == "" {
+		return nil, errors.New("s3 storage requires an endpoint")
+	}
+	if conf.Bucket == "" {
+		return nil, errors.New("s3 storage requires a bucket")
+	}
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("s3 endpoint %q must be an http or https URL", conf.Endpoint)
+	}
+
+	region := conf.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3{
+		client:    &http.Client{Timeout: 5 * time.Minute},
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    conf.Bucket,
+		prefix:    conf.Prefix,
+		accessKey: conf.AccessKeyID,
+		secretKey: string(conf.SecretAccessKey),
+		now:       time.Now,
+	}, nil
+}
+
+// objectURL returns the path-style URL of the object.
+func (s *S3) objectURL(dgst digest.Digest) (string, error) {
+	k, err := key(dgst)
+	if err != nil {
+		return "", err
+	}
+	return s.endpoint.JoinPath(s.bucket, s.prefix+k).String(), nil
+}
+
+// do sends the signed request for the object and returns the response.  The caller must close the body.
+func (s *S3) do(ctx context.Context, method string, dgst digest.Digest, body []byte) (*http.Response, error) {
+	u, err := s.objectURL(dgst)
+	if err != nil {
+		return nil, err
+	}
+	return s.doURL(ctx, method, u, body)
+}
+
+// doURL sends the signed request and returns the response.  The caller must close the body.
+func (s *S3) doURL(ctx context.Context, method, u string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating s3 request: %w", err)
+	}
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	s.sign(req, body)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s request: %w", method, err)
+	}
+	return res, nil
+}
+
+// Put implements Store.
+func (s *S3) Put(ctx context.Context, dgst digest.Digest, data []byte) (string, error) {
+	k, err := key(dgst)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := s.do(ctx, http.MethodPut, dgst, data)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", s3Error(res)
+	}
+	return s3Scheme + ":" + k, nil
+}
+
+// Get implements Store.
+func (s *S3) Get(ctx context.Context, ref string) ([]byte, error) {
+	dgst, err := parseRef(s3Scheme, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.do(ctx, http.MethodGet, dgst, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, dgst)
+	default:
+		return nil, s3Error(res)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading s3 object: %w", err)
+	}
+	if err := verify(dgst, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete implements Store.
+func (s *S3) Delete(ctx context.Context, ref string) error {
+	dgst, err := parseRef(s3Scheme, ref)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodDelete, dgst, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return s3Error(res)
+	}
+	return nil
+}
+
+// listBucketResult is the response of ListObjectsV2.
+type listBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		LastModified time.Time
+	}
+}
+
+// Walk implements Store.
+func (s *S3) Walk(ctx context.Context, fn func(ref string, modified time.Time) error) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u := s.endpoint.JoinPath(s.bucket)
+		u.RawQuery = query.Encode()
+
+		res, err := s.doURL(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode != http.StatusOK {
+			err := s3Error(res)
+			res.Body.Close()
+			return err
+		}
+		result := listBucketResult{}
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return fmt.Errorf("parsing s3 object list: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			ref := s3Scheme + ":" + strings.TrimPrefix(object.Key, s.prefix)
+			if _, err := parseRef(s3Scheme, ref); err != nil {
+				// not a blob
+				continue
+			}
+			if err := fn(ref, object.LastModified); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// s3Error returns an error with the status and (truncated) XML error document of the response.
+func s3Error(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3 %s %s failed with status %s: %s", res.Request.Method, res.Request.URL.Path, res.Status, bytes.TrimSpace(body))
+}
+
+// sign adds the AWS Signature Version 4 authorization header to the request.
+// All headers of the request (and the host) are signed.  Requests are not signed without an access key.
+func (s *S3) sign(req *http.Request, body []byte) {
+	if s.accessKey == "" {
+		return
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// canonical headers are lower case, sorted, and include the host
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
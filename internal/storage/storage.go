@@ -0,0 +1,78 @@
+// Package storage stores the raw data of objects outside of the database in content-addressable blob storage.
+// Blobs are keyed by their (canonical) digest so storing the same data twice is harmless.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a content-addressable blob store.
+type Store interface {
+	// Put stores the data (that has the digest dgst) and returns the reference to it (that is saved in the database).
+	// Putting data that is already stored updates its modification time.
+	Put(ctx context.Context, dgst digest.Digest, data []byte) (string, error)
+
+	// Get returns the data of the reference.  The data is verified against its digest.
+	Get(ctx context.Context, ref string) ([]byte, error)
+
+	// Delete removes the data of the reference.  Deleting data that does not exist is not an error.
+	Delete(ctx context.Context, ref string) error
+
+	// Walk calls fn with the reference and modification time of each blob.  Walk stops at the first error from fn.
+	Walk(ctx context.Context, fn func(ref string, modified time.Time) error) error
+}
+
+// New creates the store from the configuration.  It returns nil when raw data is stored in the database.
+func New(conf v1alpha2.BlobStorage) (Store, error) {
+	switch conf.Type {
+	case "", "database":
+		return nil, nil
+	case "filesystem":
+		return NewFilesystem(conf.Path)
+	case "s3":
+		return NewS3(conf.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage type %q, must be one of database, filesystem, or s3", conf.Type)
+	}
+}
+
+// key returns the key (relative path) of the blob, e.g., "sha256/0a4d55a8d778e5022fab701977c5d840bbc486d0".
+func key(dgst digest.Digest) (string, error) {
+	if err := dgst.Validate(); err != nil {
+		return "", fmt.Errorf("invalid digest %q: %w", dgst, err)
+	}
+	return dgst.Algorithm().String() + "/" + dgst.Encoded(), nil
+}
+
+// parseRef returns the digest of the reference "<scheme>:<algorithm>/<encoded>".
+func parseRef(scheme, ref string) (digest.Digest, error) {
+	k, ok := strings.CutPrefix(ref, scheme+":")
+	if !ok {
+		return "", fmt.Errorf("reference %q is not stored in %s storage", ref, scheme)
+	}
+	alg, encoded, _ := strings.Cut(k, "/")
+	dgst := digest.NewDigestFromEncoded(digest.Algorithm(alg), encoded)
+	if err := dgst.Validate(); err != nil {
+		return "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	return dgst, nil
+}
+
+// verify checks that the data matches the digest.
+func verify(dgst digest.Digest, data []byte) error {
+	if actual := dgst.Algorithm().FromBytes(data); actual != dgst {
+		return fmt.Errorf("blob %s is corrupt, its digest is %s", dgst, actual)
+	}
+	return nil
+}
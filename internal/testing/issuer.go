@@ -0,0 +1,78 @@
+package testing
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// Issuer is an OIDC issuer for testing authentication.  It signs tokens with a key it publishes with OIDC discovery.
+type Issuer struct {
+	// URL is the issuer URL
+	URL string
+
+	server *httptest.Server
+	signer jose.Signer
+}
+
+// NewIssuer starts an Issuer.  Close it when done.
+func NewIssuer() (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generating the signing key: %w", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", "test").WithType("JWT"))
+	if err != nil {
+		return nil, fmt.Errorf("creating the signer: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   server.URL,
+			"jwks_uri": server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: key.Public(), KeyID: "test", Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+
+	return &Issuer{URL: server.URL, server: server, signer: signer}, nil
+}
+
+// Close stops the issuer.
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// Token returns a signed token with the claims merged with valid defaults (subject "alice" and audience "telemetry").
+func (i *Issuer) Token(claims map[string]any) (string, error) {
+	all := map[string]any{
+		"iss": i.URL,
+		"aud": []string{"telemetry"},
+		"sub": "alice",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+	payload, err := json.Marshal(all)
+	if err != nil {
+		return "", fmt.Errorf("encoding the claims: %w", err)
+	}
+	jws, err := i.signer.Sign(payload)
+	if err != nil {
+		return "", fmt.Errorf("signing the token: %w", err)
+	}
+	return jws.CompactSerialize()
+}
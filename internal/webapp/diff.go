@@ -0,0 +1,76 @@
+package webapp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/schema"
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+)
+
+// handleDiff shows the difference between two bottles side by side.
+// When only one of "from" and "to" is given, the other is the bottle it deprecates (or is deprecated by).
+func (a *WebApp) handleDiff(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	type Params struct {
+		From digest.Digest `schema:"from"`
+		To   digest.Digest `schema:"to"`
+	}
+
+	params := Params{}
+	if err := schema.NewDecoder().Decode(&params, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+
+	switch {
+	case params.From == "" && params.To != "":
+		deprecated, err := db.FindDeprecatedBy(con, params.To)
+		if err != nil {
+			return err
+		}
+		if len(deprecated) > 0 {
+			params.From = deprecated[0]
+		}
+	case params.To == "" && params.From != "":
+		deprecators, err := db.FindDeprecates(con, params.From)
+		if err != nil {
+			return err
+		}
+		if len(deprecators) > 0 {
+			params.To = deprecators[0]
+		}
+	}
+	if params.From == "" || params.To == "" {
+		return httputil.NewHTTPError(nil, http.StatusBadRequest, "The \"from\" and \"to\" parameters are required unless one bottle deprecates the other")
+	}
+
+	find := func(dgst digest.Digest, param string) (*db.BottleRelative, error) {
+		if err := dgst.Validate(); err != nil {
+			return nil, httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid \""+param+"\" parameter: "+err.Error())
+		}
+		bottle, err := db.FindBottleForDiff(con, dgst)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, httputil.NewHTTPError(err, http.StatusNotFound, "Bottle not found: "+dgst.String())
+		}
+		return bottle, err
+	}
+
+	from, err := find(params.From, "from")
+	if err != nil {
+		return err
+	}
+	to, err := find(params.To, "to")
+	if err != nil {
+		return err
+	}
+
+	return a.executeTemplateAsResponse(ctx, w, "diff.html", db.DiffBottles(from, to, params.From, params.To), "../")
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/act3-ai/go-common/pkg/logger"
 
 	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/lineage"
 	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
 )
 
@@ -47,6 +48,7 @@ func (a *WebApp) handleAbout(w http.ResponseWriter, r *http.Request) error {
 		ArtifactsCount  int64
 		SignaturesCount int64
 		BlobDataBytes   int64
+		StoredDataBytes int64
 	}
 
 	log.InfoContext(ctx, "Collecting counts")
@@ -56,8 +58,9 @@ func (a *WebApp) handleAbout(w http.ResponseWriter, r *http.Request) error {
 	artifactsCount := db.ArtifactsCount(con)
 	signaturesCount := db.SignaturesCount(con)
 	blobDataBytes := db.BlobDataBytes(con)
+	storedDataBytes := db.StoredDataBytes(con)
 
-	values := TotalCount{eventsCount, manifestsCount, bottlesCount, artifactsCount, signaturesCount, blobDataBytes}
+	values := TotalCount{eventsCount, manifestsCount, bottlesCount, artifactsCount, signaturesCount, blobDataBytes, storedDataBytes}
 
 	return a.executeTemplateAsResponse(ctx, w, "documentation.html", values, "../")
 }
@@ -277,12 +280,12 @@ func (a *WebApp) handleBottle(w http.ResponseWriter, r *http.Request) error {
 		Signature db.Signature
 		Trusted   bool
 	}
+	trustAnchor := middleware.TrustAnchorFromContext(ctx)
 	swt := []signatureWithTrust{}
 	for _, s := range *signatures {
 		swt = append(swt, signatureWithTrust{
 			Signature: s,
-			// TODO: use appropriate trust anchor
-			Trusted: s.Trusted(&db.DefaultTrustAnchor{}),
+			Trusted:   s.Trusted(trustAnchor),
 		})
 	}
 
@@ -303,9 +306,10 @@ func (a *WebApp) handleBottle(w http.ResponseWriter, r *http.Request) error {
 		BottlePullUserNums map[string]int
 		LatestAPIVersion   string
 		LineageGraphHTML   template.HTML
+		LineageFormats     []lineage.Format
 	}{
 		params,
-		totalSize, &bottle, manifestations, bottle.Digests, bottlePrettyJSON, bottlePrettyYAML, deprecatedByBottleDigests, deprecatesBottleDigests, viewers, swt, artifactViewers, totalBottlePulls, bottlePulls, latest.GroupVersion.Identifier(), lineageGraphHTML,
+		totalSize, &bottle, manifestations, bottle.Digests, bottlePrettyJSON, bottlePrettyYAML, deprecatedByBottleDigests, deprecatesBottleDigests, viewers, swt, artifactViewers, totalBottlePulls, bottlePulls, latest.GroupVersion.Identifier(), lineageGraphHTML, lineage.Formats,
 	}
 
 	return a.executeTemplateAsResponse(ctx, w, "bottle.html", values, "../")
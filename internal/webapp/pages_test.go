@@ -159,10 +159,36 @@ func (s *HandlersTestSuite) TestBottle() {
 	}
 	req := s.makeRequest("GET", u.String(), nil)
 
-	status, _, _ := s.performRequest(req)
+	status, _, body := s.performRequest(req)
 
 	s.Equal(http.StatusOK, status)
-	// TODO check the response
+	// TODO check the rest of the response
+	s.Contains(string(body), "&ancestors=1&descendants=1&format=graphml\">graphml</a>")
+}
+
+func (s *HandlersTestSuite) TestDiff() {
+	dgst, err := ttest.FileDigest(filepath.Join(s.dataDir, "bottle", "bottle1.json"), "sha256")
+	s.NoError(err)
+
+	// bottle1 deprecates bottle00 so the older bottle is found automatically
+	u := url.URL{
+		Path:     "/diff.html",
+		RawQuery: url.Values{"to": []string{dgst.String()}}.Encode(),
+	}
+	req := s.makeRequest("GET", u.String(), nil)
+
+	status, _, body := s.performRequest(req)
+
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), "The newer bottle deprecates the older bottle.")
+	s.Contains(string(body), "someusage")
+
+	// bottle3 does not deprecate anything
+	dgst, err = ttest.FileDigest(filepath.Join(s.dataDir, "bottle", "bottle3.json"), "sha256")
+	s.NoError(err)
+	u.RawQuery = url.Values{"to": []string{dgst.String()}}.Encode()
+	status, _, _ = s.performRequest(s.makeRequest("GET", u.String(), nil))
+	s.Equal(http.StatusBadRequest, status)
 }
 
 func (s *HandlersTestSuite) TestArtifactTabular() {
@@ -228,6 +254,54 @@ func (s *HandlersTestSuite) TestSimilarBottles() {
 	// TODO check the response
 }
 
+func (s *HandlersTestSuite) TestWebhooks() {
+	u := url.URL{
+		Path:     "/webhooks.html",
+		RawQuery: url.Values{"status": []string{"failed"}}.Encode(),
+	}
+	req := s.makeRequest("GET", u.String(), nil)
+
+	status, _, body := s.performRequest(req)
+
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), "No webhook deliveries.")
+}
+
+func (s *HandlersTestSuite) TestTransfers() {
+	u := url.URL{
+		Path:     "/transfers.html",
+		RawQuery: url.Values{"action": []string{"pull"}, "start": []string{"2012-01-01"}, "interval": []string{"week"}}.Encode(),
+	}
+	req := s.makeRequest("GET", u.String(), nil)
+
+	status, _, body := s.performRequest(req)
+
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), "reg2.example.com/bar/somewhere/else")
+	s.Contains(string(body), "echarts.init")
+	s.Contains(string(body), `value="2012-01-01"`)
+
+	u.RawQuery = url.Values{"start": []string{"yesterday"}}.Encode()
+	status, _, _ = s.performRequest(s.makeRequest("GET", u.String(), nil))
+	s.Equal(http.StatusBadRequest, status)
+}
+
+func (s *HandlersTestSuite) TestRepository() {
+	u := url.URL{
+		Path:     "/repository.html",
+		RawQuery: url.Values{"repository": []string{"reg.example.com/foo"}}.Encode(),
+	}
+	status, _, body := s.performRequest(s.makeRequest("GET", u.String(), nil))
+
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), "v1.0.1")
+	s.Contains(string(body), "sha256:74968ed318f252397002f7cc02c563554156cc1f0eeec91d643fc12de61314c9")
+	s.Contains(string(body), "bottle.html?digest=sha256%3a1c62b7c436992270b5ac7fc683debef0bbf461fe296d85d823ffc18f68dae33d")
+
+	status, _, _ = s.performRequest(s.makeRequest("GET", "/repository.html", nil))
+	s.Equal(http.StatusBadRequest, status)
+}
+
 func TestHandlersTestSuite(t *testing.T) {
 	suite.Run(t, new(HandlersTestSuite))
 }
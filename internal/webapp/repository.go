@@ -0,0 +1,78 @@
+package webapp
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/schema"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// handleRepository shows the tags of a repository, the bottles they point to, and the timeline of pushes.
+func (a *WebApp) handleRepository(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	type Params struct {
+		Repository string `schema:"repository"`
+	}
+
+	params := Params{}
+	if err := schema.NewDecoder().Decode(&params, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+	if params.Repository == "" {
+		return httputil.NewHTTPError(nil, http.StatusBadRequest, "The \"repository\" parameter is required")
+	}
+
+	tags, err := db.FindRepositoryTags(con, params.Repository, "")
+	if err != nil {
+		return err
+	}
+
+	type bottle struct {
+		Digest digest.Digest
+		// Tags that point to the bottle now
+		Tags []string
+	}
+
+	type values struct {
+		Repository string
+		Tags       []types.TagHistory
+		Bottles    []bottle
+		Timeline   []types.TagPush
+	}
+	v := values{
+		Repository: params.Repository,
+		Tags:       tags.Tags,
+	}
+
+	// bottles are listed in the order they were first seen on the timeline (newest first)
+	index := map[digest.Digest]int{}
+	for _, history := range tags.Tags {
+		v.Timeline = append(v.Timeline, history.Pushes...)
+	}
+	slices.SortStableFunc(v.Timeline, func(a, b types.TagPush) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	for _, p := range v.Timeline {
+		if _, ok := index[p.BottleDigest]; !ok && p.BottleDigest != "" {
+			index[p.BottleDigest] = len(v.Bottles)
+			v.Bottles = append(v.Bottles, bottle{Digest: p.BottleDigest})
+		}
+	}
+	for _, history := range tags.Tags {
+		current := history.Pushes[0]
+		if i, ok := index[current.BottleDigest]; ok {
+			v.Bottles[i].Tags = append(v.Bottles[i].Tags, history.Tag)
+		}
+	}
+
+	return a.executeTemplateAsResponse(ctx, w, "repository.html", v, "../")
+}
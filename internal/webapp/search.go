@@ -13,12 +13,12 @@ import (
 	"github.com/gorilla/schema"
 	"github.com/opencontainers/go-digest"
 	"gorm.io/gorm"
-	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/act3-ai/go-common/pkg/httputil"
 
 	"github.com/act3-ai/data-telemetry/v3/internal/db"
 	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
 )
 
 type bottleRequestParams struct {
@@ -87,44 +87,41 @@ func (p *bottleRequestParams) populateFromURLQuery(values url.Values) error {
 	return p.validate()
 }
 
+// searchQuery returns the bottle search of the params.
+func (p *bottleRequestParams) searchQuery() types.SearchQuery {
+	return types.SearchQuery{
+		LabelSelectors:       p.LabelSelectors,
+		Description:          p.Description,
+		Author:               p.Author,
+		Repository:           p.BottleRepo,
+		SignatureFingerprint: p.SignatureFingerprint,
+		SignatureAnnotations: p.SignatureAnnotations,
+		ParentsOf:            p.ParentsOf,
+		ChildrenOf:           p.ChildrenOf,
+		DeprecatedBy:         p.DeprecatedBy,
+		Deprecates:           p.Deprecates,
+		ShowDeprecated:       p.ShowDeprecated,
+		PartDigests:          p.PartDigests,
+		Metrics:              p.Metrics,
+		SortByMetric:         p.SortByMetric,
+		MetricSortAscending:  p.MetricSortAscending,
+		CreatedBefore:        time.Time(p.CreatedBefore),
+		Limit:                p.Limit,
+		Page:                 p.Page,
+	}
+}
+
 // returns an error if any fields are invalid.
 func (p *bottleRequestParams) validate() error {
 	var multiError error
 
-	validateDigest := func(dgst *digest.Digest, fieldName string) {
-		if len(dgst.String()) > 0 {
-			err := dgst.Validate()
-			if err != nil {
-				multiError = errors.Join(multiError, fmt.Errorf("invalid search param \"%s\" (%s): %w", fieldName, dgst.String(), err))
-			}
-		}
-	}
-
-	validateDigest(&p.Bottle, "bottle")
-	validateDigest(&p.SignatureFingerprint, "signature-fingerprint")
-	validateDigest(&p.ParentsOf, "parents-of")
-	validateDigest(&p.ChildrenOf, "children-of")
-	validateDigest(&p.DeprecatedBy, "deprecated-by")
-	validateDigest(&p.Deprecates, "deprecates")
-	for _, part := range p.PartDigests {
-		validateDigest(&part, "part-digest")
-	}
-
-	for _, labelSelector := range p.LabelSelectors {
-		_, err := labels.Parse(labelSelector)
-		if err != nil {
-			multiError = errors.Join(multiError, fmt.Errorf("invalid search param \"label-selector\" (%s): %w", labelSelector, err))
+	if len(p.Bottle.String()) > 0 {
+		if err := p.Bottle.Validate(); err != nil {
+			multiError = fmt.Errorf("invalid search param \"bottle\" (%s): %w", p.Bottle.String(), err)
 		}
 	}
 
-	for _, signatureAnnotation := range p.SignatureAnnotations {
-		sigAnnParts := strings.Split(signatureAnnotation, "=")
-		if len(sigAnnParts) != 2 {
-			multiError = errors.Join(multiError, fmt.Errorf("invalid search param \"signature-annotation\" (%s)", signatureAnnotation))
-		}
-	}
-
-	return multiError
+	return errors.Join(multiError, db.ValidateSearchQuery(p.searchQuery()))
 }
 
 func getBottlesFromRequestParams(ctx context.Context, params *bottleRequestParams) (*[]bottleResultEntry, *httputil.HTTPError) {
@@ -134,56 +131,7 @@ func getBottlesFromRequestParams(ctx context.Context, params *bottleRequestParam
 		return nil, httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid bottle request params")
 	}
 
-	tx := con
-
-	// add selectors
-	tx = tx.Scopes(db.FilterBySelectors(params.LabelSelectors))
-
-	// description matching
-	tx = tx.Scopes(db.RankByDescription(params.Description))
-
-	// searching Author name or Author email
-	tx = tx.Scopes(db.SearchByAuthor(params.Author))
-
-	tx = tx.Scopes(db.SearchByRepository(params.BottleRepo))
-
-	if len(params.SignatureFingerprint) > 0 {
-		tx = tx.Scopes(db.WithSignature([]digest.Digest{params.SignatureFingerprint}))
-	}
-
-	// signature annotation matching
-	tx = tx.Scopes(db.WithSignatureAnnotations(params.SignatureAnnotations))
-
-	if len(params.ParentsOf) > 0 {
-		tx = tx.Scopes(db.ParentsOf([]digest.Digest{params.ParentsOf}))
-	}
-
-	if len(params.ChildrenOf) > 0 {
-		tx = tx.Scopes(db.ChildrenOf([]digest.Digest{params.ChildrenOf}))
-	}
-
-	if len(params.DeprecatedBy) > 0 {
-		tx = tx.Scopes(db.DeprecatedBy(params.DeprecatedBy))
-
-		// If we are using "deprecated by" we can assume they want to see deprecated bottles
-		params.ShowDeprecated = true
-	}
-
-	if len(params.Deprecates) > 0 {
-		tx = tx.Scopes(db.DeprecatesThis(params.Deprecates))
-	}
-
-	if !params.ShowDeprecated {
-		tx = tx.Scopes(db.ExcludeDeprecated())
-	}
-
-	if len(params.PartDigests) > 0 {
-		tx = tx.Scopes(db.FilterByParts(params.PartDigests))
-	}
-
-	if len(params.Metrics) > 0 {
-		tx = tx.Scopes(db.FilterByMetric(params.Metrics))
-	}
+	tx := getFilteredSearchQuery(con, params)
 
 	if len(params.SortByMetric) > 0 {
 		tx = tx.Scopes(db.SortByMetric(params.SortByMetric, params.MetricSortAscending))
@@ -216,10 +164,6 @@ func getBottlesFromRequestParams(ctx context.Context, params *bottleRequestParam
 		Limit(params.Limit).
 		Offset(params.Page * params.Limit)
 
-	if !time.Time(params.CreatedBefore).IsZero() {
-		tx = tx.Where("bottles.created_at <= ?", time.Time(params.CreatedBefore))
-	}
-
 	tx.Statement.Selects = append(
 		tx.Statement.Selects,
 		"COUNT(*) OVER () AS total_count",
@@ -372,10 +316,6 @@ func getMetricNamesFromRequestParams(ctx context.Context, params *bottleRequestP
 	tx = tx.Table("bottles").
 		Distinct("bottles.id")
 
-	if !time.Time(params.CreatedBefore).IsZero() {
-		tx = tx.Where("bottles.created_at <= ?", time.Time(params.CreatedBefore))
-	}
-
 	metricQuery := con.Session(&gorm.Session{NewDB: true}).
 		Table("metrics").
 		Distinct("metrics.name").
@@ -439,10 +379,6 @@ func getCommonLabelsFromRequestParams(ctx context.Context, params *bottleRequest
 	tx = tx.Table("bottles").
 		Distinct("bottles.id")
 
-	if !time.Time(params.CreatedBefore).IsZero() {
-		tx = tx.Where("bottles.created_at <= ?", time.Time(params.CreatedBefore))
-	}
-
 	// TODO get common labels
 	labelQuery := con.Session(&gorm.Session{NewDB: true}).
 		Table("labels").
@@ -460,57 +396,12 @@ func getCommonLabelsFromRequestParams(ctx context.Context, params *bottleRequest
 }
 
 func getFilteredSearchQuery(con *gorm.DB, params *bottleRequestParams) *gorm.DB {
-	tx := con
-	// add selectors
-	tx = tx.Scopes(db.FilterBySelectors(params.LabelSelectors))
-
-	// description matching
-	tx = tx.Scopes(db.RankByDescription(params.Description))
-
-	// searching Author name or Author email
-	tx = tx.Scopes(db.SearchByAuthor(params.Author))
-
-	tx = tx.Scopes(db.SearchByRepository(params.BottleRepo))
-
-	if len(params.SignatureFingerprint) > 0 {
-		tx = tx.Scopes(db.WithSignature([]digest.Digest{params.SignatureFingerprint}))
-	}
-
-	// signature annotation matching
-	tx = tx.Scopes(db.WithSignatureAnnotations(params.SignatureAnnotations))
-
-	if len(params.ParentsOf) > 0 {
-		tx = tx.Scopes(db.ParentsOf([]digest.Digest{params.ParentsOf}))
-	}
-
-	if len(params.ChildrenOf) > 0 {
-		tx = tx.Scopes(db.ChildrenOf([]digest.Digest{params.ChildrenOf}))
-	}
-
+	// If we are using "deprecated by" we can assume they want to see deprecated bottles
 	if len(params.DeprecatedBy) > 0 {
-		tx = tx.Scopes(db.DeprecatedBy(params.DeprecatedBy))
-
-		// If we are using "deprecated by" we can assume they want to see deprecated bottles
 		params.ShowDeprecated = true
 	}
 
-	if len(params.Deprecates) > 0 {
-		tx = tx.Scopes(db.DeprecatesThis(params.Deprecates))
-	}
-
-	if !params.ShowDeprecated {
-		tx = tx.Scopes(db.ExcludeDeprecated())
-	}
-
-	if len(params.PartDigests) > 0 {
-		tx = tx.Scopes(db.FilterByParts(params.PartDigests))
-	}
-
-	if len(params.Metrics) > 0 {
-		tx = tx.Scopes(db.FilterByMetric(params.Metrics))
-	}
-
-	return tx
+	return con.Scopes(db.SearchBottles(params.searchQuery()))
 }
 
 func getTemplateNameAndRequestParams(r *http.Request, templateMap map[string]string) (string, *bottleRequestParams, *httputil.HTTPError) {
@@ -0,0 +1,148 @@
+package webapp
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	echarts "github.com/go-echarts/go-echarts/v2/charts"
+	echartsOpts "github.com/go-echarts/go-echarts/v2/opts"
+	echartsRender "github.com/go-echarts/go-echarts/v2/render"
+	"github.com/gorilla/schema"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+// parseDate parses a date (as given by a date input) or an RFC 3339 time.
+// The empty string is the zero time.  It returns an invalid value (so decoding fails) if the time can not be parsed.
+func parseDate(value string) reflect.Value {
+	if value == "" {
+		return reflect.ValueOf(time.Time{})
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return reflect.ValueOf(t)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return reflect.ValueOf(t)
+	}
+	return reflect.Value{}
+}
+
+// handleTransfers renders the bytes transferred for each bottle, repository, and user and the throughput of each repository.
+func (a *WebApp) handleTransfers(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	query := types.TransferQuery{}
+	decoder := schema.NewDecoder()
+	decoder.RegisterConverter(time.Time{}, parseDate)
+	if err := decoder.Decode(&query, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+	if err := db.ValidateTransferQuery(query); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+
+	stats, err := db.TransferStats(con, query)
+	if err != nil {
+		return err
+	}
+
+	type values struct {
+		Query     types.TransferQuery
+		Stats     *types.TransferStats
+		Intervals []types.EventInterval
+		Charts    []template.HTML
+	}
+	v := values{
+		Query:     query,
+		Stats:     stats,
+		Intervals: []types.EventInterval{types.IntervalHour, types.IntervalDay, types.IntervalWeek, types.IntervalMonth},
+		Charts: []template.HTML{
+			transferChart("Bytes transferred by repository", stats.Repositories, nil),
+			transferChart("Bytes transferred by user", stats.Users, nil),
+			transferChart("Bytes transferred by bottle", stats.Bottles, shortDigest),
+			throughputChart(stats),
+		},
+	}
+
+	return a.executeTemplateAsResponse(ctx, w, "transfers.html", v, "../")
+}
+
+// shortDigest shortens a digest to the first 12 characters of its encoded portion.
+func shortDigest(name string) string {
+	encoded := digest.Digest(name).Encoded()
+	if len(encoded) > 12 {
+		return encoded[:12]
+	}
+	return name
+}
+
+// newChartOpts are the options of the transfer charts.
+func newChartOpts(title string) []echarts.GlobalOpts {
+	return []echarts.GlobalOpts{
+		echarts.WithTitleOpts(echartsOpts.Title{Title: title}),
+		echarts.WithTooltipOpts(echartsOpts.Tooltip{Show: echartsOpts.Bool(true), Trigger: "axis"}),
+		echarts.WithInitializationOpts(echartsOpts.Initialization{Width: "100%", Height: "400px"}),
+	}
+}
+
+// chartHTML renders a chart to be embedded in a page.
+// The page must include echarts.min.js (the custom build of echarts must include the bar and line charts).
+func chartHTML(renderer echartsRender.Renderer) template.HTML {
+	snippet := renderer.RenderSnippet()
+	return template.HTML(snippet.Element + snippet.Script)
+}
+
+// transferChart renders a horizontal bar chart of the bytes transferred (the most at the top).
+// label shortens the names (optional).
+func transferChart(title string, totals []types.TransferTotal, label func(string) string) template.HTML {
+	names := make([]string, len(totals))
+	data := make([]echartsOpts.BarData, len(totals))
+	for i, t := range totals {
+		names[i] = t.Name
+		if label != nil {
+			names[i] = label(t.Name)
+		}
+		data[i] = echartsOpts.BarData{Name: t.Name, Value: t.Bytes}
+	}
+	// the category axis is drawn bottom to top
+	slices.Reverse(names)
+	slices.Reverse(data)
+
+	bar := echarts.NewBar()
+	bar.SetGlobalOptions(append(newChartOpts(title),
+		echarts.WithXAxisOpts(echartsOpts.XAxis{Name: "bytes", Type: "value"}),
+		echarts.WithYAxisOpts(echartsOpts.YAxis{Type: "category"}),
+	)...)
+	// the names are moved to the Y axis
+	bar.SetXAxis(names).
+		AddSeries("bytes", data).
+		XYReversal()
+	return chartHTML(bar.Renderer)
+}
+
+// throughputChart renders a line chart of the average throughput of each repository over time.
+func throughputChart(stats *types.TransferStats) template.HTML {
+	line := echarts.NewLine()
+	line.SetGlobalOptions(append(newChartOpts("Average throughput by repository"),
+		echarts.WithXAxisOpts(echartsOpts.XAxis{Type: "time"}),
+		echarts.WithYAxisOpts(echartsOpts.YAxis{Name: "bytes/s", Type: "value"}),
+		echarts.WithLegendOpts(echartsOpts.Legend{Show: echartsOpts.Bool(true), Top: "bottom"}),
+	)...)
+	for _, series := range stats.Throughput {
+		data := make([]echartsOpts.LineData, len(series.Buckets))
+		for i, b := range series.Buckets {
+			data[i] = echartsOpts.LineData{Value: []any{b.Start.Format(time.RFC3339), b.Bandwidth}}
+		}
+		line.AddSeries(series.Repository, data)
+	}
+	return chartHTML(line.Renderer)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/act3-ai/go-common/pkg/httputil"
 	"github.com/act3-ai/go-common/pkg/logger"
 
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
 	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
 )
 
@@ -125,6 +126,11 @@ func (a *WebApp) Initialize(serveMux *http.ServeMux) {
 	serveMux.Handle("GET /leaderboard.html", httputil.RootHandler(a.getPageHandler("leaderboard.html")))
 	serveMux.Handle("GET /bottle.html", httputil.RootHandler(a.handleBottle))
 	serveMux.Handle("GET /similarBottles", httputil.RootHandler(a.handleSimilarBottles))
+	// the delivery log has the webhook URLs and payloads
+	serveMux.Handle("GET /webhooks.html", middleware.RequireAdmin(httputil.RootHandler(a.handleWebhooks)))
+	serveMux.Handle("GET /diff.html", httputil.RootHandler(a.handleDiff))
+	serveMux.Handle("GET /transfers.html", httputil.RootHandler(a.handleTransfers))
+	serveMux.Handle("GET /repository.html", httputil.RootHandler(a.handleRepository))
 
 	// search components
 	searchMux := http.NewServeMux()
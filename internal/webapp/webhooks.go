@@ -0,0 +1,60 @@
+package webapp
+
+import (
+	"net/http"
+
+	"github.com/gorilla/schema"
+
+	"github.com/act3-ai/go-common/pkg/httputil"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+)
+
+// handleWebhooks renders the webhook delivery log (newest first).
+func (a *WebApp) handleWebhooks(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	con := middleware.DatabaseFromContext(ctx)
+
+	type Params struct {
+		Webhook string `schema:"webhook"`
+		Status  string `schema:"status"`
+		Limit   int    `schema:"limit"`
+	}
+
+	params := Params{
+		Limit: 100,
+	}
+	if err := schema.NewDecoder().Decode(&params, r.URL.Query()); err != nil {
+		return httputil.NewHTTPError(err, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+	}
+
+	tx := con.Model(&db.WebhookDelivery{}).
+		Order("created_at DESC").
+		Order("id DESC").
+		Limit(params.Limit)
+	if params.Webhook != "" {
+		tx = tx.Where("webhook = ?", params.Webhook)
+	}
+	if params.Status != "" {
+		tx = tx.Where("status = ?", params.Status)
+	}
+
+	deliveries := []db.WebhookDelivery{}
+	if err := tx.Find(&deliveries).Error; err != nil {
+		return err
+	}
+
+	type values struct {
+		Params     Params
+		Statuses   []string
+		Deliveries []db.WebhookDelivery
+	}
+	v := values{
+		Params:     params,
+		Statuses:   []string{db.WebhookDeliveryPending, db.WebhookDeliveryDelivered, db.WebhookDeliveryFailed},
+		Deliveries: deliveries,
+	}
+
+	return a.executeTemplateAsResponse(ctx, w, "webhooks.html", v, "../")
+}
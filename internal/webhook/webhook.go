@@ -0,0 +1,392 @@
+// Package webhook notifies the webhooks in the server configuration of server events.
+// Deliveries are queued in the database (in the same transaction as the ingest) and sent in the background with retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"gorm.io/gorm"
+
+	"github.com/act3-ai/go-common/pkg/logger"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/selector"
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+const (
+	// maxAttempts is the number of times a delivery is attempted before it is marked as failed.
+	maxAttempts = 10
+
+	// minBackoff is the delay before the first retry.  The delay doubles on each retry up to maxBackoff.
+	minBackoff = 10 * time.Second
+
+	// maxBackoff is the longest delay between retries.
+	maxBackoff = time.Hour
+
+	// deliveryTimeout is how long we wait for a webhook to respond.
+	deliveryTimeout = 30 * time.Second
+
+	// leaseDuration is how long a claimed delivery is hidden from other replicas.
+	leaseDuration = 2 * deliveryTimeout
+
+	// pollInterval is how often the queue is checked for retries and for deliveries queued by other replicas.
+	pollInterval = 15 * time.Second
+
+	// batchSize is the maximum number of deliveries claimed at a time.
+	batchSize = 10
+)
+
+// Dispatcher queues and delivers webhook payloads.
+type Dispatcher struct {
+	hooks  []v1alpha2.Webhook
+	client *http.Client
+	wake   chan struct{}
+}
+
+// NewDispatcher validates the webhook configuration and creates a Dispatcher.
+func NewDispatcher(hooks []v1alpha2.Webhook) (*Dispatcher, error) {
+	names := make(map[string]bool, len(hooks))
+	for _, hook := range hooks {
+		if hook.Name == "" {
+			return nil, errors.New("webhook name is required")
+		}
+		if names[hook.Name] {
+			return nil, fmt.Errorf("duplicate webhook name %q", hook.Name)
+		}
+		names[hook.Name] = true
+
+		u, err := url.Parse(hook.URL)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %q has an invalid URL: %w", hook.Name, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("webhook %q URL must be http or https", hook.Name)
+		}
+
+		if hook.Secret == "" {
+			return nil, fmt.Errorf("webhook %q secret is required", hook.Name)
+		}
+
+		for _, event := range hook.Events {
+			if !slices.Contains(types.WebhookEvents, event) {
+				return nil, fmt.Errorf("webhook %q has an unknown event %q, expected one of %v", hook.Name, event, types.WebhookEvents)
+			}
+		}
+
+		for _, s := range hook.Selectors {
+			if _, err := selector.Parse(s); err != nil {
+				return nil, fmt.Errorf("webhook %q has an invalid selector %q: %w", hook.Name, s, err)
+			}
+		}
+	}
+
+	return &Dispatcher{
+		hooks:  hooks,
+		client: &http.Client{Timeout: deliveryTimeout},
+		wake:   make(chan struct{}, 1),
+	}, nil
+}
+
+// Enqueue queues deliveries for the events caused by a newly ingested object.
+// The object is identified by the table it was ingested into, the ID of its data, and the digest it was ingested with.
+// Only bottles and signatures cause events.
+func (d *Dispatcher) Enqueue(con *gorm.DB, table string, dataID uint, dgst digest.Digest) error {
+	if len(d.hooks) == 0 {
+		return nil
+	}
+
+	switch table {
+	case "bottles":
+		return d.enqueueBottle(con, dataID, dgst)
+	case "signatures":
+		return d.enqueueSignatures(con, dataID)
+	default:
+		return nil
+	}
+}
+
+// enqueueBottle queues the bottle event and a deprecation event for each bottle that it deprecates.
+func (d *Dispatcher) enqueueBottle(con *gorm.DB, dataID uint, dgst digest.Digest) error {
+	bottle := db.Bottle{}
+	if err := con.Preload("Labels").Preload("Deprecates").Where("data_id = ?", dataID).First(&bottle).Error; err != nil {
+		return fmt.Errorf("loading bottle: %w", err)
+	}
+
+	now := time.Now()
+	payload := types.WebhookPayload{
+		Event:        types.WebhookEventBottle,
+		Timestamp:    now,
+		BottleDigest: dgst,
+		Labels:       labelMap(bottle.Labels),
+	}
+	if err := d.enqueuePayload(con, bottle.ID, payload); err != nil {
+		return err
+	}
+
+	for _, deprecated := range bottle.Deprecates {
+		payload.Event = types.WebhookEventDeprecation
+		payload.DeprecatedBottleDigest = deprecated.DeprecatedBottleDigest
+		if err := d.enqueuePayload(con, bottle.ID, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueSignatures queues a signature event for each signature.
+// Only valid signatures are ingested so there is no need to validate them here.
+func (d *Dispatcher) enqueueSignatures(con *gorm.DB, dataID uint) error {
+	signatures := []db.Signature{}
+	if err := con.Preload("Annotations").Preload("Bottle.Labels").Where("data_id = ?", dataID).Find(&signatures).Error; err != nil {
+		return fmt.Errorf("loading signatures: %w", err)
+	}
+
+	now := time.Now()
+	for _, sig := range signatures {
+		annotations := make(map[string]string, len(sig.Annotations))
+		for _, annotation := range sig.Annotations {
+			annotations[annotation.Key] = annotation.Value
+		}
+		payload := types.WebhookPayload{
+			Event:        types.WebhookEventSignature,
+			Timestamp:    now,
+			BottleDigest: sig.BottleDigest,
+			Labels:       labelMap(sig.Bottle.Labels),
+			Signature: &types.WebhookSignature{
+				ManifestDigest:       sig.ManifestDigest,
+				SignatureType:        sig.SignatureType,
+				PublicKeyFingerprint: sig.PublicKeyFingerPrint,
+				Annotations:          annotations,
+			},
+		}
+		if err := d.enqueuePayload(con, sig.BottleID, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueuePayload queues a delivery of the payload for each webhook that subscribes to the event for the bottle.
+func (d *Dispatcher) enqueuePayload(con *gorm.DB, bottleID uint, payload types.WebhookPayload) error {
+	for _, hook := range d.hooks {
+		if len(hook.Events) != 0 && !slices.Contains(hook.Events, payload.Event) {
+			continue
+		}
+
+		ok, err := matchesSelectors(con, hook.Selectors, bottleID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		payload.Webhook = hook.Name
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("encoding webhook payload: %w", err)
+		}
+
+		delivery := db.WebhookDelivery{
+			Webhook:       hook.Name,
+			URL:           hook.URL,
+			Event:         payload.Event,
+			Payload:       data,
+			Status:        db.WebhookDeliveryPending,
+			NextAttemptAt: payload.Timestamp,
+		}
+		if err := con.Create(&delivery).Error; err != nil {
+			return fmt.Errorf("queueing webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// matchesSelectors returns true if the bottle matches any of the selectors (or there are no selectors).
+func matchesSelectors(con *gorm.DB, selectors []string, bottleID uint) (bool, error) {
+	if len(selectors) == 0 {
+		return true, nil
+	}
+	var count int64
+	err := con.Session(&gorm.Session{NewDB: true}).
+		Table("bottles").
+		Where("bottles.id = ?", bottleID).
+		Scopes(db.FilterBySelectors(selectors)).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("matching webhook selectors: %w", err)
+	}
+	return count > 0, nil
+}
+
+func labelMap(labels []db.Label) map[string]string {
+	m := make(map[string]string, len(labels))
+	for _, label := range labels {
+		m[label.Key] = label.Value
+	}
+	return m
+}
+
+// Wake tells Run to check the queue now.  It should be called after deliveries are committed.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+		// already awake
+	}
+}
+
+// Run delivers queued payloads until the context is done.
+// Each replica runs its own dispatcher.  Deliveries are claimed with a lease so only one replica sends each attempt.
+func (d *Dispatcher) Run(ctx context.Context, con *gorm.DB) {
+	log := logger.FromContext(ctx)
+	con = con.WithContext(ctx)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := d.deliverDue(ctx, con)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.ErrorContext(ctx, "Delivering webhooks", "error", err)
+				}
+				break
+			}
+			if n < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue claims and attempts the deliveries that are due.
+// It returns the number of deliveries that were due.
+func (d *Dispatcher) deliverDue(ctx context.Context, con *gorm.DB) (int, error) {
+	now := time.Now()
+	due := []db.WebhookDelivery{}
+	err := con.Where("status = ? AND next_attempt_at <= ?", db.WebhookDeliveryPending, now).
+		Order("next_attempt_at").
+		Order("id").
+		Limit(batchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, fmt.Errorf("finding due webhook deliveries: %w", err)
+	}
+
+	for i := range due {
+		delivery := &due[i]
+
+		// claim the delivery so other replicas skip it while it is in flight
+		res := con.Model(&db.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, db.WebhookDeliveryPending, now).
+			Update("next_attempt_at", now.Add(leaseDuration))
+		if res.Error != nil {
+			return 0, fmt.Errorf("claiming webhook delivery: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		d.attempt(ctx, delivery)
+		if err := con.Save(delivery).Error; err != nil {
+			return 0, fmt.Errorf("saving webhook delivery: %w", err)
+		}
+	}
+	return len(due), nil
+}
+
+// attempt sends the delivery and updates its status.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *db.WebhookDelivery) {
+	log := logger.FromContext(ctx).With("webhook", delivery.Webhook, "delivery", delivery.ID, "event", delivery.Event)
+
+	i := slices.IndexFunc(d.hooks, func(hook v1alpha2.Webhook) bool {
+		return hook.Name == delivery.Webhook
+	})
+	if i < 0 {
+		log.WarnContext(ctx, "Dropping delivery for a webhook that is no longer configured")
+		delivery.Status = db.WebhookDeliveryFailed
+		delivery.LastError = "webhook is no longer configured"
+		return
+	}
+	hook := d.hooks[i]
+
+	delivery.Attempts++
+	delivery.URL = hook.URL
+	delivery.LastStatusCode, delivery.LastError = 0, ""
+
+	statusCode, err := d.send(ctx, hook, delivery)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		now := time.Now()
+		delivery.Status = db.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		log.InfoContext(ctx, "Webhook delivered", "attempts", delivery.Attempts)
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = db.WebhookDeliveryFailed
+		log.ErrorContext(ctx, "Webhook delivery failed, giving up", "attempts", delivery.Attempts, "error", err)
+		return
+	}
+	delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts))
+	log.WarnContext(ctx, "Webhook delivery failed, will retry", "attempts", delivery.Attempts, "nextAttemptAt", delivery.NextAttemptAt, "error", err)
+}
+
+// send POSTs the signed payload to the webhook.
+// It returns the response status code (zero if there was no response).
+func (d *Dispatcher) send(ctx context.Context, hook v1alpha2.Webhook, delivery *db.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(types.HeaderWebhookEvent, delivery.Event)
+	req.Header.Set(types.HeaderWebhookDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(types.HeaderWebhookSignature, types.SignWebhookPayload([]byte(hook.Secret), delivery.Payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+
+	// drain (a bit of) the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt after the given number of attempts.
+func backoff(attempts uint) time.Duration {
+	delay := minBackoff
+	for i := uint(1); i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
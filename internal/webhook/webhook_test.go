@@ -0,0 +1,199 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bottle "github.com/act3-ai/bottle-schema/pkg/apis/data.act3-ace.io"
+	"github.com/act3-ai/go-common/pkg/logger"
+	"github.com/act3-ai/go-common/pkg/test"
+
+	"github.com/act3-ai/data-telemetry/v3/internal/api"
+	"github.com/act3-ai/data-telemetry/v3/internal/db"
+	"github.com/act3-ai/data-telemetry/v3/internal/middleware"
+	ttest "github.com/act3-ai/data-telemetry/v3/internal/testing"
+	"github.com/act3-ai/data-telemetry/v3/internal/webhook"
+	"github.com/act3-ai/data-telemetry/v3/pkg/apis/config.telemetry.act3-ace.io/v1alpha2"
+	client "github.com/act3-ai/data-telemetry/v3/pkg/client"
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
+)
+
+const testSecret = "s3cr3t"
+
+// receiver records the payloads POSTed to a webhook.
+type receiver struct {
+	mu       sync.Mutex
+	payloads map[string][]types.WebhookPayload // by webhook name
+	invalid  int
+}
+
+func (rcv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	if !types.VerifyWebhookPayload([]byte(testSecret), body, r.Header.Get(types.HeaderWebhookSignature)) {
+		rcv.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload types.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Event != r.Header.Get(types.HeaderWebhookEvent) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rcv.payloads[payload.Webhook] = append(rcv.payloads[payload.Webhook], payload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rcv *receiver) get(name string) []types.WebhookPayload {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return rcv.payloads[name]
+}
+
+type WebhookTestSuite struct {
+	suite.Suite
+	dataDir  string
+	log      *slog.Logger
+	ctx      context.Context
+	db       *gorm.DB
+	receiver *receiver
+}
+
+func (s *WebhookTestSuite) SetupTest() {
+	s.dataDir = filepath.Join("..", "..", "testdata")
+	s.log = test.Logger(s.T(), 0)
+	s.ctx = logger.NewContext(context.Background(), s.log)
+
+	scheme := runtime.NewScheme()
+	s.NoError(bottle.AddToScheme(scheme))
+
+	var err error
+	s.db, err = db.Open(s.ctx, v1alpha2.Database{DSN: "file::memory:"}, scheme)
+	s.Require().NoError(err)
+
+	s.receiver = &receiver{payloads: map[string][]types.WebhookPayload{}}
+	hookServer := httptest.NewServer(s.receiver)
+	s.T().Cleanup(hookServer.Close)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	s.T().Cleanup(failing.Close)
+
+	dispatcher, err := webhook.NewDispatcher([]v1alpha2.Webhook{
+		{Name: "all", URL: hookServer.URL, Secret: testSecret},
+		{Name: "deprecations", URL: hookServer.URL, Secret: testSecret, Events: []string{types.WebhookEventDeprecation}, Selectors: []string{"refname=bottle1"}},
+		{Name: "unmatched", URL: hookServer.URL, Secret: testSecret, Selectors: []string{"type=production"}},
+		{Name: "failing", URL: failing.URL, Secret: testSecret, Events: []string{types.WebhookEventSignature}},
+	})
+	s.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx, s.db)
+	}()
+	s.T().Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	serveMux := http.NewServeMux()
+	a := &api.API{Webhooks: dispatcher}
+	a.Initialize(serveMux, scheme)
+	server := httptest.NewServer(middleware.DatabaseMiddleware(s.db)(serveMux))
+	s.T().Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	s.Require().NoError(err)
+	s.Require().NoError(client.UploadAll(s.ctx, server.Client(), s.dataDir, u, "", false))
+}
+
+func (s *WebhookTestSuite) TestDeliveries() {
+	bottleDigest, err := ttest.FileDigest(filepath.Join(s.dataDir, "bottle", "bottle1.json"), "sha256")
+	s.Require().NoError(err)
+
+	s.Eventually(func() bool {
+		var pending int64
+		s.NoError(s.db.Model(&db.WebhookDelivery{}).Where("status = ? AND attempts = 0", db.WebhookDeliveryPending).Count(&pending).Error)
+		return pending == 0
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// the selector and event filters only let the deprecation of bottle1 through
+	deprecations := s.receiver.get("deprecations")
+	s.Require().Len(deprecations, 1)
+	s.Equal(types.WebhookEventDeprecation, deprecations[0].Event)
+	s.Equal(bottleDigest, deprecations[0].BottleDigest)
+	s.Equal(digest.Digest("sha256:2e9e86ac5509a9870d4109c1d0d26d160cc7ce21d8350ac74d37371894d300f6"), deprecations[0].DeprecatedBottleDigest)
+	s.Equal("bottle1", deprecations[0].Labels["refname"])
+
+	events := map[string]int{}
+	for _, payload := range s.receiver.get("all") {
+		events[payload.Event]++
+		if payload.Event == types.WebhookEventSignature {
+			s.NotNil(payload.Signature)
+		}
+	}
+	s.Equal(7, events[types.WebhookEventBottle])
+	s.Equal(3, events[types.WebhookEventDeprecation])
+	s.Positive(events[types.WebhookEventSignature])
+
+	s.Empty(s.receiver.get("unmatched"))
+	s.Zero(s.receiver.invalid)
+
+	// failed deliveries stay in the queue to be retried
+	failed := []db.WebhookDelivery{}
+	s.NoError(s.db.Where("webhook = ?", "failing").Find(&failed).Error)
+	s.Require().NotEmpty(failed)
+	for _, delivery := range failed {
+		s.Equal(db.WebhookDeliveryPending, delivery.Status)
+		s.Equal(uint(1), delivery.Attempts)
+		s.Equal(http.StatusServiceUnavailable, delivery.LastStatusCode)
+		s.True(delivery.NextAttemptAt.After(time.Now()))
+	}
+
+	delivered := []db.WebhookDelivery{}
+	s.NoError(s.db.Where("webhook = ?", "all").Find(&delivered).Error)
+	for _, delivery := range delivered {
+		s.Equal(db.WebhookDeliveryDelivered, delivery.Status)
+		s.NotNil(delivery.DeliveredAt)
+	}
+}
+
+func (s *WebhookTestSuite) TestInvalidConfig() {
+	_, err := webhook.NewDispatcher([]v1alpha2.Webhook{{Name: "a", URL: "http://example.com", Secret: "x", Events: []string{"bogus"}}})
+	s.Error(err)
+
+	_, err = webhook.NewDispatcher([]v1alpha2.Webhook{{Name: "a", URL: "http://example.com"}})
+	s.Error(err)
+
+	_, err = webhook.NewDispatcher([]v1alpha2.Webhook{{Name: "a", URL: "http://example.com", Secret: "x", Selectors: []string{"a=b=c"}}})
+	s.Error(err)
+}
+
+func TestWebhookTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
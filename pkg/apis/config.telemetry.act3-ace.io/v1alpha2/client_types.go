@@ -40,6 +40,9 @@ type Location struct {
 
 	// Bearer token to use for authentication
 	Token redact.Secret `json:"token,omitempty" datapolicy:"token"`
+
+	// SigningKeyFile is the path to a PEM encoded ed25519 private key used to sign upload requests
+	SigningKeyFile string `json:"signingKeyFile,omitempty"`
 }
 
 // OAuthProvider defines a host and client application ID used for OAuth2.0 Device Grant authentication
@@ -97,4 +100,8 @@ request:
     url: http://localhost:8100
     cookies:
       foo: something else
+  - name: Edge Relay
+    url: https://relay.example.com
+    # uploads are signed with this ed25519 key (created with "openssl genpkey -algorithm ed25519")
+    signingKeyFile: /etc/telemetry/signing.pem
 `
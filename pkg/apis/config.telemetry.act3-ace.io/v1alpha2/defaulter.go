@@ -1,5 +1,7 @@
 package v1alpha2
 
+import "time"
+
 // ServerConfigurationDefault defaults the configuration values.
 func ServerConfigurationDefault(obj *ServerConfiguration) {
 	// This is called after we decode the values (from file) so we need to be careful not to overwrite values that are already set.
@@ -12,6 +14,18 @@ func ServerConfigurationDefault(obj *ServerConfiguration) {
 	if obj.DB.DSN == "" {
 		obj.DB.DSN = "file:test.db"
 	}
+
+	if obj.Auth.UsernameClaim == "" {
+		obj.Auth.UsernameClaim = "sub"
+	}
+
+	if obj.Auth.GroupsClaim == "" {
+		obj.Auth.GroupsClaim = "groups"
+	}
+
+	if obj.Pending.MaxAge.Duration == 0 {
+		obj.Pending.MaxAge.Duration = 7 * 24 * time.Hour
+	}
 }
 
 // ClientConfigurationDefault defaults the configuration values.
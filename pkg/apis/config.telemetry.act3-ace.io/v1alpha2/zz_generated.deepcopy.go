@@ -24,6 +24,75 @@ func (in *ACEHubInstance) DeepCopy() *ACEHubInstance {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRule) DeepCopyInto(out *AccessRule) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRule.
+func (in *AccessRule) DeepCopy() *AccessRule {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Auth) DeepCopyInto(out *Auth) {
+	*out = *in
+	if in.Read != nil {
+		in, out := &in.Read, &out.Read
+		*out = new(AccessRule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Write != nil {
+		in, out := &in.Write, &out.Write
+		*out = make(map[string]AccessRule, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	in.Admin.DeepCopyInto(&out.Admin)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Auth.
+func (in *Auth) DeepCopy() *Auth {
+	if in == nil {
+		return nil
+	}
+	out := new(Auth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlobStorage) DeepCopyInto(out *BlobStorage) {
+	*out = *in
+	out.S3 = in.S3
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlobStorage.
+func (in *BlobStorage) DeepCopy() *BlobStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(BlobStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BottleSpec) DeepCopyInto(out *BottleSpec) {
 	*out = *in
@@ -91,9 +160,25 @@ func (in *ClientConfigurationSpec) DeepCopy() *ClientConfigurationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientKey) DeepCopyInto(out *ClientKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientKey.
+func (in *ClientKey) DeepCopy() *ClientKey {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Database) DeepCopyInto(out *Database) {
 	*out = *in
+	out.Storage = in.Storage
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Database.
@@ -106,6 +191,22 @@ func (in *Database) DeepCopy() *Database {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventRetention) DeepCopyInto(out *EventRetention) {
+	*out = *in
+	out.MaxAge = in.MaxAge
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventRetention.
+func (in *EventRetention) DeepCopy() *EventRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(EventRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GPU) DeepCopyInto(out *GPU) {
 	*out = *in
@@ -166,6 +267,21 @@ func (in *HubEnvTemplateSpec) DeepCopy() *HubEnvTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityConstraint) DeepCopyInto(out *IdentityConstraint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityConstraint.
+func (in *IdentityConstraint) DeepCopy() *IdentityConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Location) DeepCopyInto(out *Location) {
 	*out = *in
@@ -204,6 +320,22 @@ func (in *OAuthProvider) DeepCopy() *OAuthProvider {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingQueue) DeepCopyInto(out *PendingQueue) {
+	*out = *in
+	out.MaxAge = in.MaxAge
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingQueue.
+func (in *PendingQueue) DeepCopy() *PendingQueue {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingQueue)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Port) DeepCopyInto(out *Port) {
 	*out = *in
@@ -219,6 +351,62 @@ func (in *Port) DeepCopy() *Port {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestSigning) DeepCopyInto(out *RequestSigning) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]ClientKey, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestSigning.
+func (in *RequestSigning) DeepCopy() *RequestSigning {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestSigning)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Retention) DeepCopyInto(out *Retention) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]EventRetention, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Retention.
+func (in *Retention) DeepCopy() *Retention {
+	if in == nil {
+		return nil
+	}
+	out := new(Retention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3Storage) DeepCopyInto(out *S3Storage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Storage.
+func (in *S3Storage) DeepCopy() *S3Storage {
+	if in == nil {
+		return nil
+	}
+	out := new(S3Storage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServerConfiguration) DeepCopyInto(out *ServerConfiguration) {
 	*out = *in
@@ -249,6 +437,18 @@ func (in *ServerConfigurationSpec) DeepCopyInto(out *ServerConfigurationSpec) {
 	*out = *in
 	out.DB = in.DB
 	in.WebApp.DeepCopyInto(&out.WebApp)
+	in.Trust.DeepCopyInto(&out.Trust)
+	if in.Webhooks != nil {
+		in, out := &in.Webhooks, &out.Webhooks
+		*out = make([]Webhook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
+	in.RequestSigning.DeepCopyInto(&out.RequestSigning)
+	in.Retention.DeepCopyInto(&out.Retention)
+	out.Pending = in.Pending
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerConfigurationSpec.
@@ -261,6 +461,36 @@ func (in *ServerConfigurationSpec) DeepCopy() *ServerConfigurationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Trust) DeepCopyInto(out *Trust) {
+	*out = *in
+	if in.Fingerprints != nil {
+		in, out := &in.Fingerprints, &out.Fingerprints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RootCAs != nil {
+		in, out := &in.RootCAs, &out.RootCAs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Identities != nil {
+		in, out := &in.Identities, &out.Identities
+		*out = make([]IdentityConstraint, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Trust.
+func (in *Trust) DeepCopy() *Trust {
+	if in == nil {
+		return nil
+	}
+	out := new(Trust)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ViewerSpec) DeepCopyInto(out *ViewerSpec) {
 	*out = *in
@@ -277,6 +507,31 @@ func (in *ViewerSpec) DeepCopy() *ViewerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Webhook) DeepCopyInto(out *Webhook) {
+	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selectors != nil {
+		in, out := &in.Selectors, &out.Selectors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Webhook.
+func (in *Webhook) DeepCopy() *Webhook {
+	if in == nil {
+		return nil
+	}
+	out := new(Webhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebApp) DeepCopyInto(out *WebApp) {
 	*out = *in
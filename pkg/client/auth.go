@@ -1,7 +1,17 @@
 package client
 
 import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+
+	"github.com/act3-ai/data-telemetry/v3/pkg/types"
 )
 
 // AuthRequestOptsFunc can be passed to all API requests to customize the API request with auth options.
@@ -16,3 +26,59 @@ func WithBearerTokenAuth(token string) AuthRequestOptsFunc {
 		return nil
 	}
 }
+
+// WithRequestSignature signs the request body with the ed25519 key so the server can verify where the request came from.
+// The request is not signed when key is nil.
+func WithRequestSignature(key ed25519.PrivateKey) AuthRequestOptsFunc {
+	return func(req *http.Request) error {
+		if key == nil {
+			return nil
+		}
+
+		var body []byte
+		switch {
+		case req.GetBody != nil:
+			rc, err := req.GetBody()
+			if err != nil {
+				return fmt.Errorf("getting request body to sign: %w", err)
+			}
+			defer rc.Close()
+			body, err = io.ReadAll(rc)
+			if err != nil {
+				return fmt.Errorf("reading request body to sign: %w", err)
+			}
+		case req.Body != nil && req.Body != http.NoBody:
+			return errors.New("request body can not be signed since it can not be read twice")
+		}
+
+		pubkey, ok := key.Public().(ed25519.PublicKey)
+		if !ok {
+			return errors.New("invalid ed25519 private key")
+		}
+		req.Header.Set(types.HeaderSignature, base64.StdEncoding.EncodeToString(ed25519.Sign(key, body)))
+		req.Header.Set(types.HeaderPublicKey, base64.StdEncoding.EncodeToString(pubkey))
+		return nil
+	}
+}
+
+// LoadSigningKey reads a PEM encoded (PKCS #8) ed25519 private key used to sign requests.
+// Such a key can be created with "openssl genpkey -algorithm ed25519".
+func LoadSigningKey(file string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", file)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is a %T, not an ed25519 key", key)
+	}
+	return edKey, nil
+}
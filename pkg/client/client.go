@@ -42,17 +42,19 @@ type Client interface {
 	PutSignature(ctx context.Context, alg digest.Algorithm, signatureJSON []byte) error
 	// PutBlob makes a PUT request to the Telemetry Server on a Blob using algorithm and blob of JSON type
 	PutBlob(ctx context.Context, alg digest.Algorithm, blob []byte) error
+	// SendBulk sends the records (of any type and in any order) to the Telemetry Server in a single request and returns the status of each record
+	SendBulk(ctx context.Context, records []types.BulkRecord) ([]types.BulkRecordStatus, error)
 
-	// TODO Return the latest time as well????
+	// The List methods start after the cursor (an opaque token from ListResultEntry.Cursor of a previous listing) if not empty, otherwise after since.
 
-	// ListBlobs returns Blobs Lists details of type ListResultEntry and an error based of specified time and limit, on successful GET request to the telemetry Server
-	ListBlobs(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error)
-	// ListBottles returns Bottles Lists details of type ListResultEntry and an error based of specified time and limit, on successful GET request to the telemetry Server
-	ListBottles(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error)
-	// ListManifests returns Manifests Lists details of type ListResultEntry and an error based of specified time and limit, on successful GET request to the telemetry Server
-	ListManifests(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error)
-	// ListEvents returns Events Lists details of type ListResultEntry and an error based of specified time and limit, on successful GET request to the telemetry Server
-	ListEvents(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error)
+	// ListBlobs returns Blobs Lists details of type ListResultEntry and an error based of specified time or cursor and limit, on successful GET request to the telemetry Server
+	ListBlobs(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error)
+	// ListBottles returns Bottles Lists details of type ListResultEntry and an error based of specified time or cursor and limit, on successful GET request to the telemetry Server
+	ListBottles(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error)
+	// ListManifests returns Manifests Lists details of type ListResultEntry and an error based of specified time or cursor and limit, on successful GET request to the telemetry Server
+	ListManifests(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error)
+	// ListEvents returns Events Lists details of type ListResultEntry and an error based of specified time or cursor and limit, on successful GET request to the telemetry Server
+	ListEvents(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error)
 
 	// GetBlob retrieves and returns a blob with its digest
 	GetBlob(ctx context.Context, dgst digest.Digest) ([]byte, error)
@@ -79,8 +81,15 @@ type Client interface {
 	GetLocations(ctx context.Context, bottledigest digest.Digest) ([]types.LocationResponse, error)
 	// BottleSearch will search for a Bottle with selectors, description and return result in SearchResult type format
 	BottleSearch(ctx context.Context, selectors []string, description string, limit int, digestOnly bool) ([]types.SearchResult, error)
+	// Search will search for bottles with the filters of the catalog (in the web app) and return a page of results
+	Search(ctx context.Context, query types.SearchQuery) (*types.SearchResponse, error)
+	// GetLineage will return the lineage graph of a bottle with the given number of generations of ancestors and descendants
+	GetLineage(ctx context.Context, dgst digest.Digest, ancestors, descendants uint) (*types.Lineage, error)
 	// GetBottlesFromMetric will retrieve and return bottles with selectors and metric metric in a slice
 	GetBottlesFromMetric(ctx context.Context, selectors []string, metric string, limit int, desc bool) ([]byte, error)
+
+	// RemoveBottle removes a bottle (and everything that references it) from the Telemetry server, leaving a tombstone so it is not uploaded again.  It requires an admin.
+	RemoveBottle(ctx context.Context, dgst digest.Digest, reason string) error
 }
 
 // BottleDetailURL returns the URL to use to view the bottle with the given bottleDigest in a browser.  u is the telemetry server base URL and dgst is the bottle config digest.
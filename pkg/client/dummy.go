@@ -80,29 +80,36 @@ func (dc *Dummy) PutBlob(ctx context.Context, alg digest.Algorithm, blob []byte)
 	return nil
 }
 
+// SendBulk will make a Dummy SendBulk call.
+func (dc *Dummy) SendBulk(ctx context.Context, records []types.BulkRecord) ([]types.BulkRecordStatus, error) {
+	log := logger.FromContext(ctx)
+	log.InfoContext(ctx, "Dummy client called")
+	return nil, ErrNotFound
+}
+
 // ListBlobs will make a Dummy ListBlobs call.
-func (dc *Dummy) ListBlobs(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (dc *Dummy) ListBlobs(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	log := logger.FromContext(ctx)
 	log.InfoContext(ctx, "Dummy client called")
 	return nil, ErrNotFound
 }
 
 // ListBottles will make a Dummy ListBottles call.
-func (dc *Dummy) ListBottles(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (dc *Dummy) ListBottles(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	log := logger.FromContext(ctx)
 	log.InfoContext(ctx, "Dummy client called")
 	return nil, ErrNotFound
 }
 
 // ListManifests will make a Dummy ListManifests call.
-func (dc *Dummy) ListManifests(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (dc *Dummy) ListManifests(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	log := logger.FromContext(ctx)
 	log.InfoContext(ctx, "Dummy client called")
 	return nil, ErrNotFound
 }
 
 // ListEvents will make a Dummy ListEvents call.
-func (dc *Dummy) ListEvents(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (dc *Dummy) ListEvents(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	log := logger.FromContext(ctx)
 	log.InfoContext(ctx, "Dummy client called")
 	return nil, ErrNotFound
@@ -178,9 +185,30 @@ func (dc *Dummy) BottleSearch(ctx context.Context, selectors []string, descripti
 	return nil, ErrNotFound
 }
 
+// Search will make a Dummy Search call.
+func (dc *Dummy) Search(ctx context.Context, query types.SearchQuery) (*types.SearchResponse, error) {
+	log := logger.FromContext(ctx)
+	log.InfoContext(ctx, "Dummy client called")
+	return nil, ErrNotFound
+}
+
+// GetLineage will make a Dummy GetLineage call.
+func (dc *Dummy) GetLineage(ctx context.Context, dgst digest.Digest, ancestors, descendants uint) (*types.Lineage, error) {
+	log := logger.FromContext(ctx)
+	log.InfoContext(ctx, "Dummy client called")
+	return nil, ErrNotFound
+}
+
 // GetBottlesFromMetric will make a Dummy GetBottlesFromMetric call.
 func (dc *Dummy) GetBottlesFromMetric(ctx context.Context, selectors []string, metric string, limit int, desc bool) ([]byte, error) {
 	log := logger.FromContext(ctx)
 	log.InfoContext(ctx, "Dummy client called")
 	return nil, ErrNotFound
 }
+
+// RemoveBottle will make a Dummy RemoveBottle call.
+func (dc *Dummy) RemoveBottle(ctx context.Context, dgst digest.Digest, reason string) error {
+	log := logger.FromContext(ctx)
+	log.InfoContext(ctx, "Dummy client called")
+	return ErrNotFound
+}
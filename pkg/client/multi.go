@@ -95,6 +95,28 @@ func (mc *MultiClient) PutBlob(ctx context.Context, alg digest.Algorithm, blob [
 	return errors.Join(errs...)
 }
 
+// SendBulk will send the records to all the apis.  The statuses from the first api are returned.
+func (mc *MultiClient) SendBulk(ctx context.Context, records []types.BulkRecord) ([]types.BulkRecordStatus, error) {
+	type bulkResult struct {
+		statuses []types.BulkRecordStatus
+		err      error
+	}
+	results := parallelMap(mc.clients, func(client Client, _ int) bulkResult {
+		statuses, err := client.SendBulk(ctx, records)
+		return bulkResult{statuses, err}
+	})
+
+	var statuses []types.BulkRecordStatus
+	errs := make([]error, 0, len(results))
+	for i, result := range results {
+		if i == 0 {
+			statuses = result.statuses
+		}
+		errs = append(errs, result.err)
+	}
+	return statuses, errors.Join(errs...)
+}
+
 // Upload will upload files to api.
 func (mc *MultiClient) Upload(ctx context.Context, file string, skipInvalid bool) error {
 	errs := parallelMap(mc.clients, func(client Client, _ int) error {
@@ -128,30 +150,30 @@ func (mc *MultiClient) DownloadAll(ctx context.Context, since time.Time, fromlat
 }
 
 // ListBlobs will make a get a list of blobs from a specific time.
-func (mc *MultiClient) ListBlobs(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (mc *MultiClient) ListBlobs(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	return genericGet(mc, func(client Client) ([]types.ListResultEntry, error) {
-		return client.ListBlobs(ctx, since, limit)
+		return client.ListBlobs(ctx, since, cursor, limit)
 	})
 }
 
 // ListBottles will make a get a bottles list from a specific time.
-func (mc *MultiClient) ListBottles(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (mc *MultiClient) ListBottles(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	return genericGet(mc, func(client Client) ([]types.ListResultEntry, error) {
-		return client.ListBottles(ctx, since, limit)
+		return client.ListBottles(ctx, since, cursor, limit)
 	})
 }
 
 // ListManifests will make a get a manifests list from a specific time.
-func (mc *MultiClient) ListManifests(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (mc *MultiClient) ListManifests(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	return genericGet(mc, func(client Client) ([]types.ListResultEntry, error) {
-		return client.ListManifests(ctx, since, limit)
+		return client.ListManifests(ctx, since, cursor, limit)
 	})
 }
 
 // ListEvents will make a get an events list from a specific time.
-func (mc *MultiClient) ListEvents(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
+func (mc *MultiClient) ListEvents(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
 	return genericGet(mc, func(client Client) ([]types.ListResultEntry, error) {
-		return client.ListEvents(ctx, since, limit)
+		return client.ListEvents(ctx, since, cursor, limit)
 	})
 }
 
@@ -197,9 +219,42 @@ func (mc *MultiClient) BottleSearch(ctx context.Context, selectors []string, des
 	})
 }
 
+// Search will return a page of the bottles that match the query (from the first api that responds).
+func (mc *MultiClient) Search(ctx context.Context, query types.SearchQuery) (*types.SearchResponse, error) {
+	return genericGet(mc, func(client Client) (*types.SearchResponse, error) {
+		return client.Search(ctx, query)
+	})
+}
+
+// GetLineage will return the lineage graph of the bottle (from the first api that responds).
+func (mc *MultiClient) GetLineage(ctx context.Context, dgst digest.Digest, ancestors, descendants uint) (*types.Lineage, error) {
+	return genericGet(mc, func(client Client) (*types.Lineage, error) {
+		return client.GetLineage(ctx, dgst, ancestors, descendants)
+	})
+}
+
 // GetBottlesFromMetric will return the bottles using metric.
 func (mc *MultiClient) GetBottlesFromMetric(ctx context.Context, selectors []string, metric string, limit int, desc bool) ([]byte, error) {
 	return genericGet(mc, func(client Client) ([]byte, error) {
 		return client.GetBottlesFromMetric(ctx, selectors, metric, limit, desc)
 	})
 }
+
+// RemoveBottle will remove the bottle from every api that has it.
+func (mc *MultiClient) RemoveBottle(ctx context.Context, dgst digest.Digest, reason string) error {
+	errs := parallelMap(mc.clients, func(client Client, _ int) error {
+		return client.RemoveBottle(ctx, dgst, reason)
+	})
+	found := false
+	for i, err := range errs {
+		if errors.Is(err, ErrNotFound) {
+			errs[i] = nil
+			continue
+		}
+		found = true
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return errors.Join(errs...)
+}
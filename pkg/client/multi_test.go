@@ -37,6 +37,8 @@ type MultiTestSuite struct {
 	log     *slog.Logger
 	ctx     context.Context
 	client  *MultiClient
+	issuer  *ttest.Issuer
+	token   string
 }
 
 func (s *MultiTestSuite) getBlobByDigest(dgst digest.Digest) ([]byte, error) {
@@ -63,19 +65,29 @@ func (s *MultiTestSuite) SetupTest() {
 	}, scheme)
 	s.NoError(err)
 
+	// removing bottles requires an admin
+	s.issuer, err = ttest.NewIssuer()
+	s.NoError(err)
+	s.token, err = s.issuer.Token(map[string]any{"sub": "root"})
+	s.NoError(err)
+	authMiddleware := middleware.AuthMiddleware(middleware.NewAuthenticator(v1alpha2.Auth{
+		Issuer: s.issuer.URL,
+		Admin:  v1alpha2.AccessRule{Users: []string{"root"}},
+	}))
+
 	// initializing 2 apis for different clients
 	apiA := api.API{}
 	apiMuxA := http.NewServeMux()
 	apiA.Initialize(apiMuxA, scheme)
 	routerA := http.NewServeMux()
-	routerA.Handle("/api/", http.StripPrefix("/api", apiMuxA))
+	routerA.Handle("/api/", http.StripPrefix("/api", authMiddleware(apiMuxA)))
 	wrappedRouterA := httputil.LoggingMiddleware(s.log)(middleware.DatabaseMiddleware(myDB)(routerA))
 
 	apiB := api.API{}
 	apiMuxB := http.NewServeMux()
 	apiB.Initialize(apiMuxB, scheme)
 	routerB := http.NewServeMux()
-	routerB.Handle("/api/", http.StripPrefix("/api", apiMuxB))
+	routerB.Handle("/api/", http.StripPrefix("/api", authMiddleware(apiMuxB)))
 	wrappedRouterB := httputil.LoggingMiddleware(s.log)(middleware.DatabaseMiddleware(myDB2)(routerB))
 
 	// process and load the blobs
@@ -90,12 +102,12 @@ func (s *MultiTestSuite) SetupTest() {
 	s.serverA = httptest.NewServer(wrappedRouterA)
 	s.serverB = httptest.NewServer(wrappedRouterB)
 
-	client1, err := NewSingleClient(s.serverA.Client(), s.serverA.URL, "mycooltoken")
+	client1, err := NewSingleClient(s.serverA.Client(), s.serverA.URL, s.token)
 	s.NoError(err)
 
 	client2 := &Dummy{}
 
-	client3, err := NewSingleClient(s.serverB.Client(), s.serverB.URL, "mycooltoken")
+	client3, err := NewSingleClient(s.serverB.Client(), s.serverB.URL, s.token)
 	s.NoError(err)
 
 	// initiate a new multiclient
@@ -106,6 +118,7 @@ func (s *MultiTestSuite) TearDownTest() {
 	// Close the server when test finishes
 	s.serverA.Close()
 	s.serverB.Close()
+	s.issuer.Close()
 }
 
 func (s *MultiTestSuite) TestUploadAll() {
@@ -301,7 +314,7 @@ func (s *MultiTestSuite) TestListBlobs() {
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getblob, err := s.client.ListBlobs(s.ctx, askTime, 10)
+	getblob, err := s.client.ListBlobs(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getblob)
@@ -320,7 +333,7 @@ func (s *MultiTestSuite) TestListBottles() {
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getbottles, err := s.client.ListBottles(s.ctx, askTime, 10)
+	getbottles, err := s.client.ListBottles(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getbottles)
@@ -338,7 +351,7 @@ func (s *MultiTestSuite) TestListManifests() {
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getManifests, err := s.client.ListManifests(s.ctx, askTime, 10)
+	getManifests, err := s.client.ListManifests(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getManifests)
@@ -356,7 +369,7 @@ func (s *MultiTestSuite) TestListEvents() {
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getEvents, err := s.client.ListEvents(s.ctx, askTime, 10)
+	getEvents, err := s.client.ListEvents(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getEvents)
@@ -477,6 +490,51 @@ func (s *MultiTestSuite) TestGetBottlesFromMetric() {
 	s.NotEmpty(metricSearch)
 }
 
+func (s *MultiTestSuite) TestRemoveBottle() {
+	s.NoError(s.client.UploadAll(s.ctx, s.dataDir, false))
+
+	bottleDigest, err := ttest.FileDigest(filepath.Join(s.dataDir, "bottle", "bottle1.json"), "sha256")
+	s.NoError(err)
+
+	// mirror server A
+	a, err := NewSingleClient(s.serverA.Client(), s.serverA.URL, s.token)
+	s.NoError(err)
+	mirror := s.T().TempDir()
+	s.NoError(a.DownloadAll(s.ctx, time.Time{}, true, 100, mirror))
+	bottleFile := filepath.Join(mirror, "bottle", bottleDigest.Algorithm().String()+"-"+bottleDigest.Encoded())
+	s.FileExists(bottleFile)
+
+	s.NoError(s.client.RemoveBottle(s.ctx, bottleDigest, "testing"))
+	s.ErrorIs(s.client.RemoveBottle(s.ctx, bottleDigest, "testing"), ErrNotFound)
+	_, err = a.GetBottle(s.ctx, bottleDigest)
+	s.Error(err)
+
+	// the removed objects are not accepted again
+	err = a.Upload(s.ctx, filepath.Join(s.dataDir, "bottle", "index.csv"), false)
+	s.NoError(err)
+	err = a.PutBottle(s.ctx, digest.SHA256, s.readFile("bottle", "bottle1.json"))
+	s.ErrorIs(err, ErrRemoved)
+	err = a.PutEvent(s.ctx, digest.SHA256, s.readFile("event", "push1.json"))
+	s.ErrorIs(err, ErrRemoved)
+
+	// the mirror discards its copy so it does not upload it again
+	s.NoError(a.DownloadAll(s.ctx, time.Time{}, true, 100, mirror))
+	s.NoFileExists(bottleFile)
+	index, err := os.ReadFile(filepath.Join(mirror, "bottle", "index.csv"))
+	s.NoError(err)
+	s.NotContains(string(index), bottleDigest.Encoded())
+
+	c, err := NewSingleClient(s.serverB.Client(), s.serverB.URL, s.token)
+	s.NoError(err)
+	s.NoError(c.UploadAll(s.ctx, mirror, false))
+}
+
+func (s *MultiTestSuite) readFile(objType, name string) []byte {
+	data, err := os.ReadFile(filepath.Join(s.dataDir, objType, name))
+	s.NoError(err)
+	return data
+}
+
 func TestMultiTestSuite(t *testing.T) {
 	suite.Run(t, new(MultiTestSuite))
 }
@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/opencontainers/go-digest"
@@ -61,18 +62,30 @@ func NewRequestClientFromConfig(conf v1alpha2.ClientConfiguration) (*http.Client
 	return c, nil
 }
 
-// getLatestTimestamp reads the index.latest file and parses the timestamp.
-func getLatestTimestamp(latestFile string) (*time.Time, error) {
-	// use that instead of "since"
+// readLatest reads the index.latest file.  It contains the cursor of the last entry downloaded.
+// Files written by older versions (or when the server does not provide cursors) contain the timestamp of the last entry instead.
+func readLatest(latestFile string) (since *time.Time, cursor string, err error) {
 	b, err := os.ReadFile(latestFile)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read latest timestamp: %w", err)
+		return nil, "", fmt.Errorf("unable to read latest cursor: %w", err)
 	}
-	t, err := time.Parse(time.RFC3339Nano, string(b))
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse latest timestamp: %w", err)
+	content := strings.TrimSpace(string(b))
+	if content == "" {
+		return nil, "", errors.New("latest cursor is empty")
+	}
+	if t, err := time.Parse(time.RFC3339Nano, content); err == nil {
+		return &t, "", nil
+	}
+	return nil, content, nil
+}
+
+// writeLatest writes the cursor (or timestamp when there is no cursor) of the last entry downloaded to the index.latest file.
+func writeLatest(latestFile string, since time.Time, cursor string) error {
+	content := cursor
+	if content == "" {
+		content = since.Format(time.RFC3339Nano)
 	}
-	return &t, nil
+	return os.WriteFile(latestFile, []byte(content), os.ModePerm)
 }
 
 // Download raw objects.
@@ -85,6 +98,16 @@ func Download(ctx context.Context, c *http.Client, since time.Time, fromLatest b
 		return fmt.Errorf("download operation failed to make directories: %w", err)
 	}
 
+	// discard what we have of bottles removed from the server so they are not uploaded (e.g., to a mirror) again
+	tombstones, err := listTombstones(ctx, c, u, WithBearerTokenAuth(token))
+	if err != nil {
+		// older servers do not have tombstones
+		log.InfoContext(ctx, "Unable to get the removed bottles", "error", err)
+	}
+	if err := pruneRemoved(ctx, file, tombstones); err != nil {
+		return err
+	}
+
 	// open for writing and appending (create if not already existing)
 	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY|os.O_CREATE, os.ModePerm)
 	if err != nil {
@@ -95,17 +118,23 @@ func Download(ctx context.Context, c *http.Client, since time.Time, fromLatest b
 
 	latestFile := filepath.Join(dir, "index.latest")
 	latest := since
+	cursor := ""
 	if fromLatest {
-		if t, err := getLatestTimestamp(latestFile); err != nil {
-			log.InfoContext(ctx, "Failed to get the latest timestamp", "error", err)
-		} else {
+		switch t, cur, err := readLatest(latestFile); {
+		case err != nil:
+			log.InfoContext(ctx, "Failed to get the latest cursor", "error", err)
+		case cur != "":
+			log.InfoContext(ctx, "Using latest cursor")
+			cursor = cur
+		default:
 			log.InfoContext(ctx, "Using latest", "since", t)
 			latest = *t
 		}
 	}
 
 	for {
-		results, err := doListRequest(ctx, c, u, objType, latest, batchSize, WithBearerTokenAuth(token))
+		// the cursor takes precedence over latest
+		results, err := doListRequest(ctx, c, u, objType, latest, cursor, batchSize, WithBearerTokenAuth(token))
 		if err != nil {
 			return err
 		}
@@ -115,8 +144,9 @@ func Download(ctx context.Context, c *http.Client, since time.Time, fromLatest b
 				return err
 			}
 
-			// update the latest timestamp
+			// update the latest position (older servers do not provide a cursor)
 			latest = result.CreatedAt
+			cursor = result.Cursor
 		}
 		w.Flush()
 
@@ -126,18 +156,69 @@ func Download(ctx context.Context, c *http.Client, since time.Time, fromLatest b
 		}
 
 		// we write out the latestFile here, as well, to better handle premature network failure
-		if err := os.WriteFile(latestFile, []byte(latest.Format(time.RFC3339Nano)), os.ModePerm); err != nil {
-			return fmt.Errorf("unable to incrementally write the latest timestamp file: %w", err)
+		if err := writeLatest(latestFile, latest, cursor); err != nil {
+			return fmt.Errorf("unable to incrementally write the latest cursor file: %w", err)
 		}
 	}
 
-	if err := os.WriteFile(latestFile, []byte(latest.Format(time.RFC3339Nano)), os.ModePerm); err != nil {
-		return fmt.Errorf("unable to write the final latest timestamp file: %w", err)
+	if err := writeLatest(latestFile, latest, cursor); err != nil {
+		return fmt.Errorf("unable to write the final latest cursor file: %w", err)
 	}
 
 	return nil
 }
 
+// pruneRemoved deletes the entries (and data files) of the index file that have digests of the removed bottles.
+func pruneRemoved(ctx context.Context, file string, tombstones []types.Tombstone) error {
+	log := logger.FromContext(ctx)
+	if len(tombstones) == 0 {
+		return nil
+	}
+	removed := map[string]bool{}
+	for _, tombstone := range tombstones {
+		for _, dgst := range tombstone.Digests {
+			// the data files are named with the digest (see writeRecordToCsv)
+			removed[dgst.Algorithm().String()+"-"+dgst.Encoded()] = true
+		}
+	}
+
+	index, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read index file: %w", err)
+	}
+	r := csv.NewReader(bytes.NewReader(index))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return fmt.Errorf("unable to parse index file: %w", err)
+	}
+
+	kept := make([][]string, 0, len(records))
+	for _, record := range records {
+		if len(record) == 0 || !removed[record[0]] {
+			kept = append(kept, record)
+			continue
+		}
+		log.InfoContext(ctx, "Discarding removed object", "file", record[0])
+		if err := os.Remove(filepath.Join(filepath.Dir(file), record[0])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to delete removed object: %w", err)
+		}
+	}
+	if len(kept) == len(records) {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.WriteAll(kept); err != nil {
+		return fmt.Errorf("unable to write index file: %w", err)
+	}
+	return os.WriteFile(file, buf.Bytes(), os.ModePerm)
+}
+
 // DownloadAll raw files given in the file of all types.
 func DownloadAll(ctx context.Context, c *http.Client, since time.Time, fromLatest bool, batchSize int, path string, u *url.URL, token string) error {
 	// iterate in reverse order
@@ -152,14 +233,20 @@ func DownloadAll(ctx context.Context, c *http.Client, since time.Time, fromLates
 }
 
 // Upload all raw files given in the file.
-func Upload(ctx context.Context, c *http.Client, file string, u *url.URL, token string, skipInvalid bool) error {
+// The options (e.g., WithRequestSignature) are applied to every request.
+func Upload(ctx context.Context, c *http.Client, file string, u *url.URL, token string, skipInvalid bool, options ...AuthRequestOptsFunc) error {
 	dir := filepath.Dir(file)
 	objType := filepath.Base(dir)
 	log := logger.FromContext(ctx).With("type", objType)
+	options = append([]AuthRequestOptsFunc{WithBearerTokenAuth(token)}, options...)
 
 	return processIndexFile(file, func(datafile string, dgst digest.Digest, data []byte) error {
 		log.InfoContext(ctx, "Uploading", "objType", objType, "file", datafile, "algorithm", dgst.Algorithm())
-		err := doPutRequest(ctx, c, u, objType, data, dgst.Algorithm(), WithBearerTokenAuth(token))
+		err := doPutRequest(ctx, c, u, objType, data, dgst.Algorithm(), options...)
+		if errors.Is(err, ErrRemoved) {
+			log.InfoContext(ctx, "Not uploading removed object", "file", datafile, "error", err)
+			return nil
+		}
 		if err != nil {
 			target := &types.MissingDigestsError{}
 			if errors.As(err, &target) && skipInvalid {
@@ -172,10 +259,10 @@ func Upload(ctx context.Context, c *http.Client, file string, u *url.URL, token
 }
 
 // UploadAll raw files given in the file of all types.
-func UploadAll(ctx context.Context, c *http.Client, path string, u *url.URL, token string, skipInvalid bool) error {
+func UploadAll(ctx context.Context, c *http.Client, path string, u *url.URL, token string, skipInvalid bool, options ...AuthRequestOptsFunc) error {
 	for _, objType := range types.TopologicalOrderingOfTypes {
 		file := filepath.Join(path, objType, "index.csv")
-		if err := Upload(ctx, c, file, u, token, skipInvalid); err != nil {
+		if err := Upload(ctx, c, file, u, token, skipInvalid, options...); err != nil {
 			return err
 		}
 	}
@@ -244,10 +331,131 @@ func doPutRequest(ctx context.Context, c *http.Client,
 	if res.Header.Get(types.HeaderContentDigest) == "" {
 		return fmt.Errorf("expected header %s is missing", types.HeaderContentDigest)
 	}
+	if res.StatusCode == http.StatusAccepted {
+		log.InfoContext(ctx, "Queued by the server until the objects it depends on are uploaded", "digest", dgst)
+	}
 
 	return res.Body.Close()
 }
 
+// listTombstones returns the bottles removed from the server.
+func listTombstones(ctx context.Context, c *http.Client, u *url.URL, options ...AuthRequestOptsFunc) ([]types.Tombstone, error) {
+	uu := *u
+	uu.Path += "/tombstone"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uu.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tombstone request: %w", err)
+	}
+
+	for _, fn := range options {
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := doRequest(req, c)
+	if err != nil {
+		return nil, err
+	}
+
+	results := struct {
+		Results []types.Tombstone
+	}{}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("unable to decode tombstones: %w", err)
+	}
+	return results.Results, nil
+}
+
+// RemoveBottle removes the bottle from the server (it requires an admin).  It returns ErrNotFound if the server does not have the bottle.
+func RemoveBottle(ctx context.Context, c *http.Client, u *url.URL, dgst digest.Digest, reason string, options ...AuthRequestOptsFunc) (*types.Tombstone, error) {
+	uu := *u
+	uu.Path += "/bottle"
+	uu.RawQuery = url.Values{
+		"digest": []string{dgst.String()},
+		"reason": []string{reason},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uu.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create remove request: %w", err)
+	}
+
+	for _, fn := range options {
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to perform HTTP delete request: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("bottle %s: %w", dgst, ErrNotFound)
+	case res.StatusCode >= http.StatusMultipleChoices:
+		return nil, processErrorResponse(res)
+	}
+
+	tombstone := &types.Tombstone{}
+	if err := json.NewDecoder(res.Body).Decode(tombstone); err != nil {
+		return nil, fmt.Errorf("unable to decode tombstone: %w", err)
+	}
+	return tombstone, nil
+}
+
+// doBulkRequest sends the records as newline delimited JSON to the bulk handler.
+func doBulkRequest(ctx context.Context, c *http.Client,
+	u *url.URL,
+	records []types.BulkRecord, options ...AuthRequestOptsFunc,
+) ([]types.BulkRecordStatus, error) {
+	log := logger.FromContext(ctx).WithGroup("bulk-request")
+	ctx = logger.NewContext(ctx, log)
+
+	body := &bytes.Buffer{}
+	enc := json.NewEncoder(body)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return nil, fmt.Errorf("unable to encode bulk record: %w", err)
+		}
+	}
+
+	uu := *u
+	uu.Path += "/bulk"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uu.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", types.MediaTypeNDJSON)
+
+	for _, fn := range options {
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+	}
+
+	log.DebugContext(ctx, "Request", "url", req.URL, "records", len(records))
+
+	resBody, err := doRequest(req, c)
+	if err != nil {
+		return nil, err
+	}
+
+	results := struct {
+		Results []types.BulkRecordStatus
+	}{}
+	if err := json.Unmarshal(resBody, &results); err != nil {
+		return nil, fmt.Errorf("unable to decode bulk response: %w", err)
+	}
+
+	return results.Results, nil
+}
+
 // processResponse will process the responseData byte and unmarshall to give.
 func processErrorResponse(response *http.Response) error {
 	data, err := io.ReadAll(response.Body)
@@ -261,6 +469,9 @@ func processErrorResponse(response *http.Response) error {
 		}
 		return &result
 	}
+	if response.StatusCode == http.StatusGone {
+		return fmt.Errorf("%w: %s", ErrRemoved, string(data))
+	}
 	return fmt.Errorf("unknown HTTP error: %s, %s", response.Status, string(data))
 }
 
@@ -302,10 +513,11 @@ func doGetRequest(ctx context.Context, c *http.Client,
 }
 
 // doListRequest actually makes the request to the handler if given, otherwise to the url in the request.
+// The listing starts after the cursor if not empty, otherwise after since.
 func doListRequest(ctx context.Context, c *http.Client,
 	u *url.URL,
 	objType string,
-	since time.Time, limit int, options ...AuthRequestOptsFunc,
+	since time.Time, cursor string, limit int, options ...AuthRequestOptsFunc,
 ) ([]types.ListResultEntry, error) {
 	log := logger.FromContext(ctx).WithGroup("list-request")
 	ctx = logger.NewContext(ctx, log)
@@ -317,10 +529,15 @@ func doListRequest(ctx context.Context, c *http.Client,
 
 	uu := *u
 	uu.Path += entry.Path
-	uu.RawQuery = url.Values{
-		"since": []string{since.Format(time.RFC3339Nano)},
+	query := url.Values{
 		"limit": []string{strconv.Itoa(limit)},
-	}.Encode()
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	} else {
+		query.Set("since", since.Format(time.RFC3339Nano))
+	}
+	uu.RawQuery = query.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uu.String(), nil)
 	if err != nil {
@@ -518,6 +735,74 @@ func BottleSearch(ctx context.Context, c *http.Client, handler http.Handler,
 	return results.Results, nil
 }
 
+// Search searches for bottles with the filters of the catalog of the web app and returns a page of results.
+func Search(ctx context.Context, c *http.Client, u *url.URL, query types.SearchQuery, options ...AuthRequestOptsFunc) (*types.SearchResponse, error) {
+	log := logger.FromContext(ctx).WithGroup("search")
+	ctx = logger.NewContext(ctx, log)
+
+	uu := *u
+	uu.Path += "/catalog"
+	uu.RawQuery = query.Values().Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uu.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create search request: %w", err)
+	}
+
+	for _, fn := range options {
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := doRequest(req, c)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.SearchResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetLineage returns the lineage graph (ancestors and descendants) of the bottle with the digest.
+func GetLineage(ctx context.Context, c *http.Client, u *url.URL, dgst digest.Digest, ancestors, descendants uint, options ...AuthRequestOptsFunc) (*types.Lineage, error) {
+	log := logger.FromContext(ctx).WithGroup("get-lineage")
+	ctx = logger.NewContext(ctx, log)
+
+	uu := *u
+	uu.Path += "/lineage"
+	uu.RawQuery = url.Values{
+		"digest":      []string{dgst.String()},
+		"ancestors":   []string{strconv.FormatUint(uint64(ancestors), 10)},
+		"descendants": []string{strconv.FormatUint(uint64(descendants), 10)},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uu.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create lineage request: %w", err)
+	}
+
+	for _, fn := range options {
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := doRequest(req, c)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := &types.Lineage{}
+	if err := json.Unmarshal(body, lineage); err != nil {
+		return nil, err
+	}
+	return lineage, nil
+}
+
 func writeRecordToCsv(log *slog.Logger, result types.ListResultEntry, dir string, w *csv.Writer) error {
 	// We just pick the first one (these are sorted by the server)
 	primaryDigest := result.Digests[0]
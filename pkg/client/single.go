@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"net/http"
@@ -29,6 +30,9 @@ type Single struct {
 
 	// Token used to make authenticated http calls
 	token string
+
+	// signingKey signs the body of upload requests (optional)
+	signingKey ed25519.PrivateKey
 }
 
 // NewSingleClient creates a new client to connect to the given telemetry server.
@@ -51,6 +55,11 @@ func NewSingleClient(httpClient *http.Client, serverURL string, token string) (*
 	}, nil
 }
 
+// SetSigningKey sets the ed25519 key used to sign the body of upload requests.  Requests are not signed when key is nil.
+func (sc *Single) SetSigningKey(key ed25519.PrivateKey) {
+	sc.signingKey = key
+}
+
 // SendEvent will send an event JSON to the api.
 func (sc *Single) SendEvent(ctx context.Context, alg digest.Algorithm, eventJSON, manifestJSON, bottleConfigJSON []byte, getArtifactData GetArtifactDataFunc) error {
 	log := logger.FromContext(ctx)
@@ -132,47 +141,52 @@ func (sc *Single) SendSignature(ctx context.Context, alg digest.Algorithm, signa
 
 // PutEvent will make a put event request to the api.
 func (sc *Single) PutEvent(ctx context.Context, alg digest.Algorithm, eventJSON []byte) error {
-	return doPutRequest(ctx, sc.client, sc.apiURL, "event", eventJSON, alg, WithBearerTokenAuth(sc.token))
+	return doPutRequest(ctx, sc.client, sc.apiURL, "event", eventJSON, alg, WithBearerTokenAuth(sc.token), WithRequestSignature(sc.signingKey))
 }
 
 // PutManifest will make a put manifest request to the api.
 func (sc *Single) PutManifest(ctx context.Context, alg digest.Algorithm, manifestJSON []byte) error {
-	return doPutRequest(ctx, sc.client, sc.apiURL, "manifest", manifestJSON, alg, WithBearerTokenAuth(sc.token))
+	return doPutRequest(ctx, sc.client, sc.apiURL, "manifest", manifestJSON, alg, WithBearerTokenAuth(sc.token), WithRequestSignature(sc.signingKey))
 }
 
 // PutBottle will make a put bottle request to the api.
 func (sc *Single) PutBottle(ctx context.Context, alg digest.Algorithm, bottleConfigJSON []byte) error {
-	return doPutRequest(ctx, sc.client, sc.apiURL, "bottle", bottleConfigJSON, alg, WithBearerTokenAuth(sc.token))
+	return doPutRequest(ctx, sc.client, sc.apiURL, "bottle", bottleConfigJSON, alg, WithBearerTokenAuth(sc.token), WithRequestSignature(sc.signingKey))
 }
 
 // PutSignature will make a put event request to the api.
 func (sc *Single) PutSignature(ctx context.Context, alg digest.Algorithm, signatureJSON []byte) error {
-	return doPutRequest(ctx, sc.client, sc.apiURL, "signature", signatureJSON, alg, WithBearerTokenAuth(sc.token))
+	return doPutRequest(ctx, sc.client, sc.apiURL, "signature", signatureJSON, alg, WithBearerTokenAuth(sc.token), WithRequestSignature(sc.signingKey))
 }
 
 // PutBlob will make a put blob request to the api.
 func (sc *Single) PutBlob(ctx context.Context, alg digest.Algorithm, blob []byte) error {
-	return doPutRequest(ctx, sc.client, sc.apiURL, "blob", blob, alg, WithBearerTokenAuth(sc.token))
+	return doPutRequest(ctx, sc.client, sc.apiURL, "blob", blob, alg, WithBearerTokenAuth(sc.token), WithRequestSignature(sc.signingKey))
+}
+
+// SendBulk will send the records to the api in one request.
+func (sc *Single) SendBulk(ctx context.Context, records []types.BulkRecord) ([]types.BulkRecordStatus, error) {
+	return doBulkRequest(ctx, sc.client, sc.apiURL, records, WithBearerTokenAuth(sc.token), WithRequestSignature(sc.signingKey))
 }
 
 // ListBlobs will make a get a blobs list using since.
-func (sc *Single) ListBlobs(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
-	return doListRequest(ctx, sc.client, sc.apiURL, "blob", since, limit, WithBearerTokenAuth(sc.token))
+func (sc *Single) ListBlobs(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
+	return doListRequest(ctx, sc.client, sc.apiURL, "blob", since, cursor, limit, WithBearerTokenAuth(sc.token))
 }
 
 // ListBottles will make a get a bottles list using since.
-func (sc *Single) ListBottles(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
-	return doListRequest(ctx, sc.client, sc.apiURL, "bottle", since, limit, WithBearerTokenAuth(sc.token))
+func (sc *Single) ListBottles(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
+	return doListRequest(ctx, sc.client, sc.apiURL, "bottle", since, cursor, limit, WithBearerTokenAuth(sc.token))
 }
 
 // ListManifests will make a get a manifests list using since.
-func (sc *Single) ListManifests(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
-	return doListRequest(ctx, sc.client, sc.apiURL, "manifest", since, limit, WithBearerTokenAuth(sc.token))
+func (sc *Single) ListManifests(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
+	return doListRequest(ctx, sc.client, sc.apiURL, "manifest", since, cursor, limit, WithBearerTokenAuth(sc.token))
 }
 
 // ListEvents will make a get an events list using since.
-func (sc *Single) ListEvents(ctx context.Context, since time.Time, limit int) ([]types.ListResultEntry, error) {
-	return doListRequest(ctx, sc.client, sc.apiURL, "event", since, limit, WithBearerTokenAuth(sc.token))
+func (sc *Single) ListEvents(ctx context.Context, since time.Time, cursor string, limit int) ([]types.ListResultEntry, error) {
+	return doListRequest(ctx, sc.client, sc.apiURL, "event", since, cursor, limit, WithBearerTokenAuth(sc.token))
 }
 
 // GetBlob will make a get blob request to the api with the digest.
@@ -197,12 +211,12 @@ func (sc *Single) GetEvent(ctx context.Context, dgst digest.Digest) ([]byte, err
 
 // Upload will upload files to api.
 func (sc *Single) Upload(ctx context.Context, file string, skipInvalid bool) error {
-	return Upload(ctx, sc.client, file, sc.apiURL, sc.token, skipInvalid)
+	return Upload(ctx, sc.client, file, sc.apiURL, sc.token, skipInvalid, WithRequestSignature(sc.signingKey))
 }
 
 // UploadAll will call UploadAll function from requests.
 func (sc *Single) UploadAll(ctx context.Context, path string, skipInvalid bool) error {
-	return UploadAll(ctx, sc.client, path, sc.apiURL, sc.token, skipInvalid)
+	return UploadAll(ctx, sc.client, path, sc.apiURL, sc.token, skipInvalid, WithRequestSignature(sc.signingKey))
 }
 
 // Download will use our client to call Download function.
@@ -225,7 +239,27 @@ func (sc *Single) BottleSearch(ctx context.Context, selectors []string, descript
 	return BottleSearch(ctx, sc.client, nil, sc.apiURL, selectors, description, limit, digestOnly)
 }
 
+// Search will return a page of the bottles that match the query.
+func (sc *Single) Search(ctx context.Context, query types.SearchQuery) (*types.SearchResponse, error) {
+	return Search(ctx, sc.client, sc.apiURL, query)
+}
+
+// GetLineage will return the lineage graph of the bottle.
+func (sc *Single) GetLineage(ctx context.Context, dgst digest.Digest, ancestors, descendants uint) (*types.Lineage, error) {
+	return GetLineage(ctx, sc.client, sc.apiURL, dgst, ancestors, descendants)
+}
+
 // GetBottlesFromMetric will return the bottles using metric.
 func (sc *Single) GetBottlesFromMetric(ctx context.Context, selectors []string, metric string, limit int, desc bool) ([]byte, error) {
 	return GetBottlesFromMetric(ctx, sc.client, nil, sc.apiURL, selectors, metric, limit, desc)
 }
+
+// RemoveBottle will remove the bottle from the api.
+func (sc *Single) RemoveBottle(ctx context.Context, dgst digest.Digest, reason string) error {
+	tombstone, err := RemoveBottle(ctx, sc.client, sc.apiURL, dgst, reason, WithBearerTokenAuth(sc.token))
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).InfoContext(ctx, "Removed bottle", "server", sc.apiURL.String(), "digests", tombstone.Digests)
+	return nil
+}
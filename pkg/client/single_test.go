@@ -2,6 +2,9 @@ package client
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -37,6 +40,9 @@ type SingleTestSuite struct {
 	ctx     context.Context
 	client  *Single
 	clientB *Single
+
+	// signingKey is registered with the server mounted at /signed that requires signed uploads
+	signingKey ed25519.PrivateKey
 }
 
 func (s *SingleTestSuite) getBlobByDigest(dgst digest.Digest) ([]byte, error) {
@@ -64,6 +70,17 @@ func (s *SingleTestSuite) SetupTest() {
 	serveMux := http.NewServeMux()
 	serveMux.Handle("/api/", http.StripPrefix("/api", httputil.LoggingMiddleware(s.log)(middleware.DatabaseMiddleware(myDB)(apiMux))))
 
+	pubkey, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	s.NoError(err)
+	s.signingKey = signingKey
+	verifier, err := middleware.NewSignatureVerifier(v1alpha2.RequestSigning{
+		Required: true,
+		Keys:     []v1alpha2.ClientKey{{Name: "edge", PublicKey: base64.StdEncoding.EncodeToString(pubkey)}},
+	})
+	s.NoError(err)
+	serveMux.Handle("/signed/api/", http.StripPrefix("/signed/api",
+		httputil.LoggingMiddleware(s.log)(middleware.DatabaseMiddleware(myDB)(middleware.SignatureVerifyMiddleware(verifier)(apiMux)))))
+
 	// process and load the blobs
 	s.blobs = make(map[digest.Digest][]byte)
 	err = processIndexFile(filepath.Join(s.dataDir, "blob", "index.csv"), func(datafile string, dgst digest.Digest, data []byte) error {
@@ -208,13 +225,39 @@ func (s *SingleTestSuite) TestSendEventSuccess() {
 	s.NoError(err)
 }
 
+func (s *SingleTestSuite) TestSendBulk() {
+	records := []types.BulkRecord{}
+	for _, item := range []struct{ objType, file string }{
+		{"event", "pull4.json"},
+		{"manifest", "manifest4.json"},
+		{"bottle", "bottle4.json"},
+		{"event", "pull1.json"},
+	} {
+		data, err := os.ReadFile(filepath.Join(s.dataDir, item.objType, item.file))
+		s.NoError(err)
+		records = append(records, types.BulkRecord{Type: item.objType, Data: data})
+	}
+
+	statuses, err := s.client.SendBulk(s.ctx, records)
+	s.NoError(err)
+	s.Len(statuses, len(records))
+	s.True(statuses[0].OK())
+	s.True(statuses[1].OK())
+	s.True(statuses[2].OK())
+	s.False(statuses[3].OK())
+	s.NotEmpty(statuses[3].MissingDigests)
+
+	_, err = s.client.GetEvent(s.ctx, statuses[0].Digest)
+	s.NoError(err)
+}
+
 func (s *SingleTestSuite) TestListBlobs() {
 	s.NoError(s.client.UploadAll(s.ctx, s.dataDir, false))
 
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getblob, err := s.client.ListBlobs(s.ctx, askTime, 10)
+	getblob, err := s.client.ListBlobs(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getblob)
@@ -227,13 +270,35 @@ func (s *SingleTestSuite) TestListBlobs() {
 	}
 }
 
+func (s *SingleTestSuite) TestListBlobsCursor() {
+	s.NoError(s.client.UploadAll(s.ctx, s.dataDir, false))
+
+	all, err := s.client.ListBlobs(s.ctx, time.Time{}, "", 100)
+	s.NoError(err)
+
+	// page through with the cursor
+	paged := []types.ListResultEntry{}
+	cursor := ""
+	for {
+		page, err := s.client.ListBlobs(s.ctx, time.Time{}, cursor, 3)
+		s.NoError(err)
+		paged = append(paged, page...)
+		if len(page) < 3 {
+			break
+		}
+		cursor = page[len(page)-1].Cursor
+		s.NotEmpty(cursor)
+	}
+	s.Equal(all, paged)
+}
+
 func (s *SingleTestSuite) TestListBottles() {
 	s.NoError(s.client.UploadAll(s.ctx, s.dataDir, false))
 
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getbottles, err := s.client.ListBottles(s.ctx, askTime, 10)
+	getbottles, err := s.client.ListBottles(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getbottles)
@@ -251,7 +316,7 @@ func (s *SingleTestSuite) TestListManifests() {
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getManifests, err := s.client.ListManifests(s.ctx, askTime, 10)
+	getManifests, err := s.client.ListManifests(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getManifests)
@@ -269,7 +334,7 @@ func (s *SingleTestSuite) TestListEvents() {
 	askTime, err := time.Parse(time.RFC3339, "2021-11-15T11:06:36.762880891-05:00")
 	s.NoError(err)
 
-	getEvents, err := s.client.ListEvents(s.ctx, askTime, 10)
+	getEvents, err := s.client.ListEvents(s.ctx, askTime, "", 10)
 	s.NoError(err)
 
 	s.NotEmpty(getEvents)
@@ -405,6 +470,32 @@ func (s *SingleTestSuite) TestUploadAllFromConfig() {
 	s.NoError(err)
 }
 
+func (s *SingleTestSuite) TestUploadAllSigned() {
+	sc, err := NewSingleClient(s.server.Client(), s.server.URL+"/signed", "")
+	s.Require().NoError(err)
+
+	// unsigned
+	s.Error(sc.Upload(s.ctx, filepath.Join(s.dataDir, "blob", "index.csv"), false))
+
+	// signed by an unregistered key
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	s.Require().NoError(err)
+	sc.SetSigningKey(otherKey)
+	s.Error(sc.Upload(s.ctx, filepath.Join(s.dataDir, "blob", "index.csv"), false))
+
+	sc.SetSigningKey(s.signingKey)
+	s.NoError(sc.UploadAll(s.ctx, s.dataDir, false))
+
+	results, err := sc.SendBulk(s.ctx, []types.BulkRecord{{Type: "blob", Data: []byte("signed")}})
+	s.NoError(err)
+	s.Require().Len(results, 1)
+	s.True(results[0].OK())
+
+	// reads are not signed
+	_, err = sc.ListBlobs(s.ctx, time.Time{}, "", 10)
+	s.NoError(err)
+}
+
 func (s *SingleTestSuite) TestGetLocations() {
 	s.NoError(s.client.UploadAll(s.ctx, s.dataDir, false))
 
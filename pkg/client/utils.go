@@ -8,6 +8,9 @@ import (
 var (
 	// ErrNotFound indicates that a request returned no results.
 	ErrNotFound = errors.New("no records found for requested object")
+
+	// ErrRemoved indicates that the server does not accept the object because it was removed by an administrator.
+	ErrRemoved = errors.New("removed by an administrator")
 )
 
 // import lop "github.com/samber/lo/parallel"
@@ -35,8 +38,9 @@ func parallelMap[T any, R any](collection []T, iteratee func(T, int) R) []R {
 	return result
 }
 
-// genericGet iterates over our different multiClients and invokes our Get Request function for each Type, returning an array of the result type and an error.
-func genericGet[T any](mc *MultiClient, get func(client Client) ([]T, error)) ([]T, error) {
+// genericGet iterates over our different multiClients and invokes our Get Request function for each Type, returning the result of the first client that has it and an error.
+func genericGet[R any](mc *MultiClient, get func(client Client) (R, error)) (R, error) {
+	var none R
 	for _, client := range mc.clients {
 		result, err := get(client)
 		if err == nil {
@@ -48,8 +52,8 @@ func genericGet[T any](mc *MultiClient, get func(client Client) ([]T, error)) ([
 			continue
 		}
 		// TODO advanced: should we ignore errors or collect them until we find the blob.
-		return nil, err
+		return none, err
 	}
 	// failed to find it
-	return nil, ErrNotFound
+	return none, ErrNotFound
 }
@@ -0,0 +1,178 @@
+package types
+
+import "time"
+
+// EventInterval is the width of the time buckets of event analytics.
+type EventInterval string
+
+// Event intervals.
+const (
+	IntervalHour  EventInterval = "hour"
+	IntervalDay   EventInterval = "day"
+	IntervalWeek  EventInterval = "week" // weeks start on Monday
+	IntervalMonth EventInterval = "month"
+)
+
+// EventGroupBy is what the events of event analytics are grouped by (each group is a series).
+type EventGroupBy string
+
+// Event groupings.  The empty EventGroupBy puts all events in one series.
+const (
+	GroupByBottle     EventGroupBy = "bottle"
+	GroupByRepository EventGroupBy = "repository"
+	GroupByUsername   EventGroupBy = "username"
+	GroupByAction     EventGroupBy = "action"
+)
+
+// EventFilter selects events by action, time range, and the labels of their bottles.
+// The zero value selects all events.
+type EventFilter struct {
+	// LabelSelectors are label selectors (e.g., "type=dataset,size>=10"), the bottle of an event must match one of them
+	LabelSelectors []string `schema:"label-selector"`
+
+	// Action is the action ("pull" or "push") of the events
+	Action string `schema:"action"`
+
+	// Start and End are the time range (inclusive start, exclusive end) of the events (RFC 3339 or milliseconds since the Unix epoch)
+	Start time.Time `schema:"start"`
+	End   time.Time `schema:"end"`
+}
+
+// EventAnalyticsQuery selects the events to count and how to bucket and group them.
+// The zero value counts all events by day in one series.
+type EventAnalyticsQuery struct {
+	EventFilter
+
+	// Interval is the width of the time buckets (defaults to "day")
+	Interval EventInterval `schema:"interval"`
+
+	// GroupBy makes a series for each bottle, repository, username, or action
+	GroupBy EventGroupBy `schema:"group-by"`
+}
+
+// EventAnalytics is the number of events in each time bucket for each group.
+// Events that were removed by garbage collection are not included since their time is not retained.
+type EventAnalytics struct {
+	Interval EventInterval
+	GroupBy  EventGroupBy `json:",omitempty"`
+
+	// Series are ordered by group
+	Series []EventSeries
+}
+
+// EventSeries is the number of events in each time bucket for one group.
+type EventSeries struct {
+	// Group is the bottle digest, repository, username, or action (empty when not grouped)
+	Group string `json:",omitempty"`
+
+	// Total is the number of events in all buckets
+	Total int64
+
+	// Buckets are ordered by time.  Buckets without events are omitted.
+	Buckets []EventBucket
+}
+
+// EventBucket is the number of events in a time bucket.
+type EventBucket struct {
+	// Start is the start (in UTC) of the bucket
+	Start time.Time
+	Count int64
+}
+
+// TransferQuery selects the events of transfer statistics.
+// The zero value includes all events with the throughput by day.
+type TransferQuery struct {
+	EventFilter
+
+	// Interval is the width of the time buckets of the throughput (defaults to "day")
+	Interval EventInterval `schema:"interval"`
+
+	// Limit is the number of bottles, repositories, and users (with the most bytes transferred) to include (defaults to 20)
+	Limit int `schema:"limit"`
+}
+
+// TransferStats is the volume of data transferred by events and the throughput of each repository.
+// The bytes transferred by an event is the size of its bottle (the sum of the sizes of its parts)
+// so events that transfer only some of the parts are overcounted.
+type TransferStats struct {
+	Interval EventInterval
+
+	// Bottles, Repositories, and Users are ordered by bytes transferred (most first).
+	// Events that were removed by garbage collection (with their counts kept) are included in Bottles and Users unless
+	// the query has a time range.  Repositories only include retained events since the repository is not counted.
+	Bottles      []TransferTotal
+	Repositories []TransferTotal
+	Users        []TransferTotal
+
+	// Throughput is the average bandwidth of each repository over time (ordered by repository).  It only includes retained events.
+	Throughput []ThroughputSeries
+}
+
+// TransferTotal is the number of events and bytes transferred for a bottle (digest), repository, or user.
+type TransferTotal struct {
+	Name   string
+	Events int64
+	Bytes  uint64
+}
+
+// ThroughputSeries is the average bandwidth of a repository in each time bucket.
+type ThroughputSeries struct {
+	Repository string
+
+	// Buckets are ordered by time.  Buckets without events that report their bandwidth are omitted.
+	Buckets []ThroughputBucket
+}
+
+// ThroughputBucket is the average bandwidth of the events (that report their bandwidth) in a time bucket.
+type ThroughputBucket struct {
+	// Start is the start (in UTC) of the bucket
+	Start time.Time
+
+	// Events is the number of events that report their bandwidth
+	Events int64
+
+	// Bandwidth is the average bandwidth in bytes per second
+	Bandwidth float64
+}
+
+// StorageQuery selects the bottles of storage statistics.
+// The zero value includes all bottles.
+type StorageQuery struct {
+	// LabelSelectors are label selectors (e.g., "type=dataset,size>=10"), the bottles must match one of them
+	LabelSelectors []string `schema:"label-selector"`
+}
+
+// StorageStats is the storage in registries of the layers of the manifests of bottles.
+// Every manifest of a bottle (e.g., compressed differently) is counted except in DeduplicatedBytes.
+type StorageStats struct {
+	Bottles   int64 // bottles with a manifest
+	Manifests int64
+	Layers    int64
+
+	// UncompressedBytes is the sum of the sizes of the parts of the layers
+	UncompressedBytes int64
+
+	// CompressedBytes is the sum of the sizes of the layers (as stored in registries)
+	CompressedBytes int64
+
+	// DeduplicatedBytes is the sum of the sizes of the distinct layers (by digest).
+	// This is the storage of a registry with all the manifests since layers are shared across bottles and manifests.
+	DeduplicatedBytes int64
+
+	// ArchivedLayers are the layers of parts that are archived (directories and the files of older bottles)
+	ArchivedLayers int64
+
+	// MediaTypes is the storage of the layers of each media type (ordered by media type)
+	MediaTypes []MediaTypeStorage
+}
+
+// MediaTypeStorage is the storage of the layers with a media type.
+type MediaTypeStorage struct {
+	MediaType  string
+	Archived   bool
+	Compressed bool
+
+	Layers            int64
+	UncompressedBytes int64
+	CompressedBytes   int64
+}
@@ -0,0 +1,48 @@
+package types
+
+import (
+	"github.com/opencontainers/go-digest"
+)
+
+// MediaTypeNDJSON is the media type for newline delimited JSON used by the bulk endpoint.
+const MediaTypeNDJSON = "application/x-ndjson"
+
+// BulkRecord is a single typed object in a bulk request.
+// The request body is a stream of newline delimited BulkRecords (in any order).
+type BulkRecord struct {
+	// Type is the type of object (one of TopologicalOrderingOfTypes)
+	Type string `json:"type"`
+
+	// Digest is the digest of the data.  It is verified by the server.  If not provided then the data is digested with SHA-256.
+	Digest digest.Digest `json:"digest,omitempty"`
+
+	// Data is the raw data of the object (base64 encoded in JSON)
+	Data []byte `json:"data"`
+}
+
+// BulkRecordStatus is the outcome of processing a single BulkRecord.
+type BulkRecordStatus struct {
+	// Index is the position of the record in the request (zero based)
+	Index int `json:"index"`
+
+	// Type is the type of object
+	Type string `json:"type"`
+
+	// Digest is the digest of the data
+	Digest digest.Digest `json:"digest,omitempty"`
+
+	// StatusCode is the HTTP status code that would have been returned if the record was PUT on its own
+	// (201 when created, 202 when queued to wait for the objects it depends on, 204 when it already existed, 4xx on error)
+	StatusCode int `json:"statusCode"`
+
+	// Error is the reason the record was rejected
+	Error string `json:"error,omitempty"`
+
+	// MissingDigests are the digests of objects this record depends on that are not known (or is waiting for when queued)
+	MissingDigests []digest.Digest `json:"missingDigests,omitempty"`
+}
+
+// OK returns true if the record was accepted.
+func (s *BulkRecordStatus) OK() bool {
+	return s.StatusCode >= 200 && s.StatusCode < 300
+}
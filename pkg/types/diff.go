@@ -0,0 +1,106 @@
+package types
+
+import (
+	"github.com/opencontainers/go-digest"
+)
+
+// DiffChange is how an item of a bottle changed between two bottles.
+type DiffChange string
+
+const (
+	// DiffAdded is an item that is only in the newer bottle.
+	DiffAdded DiffChange = "added"
+
+	// DiffRemoved is an item that is only in the older bottle.
+	DiffRemoved DiffChange = "removed"
+
+	// DiffChanged is an item that is in both bottles with different values.
+	DiffChanged DiffChange = "changed"
+
+	// DiffRenamed is a part with the same digest and a different name.
+	DiffRenamed DiffChange = "renamed"
+)
+
+// BottleDiff is the structural difference between two bottles (from the older bottle "From" to the newer bottle "To").
+// Only the items that differ are included.
+type BottleDiff struct {
+	From digest.Digest
+	To   digest.Digest
+
+	// Deprecates is true when the newer bottle deprecates the older bottle
+	Deprecates bool
+
+	// Description is the change to the description (nil if it is unchanged)
+	Description *ValueDiff `json:",omitempty"`
+
+	Parts           []PartDiff     `json:",omitempty"`
+	Labels          []ValueDiff    `json:",omitempty"`
+	Annotations     []ValueDiff    `json:",omitempty"`
+	Metrics         []MetricDiff   `json:",omitempty"`
+	Sources         []ValueDiff    `json:",omitempty"`
+	Authors         []AuthorDiff   `json:",omitempty"`
+	PublicArtifacts []ArtifactDiff `json:",omitempty"`
+}
+
+// Empty returns true if the bottles do not differ.
+func (d BottleDiff) Empty() bool {
+	return d.Description == nil && len(d.Parts) == 0 && len(d.Labels) == 0 && len(d.Annotations) == 0 &&
+		len(d.Metrics) == 0 && len(d.Sources) == 0 && len(d.Authors) == 0 && len(d.PublicArtifacts) == 0
+}
+
+// ValueDiff is a change to a named value (e.g., a label or the URI of a source).
+type ValueDiff struct {
+	Name   string `json:",omitempty"`
+	Change DiffChange
+	From   string `json:",omitempty"`
+	To     string `json:",omitempty"`
+}
+
+// PartDiff is a change to a part (matched by name, or by digest when it is renamed).
+type PartDiff struct {
+	Name   string
+	Change DiffChange
+
+	// FromName is the name of the part in the older bottle when it is renamed
+	FromName string `json:",omitempty"`
+
+	FromDigest digest.Digest `json:",omitempty"`
+	ToDigest   digest.Digest `json:",omitempty"`
+	FromSize   uint64        `json:",omitempty"`
+	ToSize     uint64        `json:",omitempty"`
+
+	// Labels are the changes to the part labels (when the part is in both bottles)
+	Labels []ValueDiff `json:",omitempty"`
+}
+
+// MetricDiff is a change to a metric.  From and To are nil when the metric is not in the bottle.
+type MetricDiff struct {
+	Name   string
+	Change DiffChange
+	From   *float64 `json:",omitempty"`
+	To     *float64 `json:",omitempty"`
+
+	// Delta is To - From (only when the metric is in both bottles)
+	Delta float64 `json:",omitempty"`
+}
+
+// AuthorDiff is a change to an author (matched by name).
+type AuthorDiff struct {
+	Name   string
+	Change DiffChange
+	From   *Author `json:",omitempty"`
+	To     *Author `json:",omitempty"`
+}
+
+// ArtifactDiff is a change to a public artifact (matched by path).
+type ArtifactDiff struct {
+	Path   string
+	Change DiffChange
+
+	// Name and MediaType are from the newer bottle (the older bottle if the artifact was removed)
+	Name      string
+	MediaType string
+
+	FromDigest digest.Digest `json:",omitempty"`
+	ToDigest   digest.Digest `json:",omitempty"`
+}
@@ -13,6 +13,12 @@ import (
 const (
 	// HeaderContentDigest is a header used to denote the body's digest.
 	HeaderContentDigest = "X-Content-Digest"
+
+	// HeaderSignature is a header used to carry the base64 encoded ed25519 signature of the request body.
+	HeaderSignature = "X-Signature"
+
+	// HeaderPublicKey is a header used to carry the base64 encoded ed25519 public key that verifies HeaderSignature.
+	HeaderPublicKey = "X-Publickey"
 )
 
 // MissingDigestsError is used to denote that Blobs are missing.
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"github.com/opencontainers/go-digest"
+)
+
+// LineageNodeKind is the kind of a node in a lineage graph.
+type LineageNodeKind string
+
+const (
+	// LineageNodeBottle is a bottle known to the telemetry server.
+	LineageNodeBottle LineageNodeKind = "bottle"
+
+	// LineageNodeUnknownBottle is a bottle that is not known to the telemetry server (only its digest is known).
+	LineageNodeUnknownBottle LineageNodeKind = "unknown-bottle"
+
+	// LineageNodeURI is a source that is not a bottle (e.g., a URL).
+	LineageNodeURI LineageNodeKind = "uri"
+)
+
+// Lineage is the graph of the ancestors and descendants of a bottle.
+type Lineage struct {
+	// Digest is the digest of the bottle the lineage is for
+	Digest digest.Digest
+
+	Nodes []LineageNode
+	Edges []LineageEdge
+}
+
+// LineageNode is a bottle or other source in a lineage graph.
+type LineageNode struct {
+	// ID is a digest of a bottle (the requested digest for the bottle of the lineage) or the URI of a non-bottle source.  Edges refer to nodes by ID.
+	ID   string
+	Kind LineageNodeKind
+
+	// Generation is relative to the bottle of the lineage (e.g., -1 for parents, 0 for the bottle, 1 for children)
+	Generation int
+
+	// Digests, Description, Labels, and Authors are only known for bottles known to the telemetry server
+	Digests     []digest.Digest   `json:",omitempty"`
+	Description string            `json:",omitempty"`
+	Labels      map[string]string `json:",omitempty"`
+	Authors     []Author          `json:",omitempty"`
+}
+
+// LineageEdge is a source of a bottle in a lineage graph.
+type LineageEdge struct {
+	// From is the ID of the source (parent) and To is the ID of the bottle (child)
+	From string
+	To   string
+
+	// Name is the name of the source in the child bottle
+	Name string
+
+	// PartSelectors limit the relationship to the selected parts of the source bottle (empty when the whole bottle is used)
+	PartSelectors []string `json:",omitempty"`
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// SearchQuery is a bottle search with the same filters as the catalog of the web application.
+// The query parameters have the same names as the catalog's so a catalog URL's query can be used as is.
+// The zero value matches every bottle that is not deprecated.
+type SearchQuery struct {
+	// LabelSelectors are label selectors (e.g., "type=dataset,size>=10"), a bottle must match one of them
+	LabelSelectors []string `schema:"label-selector"`
+
+	// Description is text to match (and rank by) in the description of the bottle
+	Description string `schema:"description"`
+
+	// Author is the email (when it contains "@") or part of the name of an author of the bottle
+	Author string `schema:"author"`
+
+	// Repository is a repository the bottle was pushed to or pulled from
+	Repository string `schema:"bottle-repository"`
+
+	// SignatureFingerprint is the fingerprint of the public key of a signature of the bottle
+	SignatureFingerprint digest.Digest `schema:"signature-fingerprint"`
+
+	// SignatureAnnotations are annotations ("key=value") of a signature of the bottle
+	SignatureAnnotations []string `schema:"signature-annotation"`
+
+	// ParentsOf matches the parents (sources) of the bottle
+	ParentsOf digest.Digest `schema:"parents-of"`
+
+	// ChildrenOf matches the children of the bottle (bottles it is a source of)
+	ChildrenOf digest.Digest `schema:"children-of"`
+
+	// DeprecatedBy matches the bottles deprecated by the bottle (deprecated bottles are included)
+	DeprecatedBy digest.Digest `schema:"deprecated-by"`
+
+	// Deprecates matches the bottles that deprecate the bottle
+	Deprecates digest.Digest `schema:"deprecates"`
+
+	// ShowDeprecated includes deprecated bottles
+	ShowDeprecated bool `schema:"show-deprecated"`
+
+	// PartDigests are digests of parts the bottle must have
+	PartDigests []digest.Digest `schema:"part-digest"`
+
+	// Metrics are metric filters ("name", "name>value", or "name<value") the bottle must match
+	Metrics []string `schema:"metric"`
+
+	// SortByMetric sorts the bottles by the value of the metric (descending unless MetricSortAscending)
+	SortByMetric        string `schema:"sort-by-metric"`
+	MetricSortAscending bool   `schema:"metric-sort-ascending"`
+
+	// CreatedBefore matches bottles the server received at or before the time (RFC 3339 or milliseconds since the Unix epoch)
+	CreatedBefore time.Time `schema:"created-before"`
+
+	// Limit is the number of bottles in a page of results (at most 100)
+	Limit int `schema:"limit"`
+
+	// Page is the (zero based) page of results
+	Page int `schema:"page"`
+}
+
+// Values returns the query as URL query parameters.  Fields with the zero value are omitted.
+func (q SearchQuery) Values() url.Values {
+	values := url.Values{}
+	setString := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	setBool := func(key string, value bool) {
+		if value {
+			values.Set(key, strconv.FormatBool(value))
+		}
+	}
+	setInt := func(key string, value int) {
+		if value != 0 {
+			values.Set(key, strconv.Itoa(value))
+		}
+	}
+
+	values["label-selector"] = q.LabelSelectors
+	setString("description", q.Description)
+	setString("author", q.Author)
+	setString("bottle-repository", q.Repository)
+	setString("signature-fingerprint", q.SignatureFingerprint.String())
+	values["signature-annotation"] = q.SignatureAnnotations
+	setString("parents-of", q.ParentsOf.String())
+	setString("children-of", q.ChildrenOf.String())
+	setString("deprecated-by", q.DeprecatedBy.String())
+	setString("deprecates", q.Deprecates.String())
+	setBool("show-deprecated", q.ShowDeprecated)
+	for _, part := range q.PartDigests {
+		values.Add("part-digest", part.String())
+	}
+	values["metric"] = q.Metrics
+	setString("sort-by-metric", q.SortByMetric)
+	setBool("metric-sort-ascending", q.MetricSortAscending)
+	if !q.CreatedBefore.IsZero() {
+		values.Set("created-before", q.CreatedBefore.Format(time.RFC3339Nano))
+	}
+	setInt("limit", q.Limit)
+	setInt("page", q.Page)
+
+	// remove the empty lists
+	for key, value := range values {
+		if len(value) == 0 {
+			delete(values, key)
+		}
+	}
+	return values
+}
+
+// SearchResponse is a page of the bottles that match a SearchQuery.
+type SearchResponse struct {
+	Results []SearchResponseEntry
+
+	// Total is the number of bottles that match the query (on all pages)
+	Total int64
+
+	// Page and Limit are the page of results and its size
+	Page  int
+	Limit int
+
+	// More is true when there are more results on the following pages
+	More bool
+}
+
+// SearchResponseEntry is a bottle that matches a SearchQuery.
+type SearchResponseEntry struct {
+	Digests    []digest.Digest
+	CreatedAt  time.Time
+	Deprecated bool
+	Pulls      int
+
+	// Bottle is the bottle's configuration
+	Bottle json.RawMessage
+}
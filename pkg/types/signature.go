@@ -116,6 +116,7 @@ type SignatureValid struct {
 	BottleID  digest.Digest `json:"subjectBottleID"` // bottle digest
 	KeyFp     string        `json:"keyFingerprint"`  // fingerprint of key
 	Validated bool          `json:"validated"`       // true if the validation process succeeds (validated or trusted)
+	Trusted   bool          `json:"trusted"`         // true if the signature satisfies the server's trust policy
 }
 
 // SignatureIdentity provides a simple view of a single signature's Identity information.
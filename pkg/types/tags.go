@@ -0,0 +1,33 @@
+package types
+
+import (
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// TagPush is a push of a tag of a repository.  The tag points to the manifest (and bottle) until the next push of the tag.
+type TagPush struct {
+	Repository     string
+	Tag            string
+	ManifestDigest digest.Digest
+	BottleDigest   digest.Digest
+	Timestamp      time.Time
+	Username       string
+}
+
+// TagHistory is the history of a tag of a repository.
+type TagHistory struct {
+	Tag string
+
+	// Pushes are ordered newest first so the first is what the tag points to now
+	Pushes []TagPush
+}
+
+// RepositoryTags is the history of the tags of a repository (as recorded by push events).
+type RepositoryTags struct {
+	Repository string
+
+	// Tags are ordered by tag
+	Tags []TagHistory
+}
@@ -4,13 +4,35 @@ import (
 	"time"
 
 	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
-// LocationResponse is a struct for the location.
+// LocationQuery selects the locations of a bottle.
+type LocationQuery struct {
+	BottleDigest digest.Digest `schema:"bottle_digest"`
+
+	// Registry is the host (e.g., "reg.example.com:5000") of the registry of the locations (all registries if empty)
+	Registry string `schema:"registry"`
+
+	// Public only includes locations that do not require authentication
+	Public bool `schema:"public"`
+}
+
+// LocationResponse is a location (repository and manifest) where a bottle was pulled or pushed.
+// Locations are ordered by LastSeen (most recent first).
 type LocationResponse struct {
 	Repository   string
 	AuthRequired bool
-	Digest       digest.Digest
+	Digest       digest.Digest // of the manifest
+
+	// LastSeen is the time of the most recent pull or push of the manifest in the repository
+	LastSeen time.Time
+
+	// Tags used to pull or push the manifest in the repository (ordered by tag)
+	Tags []string
+
+	// Layers of the manifest (one for each part)
+	Layers []ocispec.Descriptor
 }
 
 // ListResultEntry is a single entry in list request.
@@ -18,6 +40,9 @@ type ListResultEntry struct {
 	CreatedAt time.Time
 	Digests   []digest.Digest
 	Data      []byte
+
+	// Cursor is an opaque continuation token.  Listing with it resumes after this entry.
+	Cursor string `json:",omitempty"`
 }
 
 // SearchResult is a result from a BottleSearch Request.
@@ -28,3 +53,35 @@ type SearchResult struct {
 
 // TopologicalOrderingOfTypes is the list of different input types in the order they need to be process/applied.
 var TopologicalOrderingOfTypes = []string{"blob", "bottle", "manifest", "event", "signature"}
+
+// MediaTypeEventStream is the media type for Server-Sent Events.
+const MediaTypeEventStream = "text/event-stream"
+
+// Tombstone is a bottle that was removed by an administrator.
+// Objects with any of the digests (the removed bottle, its manifests, events, signatures, and artifacts) are not accepted by the server.
+type Tombstone struct {
+	BottleDigest digest.Digest
+	Digests      []digest.Digest
+	RemovedAt    time.Time
+
+	// Username and Reason are only included in the response to the removal
+	Username string `json:",omitempty"`
+	Reason   string `json:",omitempty"`
+}
+
+// PendingObject is an uploaded object that is waiting (in the server's pending queue) for the objects it depends on.
+// It is processed when they are uploaded unless it expires first.
+type PendingObject struct {
+	Type           string
+	Digest         digest.Digest
+	MissingDigests []digest.Digest
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// Author is an author of a bottle.
+type Author struct {
+	Name  string
+	Email string `json:",omitempty"`
+	URL   string `json:",omitempty"`
+}
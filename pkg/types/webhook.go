@@ -0,0 +1,98 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// Webhook events.
+const (
+	// WebhookEventBottle is sent when a bottle is ingested.
+	WebhookEventBottle = "bottle"
+
+	// WebhookEventDeprecation is sent when an ingested bottle deprecates another bottle.
+	WebhookEventDeprecation = "deprecation"
+
+	// WebhookEventSignature is sent when a validated signature of a bottle is ingested.
+	WebhookEventSignature = "signature"
+)
+
+// WebhookEvents is the list of all the webhook events.
+var WebhookEvents = []string{WebhookEventBottle, WebhookEventDeprecation, WebhookEventSignature}
+
+const (
+	// HeaderWebhookEvent is the webhook event of the payload.
+	HeaderWebhookEvent = "X-Telemetry-Event"
+
+	// HeaderWebhookDelivery is the unique ID of a webhook delivery.  Retries of a delivery have the same ID.
+	HeaderWebhookDelivery = "X-Telemetry-Delivery"
+
+	// HeaderWebhookSignature is the HMAC-SHA256 of the payload in the form "sha256=<hex>".
+	HeaderWebhookSignature = "X-Telemetry-Signature"
+)
+
+// WebhookPayload is the JSON body POSTed to a webhook.
+type WebhookPayload struct {
+	// Event is the webhook event (one of WebhookEvents)
+	Event string `json:"event"`
+
+	// Webhook is the name of the webhook in the server configuration
+	Webhook string `json:"webhook"`
+
+	// Timestamp is when the event occurred
+	Timestamp time.Time `json:"timestamp"`
+
+	// BottleDigest is the digest of the ingested bottle (or the signed bottle for signature events)
+	BottleDigest digest.Digest `json:"bottleDigest"`
+
+	// Labels are the labels of the bottle
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// DeprecatedBottleDigest is the digest of the bottle that is deprecated (only for deprecation events)
+	DeprecatedBottleDigest digest.Digest `json:"deprecatedBottleDigest,omitempty"`
+
+	// Signature describes the signature (only for signature events)
+	Signature *WebhookSignature `json:"signature,omitempty"`
+}
+
+// WebhookSignature describes a validated bottle signature in a WebhookPayload.
+type WebhookSignature struct {
+	// ManifestDigest is the digest of the signed manifest
+	ManifestDigest digest.Digest `json:"manifestDigest"`
+
+	// SignatureType is the type of signature (e.g., notary or cosign)
+	SignatureType string `json:"signatureType"`
+
+	// PublicKeyFingerprint is the fingerprint of the public key (or certificate chain) of the signer
+	PublicKeyFingerprint digest.Digest `json:"publicKeyFingerprint"`
+
+	// Annotations are the signature annotations
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// SignWebhookPayload returns the value of the HeaderWebhookSignature header for the payload.
+func SignWebhookPayload(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload) // hash.Hash never returns an error
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookPayload returns true if the HeaderWebhookSignature header value is a valid signature of the payload.
+func VerifyWebhookPayload(secret, payload []byte, signature string) bool {
+	encoded, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload) // hash.Hash never returns an error
+	return hmac.Equal(sig, mac.Sum(nil))
+}